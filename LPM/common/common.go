@@ -0,0 +1,107 @@
+/*/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Package common holds what the LPM packages share: archiving, the caller, composite keys and dates
+package common
+
+import (
+"strconv"
+"encoding/json"
+"time"
+
+"github.com/hyperledger/fabric/core/chaincode/shim"	
+)
+
+var IncludeArchived = "include_archived"			//optional list query argument that also returns archived Customers and Merchants
+var DateLayout = "2006-01-02"					//the date a transactionDateTime starts with, points lots are dated with it
+var KeySeparator = "\x00"						//separates the parts of a composite key, the same way Fabric does
+
+type Archive struct{							// Reason, caller and date a Customer or Merchant was archived with instead of being deleted
+	Reason string `json:"reason"`
+	ArchivedBy string `json:"archivedBy"`
+	ArchivedAt string `json:"archivedAt"`
+}
+
+// ============================================================================================================================
+// CompositeKey - composite key of a Balance or EarnRule, the prefix picks which ID comes first so that side can be range
+// scanned without one ID matching the start of another
+// ============================================================================================================================
+func CompositeKey(prefix string, firstId string, secondId string) string {
+	return prefix + firstId + KeySeparator + secondId + KeySeparator
+}
+// ============================================================================================================================
+// ValidDay - whether a date argument is empty or a YYYY-MM-DD date
+// ============================================================================================================================
+func ValidDay(date string) bool {
+	if date == "" {
+		return true
+	}
+	_, err := time.Parse(DateLayout, date)
+	return err == nil
+}
+// ============================================================================================================================
+// RoundPoints - round a points count or worth to the two decimals the ledger keeps
+// ============================================================================================================================
+func RoundPoints(value float64) float64 {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(value, 'f', 2, 64), 64)
+	return rounded
+}
+// ============================================================================================================================
+// ArchivedRecord - the first of the given Customer or Merchant IDs that is archived, "" when none is
+// ============================================================================================================================
+func ArchivedRecord(stub shim.ChaincodeStubInterface, ids ...string) string {
+	for _, id := range ids {
+		valueAsBytes, err := stub.GetState(id)
+		if err != nil {
+			continue
+		}
+		var record struct {
+			Archive *Archive `json:"archive"`
+		}
+		json.Unmarshal(valueAsBytes, &record)
+		if record.Archive != nil {
+			return id
+		}
+	}
+	return ""
+}
+// ============================================================================================================================
+// CallerName - username attribute of the caller's certificate, "" when it has none
+// ============================================================================================================================
+func CallerName(stub shim.ChaincodeStubInterface) string {
+	username, err := stub.ReadCertAttribute("username")
+	if err != nil {
+		return ""
+	}
+	return string(username)
+}
+// ============================================================================================================================
+// TxDate - YYYY-MM-DD date of the transaction timestamp, the latest day a client supplied date may be
+// ============================================================================================================================
+func TxDate(stub shim.ChaincodeStubInterface) (string, error) {
+	ts, err := stub.GetTxTimestamp()
+	if err != nil {
+		return "", err
+	}
+	return time.Unix(ts.Seconds, int64(ts.Nanos)).UTC().Format(DateLayout), nil
+}
+// ============================================================================================================================
+// WithArchived - whether the argument at position n asks a list query for archived records too
+// ============================================================================================================================
+func WithArchived(args []string, n int) bool {
+	return len(args) > n && args[n] == IncludeArchived
+}
@@ -0,0 +1,43 @@
+/*/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Package customers holds the Customer record of the LPM chaincode
+package customers
+
+import (
+
+
+"Blockchain/LPM/common"
+)
+
+var CustomerIndexStr = "_Customerindex"				// name for the key/value that will store a list of all known Customer
+
+type Customer struct{							// Attributes of a Customer 
+	CustomerID string `json:"customerId"`					
+	UserName string `json:"userName"`
+	CustomerName string `json:"customerName"`
+	WalletWorth string `json:"walletWorth"`
+	MerchantIDs string `json:"merchantIDs,omitempty"`				// legacy comma separated merchant lists aligned by position,
+	MerchantNames string `json:"merchantNames,omitempty"`			// migrateBalances moves them into Balance records
+	MerchantColors string `json:"merchantColors,omitempty"`
+	MerchantCurrencies string `json:"merchantCurrencies,omitempty"`
+	MerchantsPointsCount string `json:"merchantsPointsCount,omitempty"`
+	MerchantsPointsWorth string `json:"merchantsPointsWorth,omitempty"`
+	Archive *common.Archive `json:"archive,omitempty"`
+}
+
+
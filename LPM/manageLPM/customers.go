@@ -0,0 +1,662 @@
+/*/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+"errors"
+"fmt"
+"strconv"
+"encoding/json"
+"strings"
+
+"github.com/hyperledger/fabric/core/chaincode/shim"	
+
+"Blockchain/LPM/common"
+"Blockchain/LPM/customers"
+"Blockchain/LPM/merchants"
+"Blockchain/LPM/transactions"
+"Blockchain/LPM/points"
+)
+
+// ============================================================================================================================
+// getCustomerByID - get Customer details for a specific ID from chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) getCustomerByID(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var customerId string
+	var err error
+	fmt.Println("start getCustomerByID")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'customerId' as an argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set customerId
+	customerId = args[0]
+	fmt.Print("customerId in getCustomerByID : "+customerId)
+	valAsbytes, err := stub.GetState(customerId)									//get the customerId from chaincode state
+	if err != nil {
+		errMsg := "{ \"message\" : \""+ customerId + " not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	res := customers.Customer{}
+	json.Unmarshal(valAsbytes, &res)
+	if res.CustomerID == customerId {
+		valAsbytes, err = points.CustomerDetails(stub, res)							//add the Customer's Balances
+		if err != nil {
+			return nil, err
+		}
+	}
+	fmt.Print("valAsbytes : ")
+	fmt.Println(valAsbytes)
+	fmt.Println("end getCustomerByID")
+	return valAsbytes, nil													//send it onward
+}
+// ============================================================================================================================
+// getCustomerDetailsByID - get Customer details for a specific ID from chaincode state POST Implementation
+// ============================================================================================================================
+func (t *ManageLPM) getCustomerDetailsByID(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var customerId string
+	var err error
+	fmt.Println("start getCustomerDetailsByID")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'customerId' as an argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set customerId
+	customerId = args[0]
+	fmt.Print("customerId in getCustomerDetailsByID : "+customerId)
+	valAsbytes, err := stub.GetState(customerId)									//get the customerId from chaincode state
+	if err != nil {
+		errMsg := "{ \"message\" : \""+ customerId + " not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	res := customers.Customer{}
+	json.Unmarshal(valAsbytes, &res)
+	if res.CustomerID == customerId {
+		valAsbytes, err = points.CustomerDetails(stub, res)							//add the Customer's Balances
+		if err != nil {
+			return nil, err
+		}
+	}
+	fmt.Print("valAsbytes : ")
+	fmt.Println(valAsbytes)
+	fmt.Println("end getCustomerDetailsByID")
+	return valAsbytes, nil													//send it onward
+}
+// ============================================================================================================================
+//  getAllCustomers- get details of all Merchants from chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) getAllCustomers(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var jsonResp, errResp string
+	var customerIndex []string
+	var valIndex customers.Customer
+	var err error
+	fmt.Println("start getAllCustomers")
+		
+	customerAsBytes, err := stub.GetState(customers.CustomerIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Customer index")
+	}
+	json.Unmarshal(customerAsBytes, &customerIndex)			//un stringify it aka JSON.parse()
+	jsonResp = "{"
+	for i,val := range customerIndex{
+		fmt.Println(strconv.Itoa(i) + " - looking at " + val + " for all Customer")
+		valueAsBytes, err := stub.GetState(val)
+		if err != nil {
+			errResp = "{\"Error\":\"Failed to get state for " + val + "\"}"
+			return nil, errors.New(errResp)
+		}
+		fmt.Print("valueAsBytes : ")
+		fmt.Println(valueAsBytes)
+		valIndex = customers.Customer{}
+		json.Unmarshal(valueAsBytes, &valIndex)
+		if valIndex.Archive != nil && !common.WithArchived(args, 0) {
+			continue
+		}
+		detailsAsBytes, err := points.CustomerDetails(stub, valIndex)
+		if err != nil {
+			return nil, err
+		}
+		jsonResp = jsonResp + "\""+ val + "\":" + string(detailsAsBytes[:])
+		if i < len(customerIndex)-1 {
+			jsonResp = jsonResp + ","
+		}
+	}
+	jsonResp = jsonResp + "}"
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
+	if strings.Contains(jsonResp, "},}"){
+		fmt.Println("in if for jsonResp contains wrong json")	
+		jsonResp = strings.Replace(jsonResp, "},}", "}}", -1)
+	}
+	fmt.Println("jsonResp in getAllCustomers::")
+	fmt.Println(jsonResp)
+
+	fmt.Println("end getAllCustomers")
+	return []byte(jsonResp), nil			//send it onward
+}
+// ============================================================================================================================
+// getCustomersByMerchantID - get Customers for a specific Merchant ID from chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) getCustomersByMerchantID(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var jsonResp, merchantId, errResp string
+	var err error
+	var valIndex customers.Customer
+	fmt.Println("start getCustomersByMerchantID")
+	if len(args) < 1 || len(args) > 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'merchantId' and optionally 'include_archived' as arguments\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set merchantId
+	merchantId = args[0]
+
+	balances, err := points.MerchantBalances(stub, merchantId)							//only Customers with a Balance for exactly this merchantId
+	if err != nil {
+		return nil, err
+	}
+	jsonResp = "{"
+	for _, balance := range balances{
+		fmt.Println("looking at " + balance.CustomerID + " for getCustomersByMerchantID")
+		valueAsBytes, err := stub.GetState(balance.CustomerID)
+		if err != nil {
+			errResp = "{\"Error\":\"Failed to get state for " + balance.CustomerID + "\"}"
+			return nil, errors.New(errResp)
+		}
+		valIndex = customers.Customer{}
+		json.Unmarshal(valueAsBytes, &valIndex)
+		if valIndex.Archive != nil && !common.WithArchived(args, 1) {
+			continue
+		}
+		detailsAsBytes, err := points.CustomerDetails(stub, valIndex)
+		if err != nil {
+			return nil, err
+		}
+		jsonResp = jsonResp + "\""+ balance.CustomerID + "\":" + string(detailsAsBytes[:]) + ","
+	}
+	jsonResp = jsonResp + "}"
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
+	fmt.Println("jsonResp : " + jsonResp)
+	fmt.Print("jsonResp in bytes : ")
+	fmt.Println([]byte(jsonResp))
+	fmt.Println("end getCustomersByMerchantID")
+	return []byte(jsonResp), nil											//send it onward
+}
+// ============================================================================================================================
+// create Customer - create a new Customer, store into chaincode state. The Customer starts with an empty Balance with
+// every listed Merchant, points are only earned on chain
+// ============================================================================================================================
+func (t *ManageLPM) createCustomer(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	if len(args) != 13 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 13\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	fmt.Println("start createCustomer")
+	customerId := args[0]
+	userName := args[1]
+	customerName := args[2]
+	walletWorth := "0"							// args[3], args[8] and args[9] are kept for compatibility, balances start empty
+	merchantID := args[4]
+	merchantName := args[5]
+	merchantColor := args[6]
+	merchantCurrency := args[7]
+	transactionID := args[10]
+ 	transactionDateTime := args[11]
+	transactionType := args[12]
+
+	customerAsBytes, err := stub.GetState(customerId)
+	if err != nil {
+		return nil, errors.New("Failed to get Customer customerID")
+	}
+	res := customers.Customer{}
+	json.Unmarshal(customerAsBytes, &res)
+	if res.CustomerID == customerId{
+		errMsg := "{ \"message\" : \"This Customer arleady exists\", \"code\" : \"503\"}"
+		err := stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil				//all stop a Customer by this name exists
+	}
+	problem, err := transactions.IdProblem(stub, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	transactionTime, timeProblem := transactions.Stamp(transactionDateTime)
+	if problem == "" && transactionID == customerId {
+		problem = "The transactionId can't be the customerId"
+	} else if problem == "" {
+		problem = timeProblem
+	}
+	if problem != "" {
+		errMsg := "{ \"customerID\" : \""+customerId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	
+	//the merchant details on boarding are stored as the Customer's first Balances, without points
+	res = customers.Customer{CustomerID: customerId, UserName: userName, CustomerName: customerName, WalletWorth: walletWorth, MerchantIDs: merchantID, MerchantNames: merchantName,
+		MerchantColors: merchantColor, MerchantCurrencies: merchantCurrency}
+	balances := points.SplitBalances(res)
+	for i := range balances {
+		merchantAsBytes, err := stub.GetState(balances[i].MerchantID)
+		if err != nil {
+			return nil, errors.New("Failed to get Merchant " + balances[i].MerchantID)
+		}
+		res_Merchant := merchants.Merchant{}
+		json.Unmarshal(merchantAsBytes, &res_Merchant)
+		if res_Merchant.MerchantID != balances[i].MerchantID || res_Merchant.Archive != nil {
+			errMsg := "{ \"customerID\" : \""+customerId+"\", \"message\" : \"Merchant " + balances[i].MerchantID + " Not Found.\", \"code\" : \"503\"}"
+			err = stub.SetEvent("errEvent", []byte(errMsg))
+			if err != nil {
+				return nil, err
+			} 
+			return nil, nil
+		}
+	}
+	for _, balance := range balances {
+		err = points.PutBalance(stub, balance)
+		if err != nil {
+			return nil, err
+		}
+	}
+	res = customers.Customer{CustomerID: customerId, UserName: userName, CustomerName: customerName, WalletWorth: walletWorth}
+	customerAsBytes, _ = json.Marshal(res)
+	fmt.Println("customer_json: " + string(customerAsBytes))
+	err = stub.PutState(customerId, customerAsBytes)									//store Customer with customerId as key
+	if err != nil {
+		return nil, err
+	}
+	//get the Customer index
+	customerIndexAsBytes, err := stub.GetState(customers.CustomerIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Customer index")
+	}
+	var customerIndex []string	
+	json.Unmarshal(customerIndexAsBytes, &customerIndex)							//un stringify it aka JSON.parse()
+	
+	//append
+	customerIndex = append(customerIndex, customerId)									//add Customer customerID to index list
+	
+	jsonAsBytes, _ := json.Marshal(customerIndex)
+	fmt.Print("jsonAsBytes: ")
+	fmt.Println(jsonAsBytes)
+	err = stub.PutState(customers.CustomerIndexStr, jsonAsBytes)						//store name of Customer
+	if err != nil {
+		return nil, err
+	}
+
+	// build the Transaction json string manually
+	transaction_json := `{`+
+		`"transactionId": "` + transactionID + `" , `+
+		`"transactionDateTime": "` + transactionDateTime + `" , `+
+		`"transactionTime": ` + strconv.FormatInt(transactionTime, 10) + ` , `+
+		`"transactionType": "` + transactionType + `" , `+
+		`"transactionFrom": "` + merchantName + `" , `+ 
+		`"transactionTo": "` + userName + `" , `+ 
+		`"credit": "` + "0" + `" , `+ 
+		`"debit": "` + "0" + `" , `+ 
+		`"customerId": "` +  customerId + `" , `+ 
+		`"merchantId": "` +  merchantID + `" `+ 
+	`}`
+	err = stub.PutState(transactionID, []byte(transaction_json))					//store Transaction with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	//get the Transaction index
+	transactionAsBytes, err := stub.GetState(transactions.TransactionIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Transaction index")
+	}
+	var transactionIndex []string	
+	json.Unmarshal(transactionAsBytes, &transactionIndex)							//un stringify it aka JSON.parse()
+	
+	//append
+	transactionIndex = append(transactionIndex, transactionID)			//add Transaction transactionID to index list
+	
+	transactioJsonAsBytes, _ := json.Marshal(transactionIndex)
+	fmt.Print("update transaction jsonAsBytes: ")
+	fmt.Println(transactioJsonAsBytes)
+	err = stub.PutState(transactions.TransactionIndexStr, transactioJsonAsBytes)						//store name of Transaction
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"customerID\" : \""+customerId+"\", \"message\" : \"Customer created succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("end createCustomer")
+	return nil, nil
+}
+// ============================================================================================================================
+// Delete - archive a Customer with a reason, the Customer's transactions stay on chain as history
+// ============================================================================================================================
+func (t *ManageLPM) deleteCustomer(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// deleteCustomer("customerId", "reason", "archivedAt")
+	if len(args) != 3 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'customerId', 'reason' and 'archivedAt' as arguments\", \"code\" : \"503\"}"
+		err := stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set customerId
+	customerId := args[0]
+	customerAsBytes, err := stub.GetState(customerId)
+	if err != nil {
+		return nil, errors.New("Failed to get Customer customerID")
+	}
+	res := customers.Customer{}
+	json.Unmarshal(customerAsBytes, &res)
+	_, err = points.MigrateCustomer(stub, &res)								//a Customer still on the legacy merchant lists is migrated first
+	if err != nil {
+		return nil, err
+	}
+	balances, err := points.CustomerBalances(stub, customerId)
+	if err != nil {
+		return nil, err
+	}
+	problem := ""
+	if res.CustomerID != customerId {
+		problem = customerId + " Not Found."
+	} else if res.Archive != nil {
+		problem = "Customer is already archived"
+	} else if args[1] == "" || args[2] == "" {
+		problem = "Expecting a reason and the archive date"
+	} else {
+		for _, balance := range balances {
+			if problem == "" && balance.PointsCount > 0 {
+				problem = "Customer still holds points of Merchant " + balance.MerchantID
+			}
+		}
+	}
+	if problem != "" {
+		errMsg := "{ \"customerID\" : \""+customerId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	res.Archive = &common.Archive{Reason: args[1], ArchivedBy: common.CallerName(stub), ArchivedAt: args[2]}
+	customerAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(customerId, customerAsBytes)								//store the archived Customer, the index keeps it
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"customerID\" : \""+customerId+"\", \"message\" : \"Customer archived succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("Customer archived succcessfully")
+	return nil, nil
+}
+// ============================================================================================================================
+// associate Customer - associate a customer to Merchant, store into chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) associateCustomer(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	if len(args) != 6 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 6\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	fmt.Println("start associateCustomer")
+	customerId := args[0]
+	merchantId := args[1]
+	startingBalance := args[2]
+	archivedId := common.ArchivedRecord(stub, customerId, merchantId)
+	if archivedId != "" {
+		errMsg := "{ \"message\" : \"" + archivedId + " is archived and can't be updated.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	
+	customerAsBytes, err := stub.GetState(customerId)
+	if err != nil {
+		return nil, errors.New("Failed to get Customer customerID")
+	}
+	
+	merchantAsBytes, err := stub.GetState(merchantId)
+	if err != nil {
+		return nil, errors.New("Failed to get Merchant merchantID")
+	}
+	
+	res := customers.Customer{}
+	res_Merchant := merchants.Merchant{}
+	res_trans := transactions.Transaction{}
+	
+	json.Unmarshal(merchantAsBytes, &res_Merchant)
+	
+	if res_Merchant.MerchantID == merchantId{
+		fmt.Println("Merchant found with merchantId in associateCustomer: " + merchantId)
+		fmt.Println(res_Merchant);
+	}else{
+		errMsg := "{ \"message\" : \""+ merchantId+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+
+	// Calculation	
+	floatStartingBalance, balanceErr := strconv.ParseFloat(startingBalance, 64)
+	floatPointsPerDollarSpent, ppdsErr := strconv.ParseFloat(res_Merchant.PointsPerDollarSpent, 64)
+	pointsToBeCredited := floatStartingBalance / floatPointsPerDollarSpent
+	transactionTime, timeProblem := transactions.Stamp(args[4])
+	json.Unmarshal(customerAsBytes, &res)
+	if res.CustomerID == customerId{
+		fmt.Println("Customer found with customerId in associateCustomer: " + customerId)
+		fmt.Println(res);
+		_, err = points.MigrateCustomer(stub, &res)								//a Customer still on the legacy merchant lists is migrated first
+		if err != nil {
+			return nil, err
+		}
+		res_trans.TransactionID = args[3]
+ 		res_trans.TransactionDateTime = args[4]
+ 		res_trans.TransactionTime = transactionTime
+ 		res_trans.TransactionType = args[5]
+ 		res_trans.TransactionFrom = res_Merchant.MerchantName
+ 		res_trans.TransactionTo = res.UserName
+ 		res_trans.Credit = strconv.FormatFloat(pointsToBeCredited, 'f', 2, 64)
+ 		res_trans.Debit = "0"
+ 		res_trans.CustomerID = customerId
+	}else{
+		errMsg := "{ \"message\" : \""+ customerId+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	problem, err := transactions.IdProblem(stub, res_trans.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	if problem == "" && (balanceErr != nil || !(floatStartingBalance > 0)) {
+		problem = "startingBalance must be a positive number"
+	} else if problem == "" && (ppdsErr != nil || !(floatPointsPerDollarSpent > 0)) {
+		problem = "Merchant " + merchantId + " has no positive pointsPerDollarSpent"
+	} else if problem == "" {
+		problem = timeProblem
+	}
+	var lot points.PointsLot
+	if problem == "" {
+		lot, problem = points.EarnedLot(res_Merchant, pointsToBeCredited, args[4])
+	}
+	var balance points.Balance
+	if problem == "" {
+		balance, _, problem, err = points.AdjustPoints(stub, &res, res_Merchant, 0, []points.PointsLot{lot})	//an existing Balance with the merchant is topped up
+		if err != nil {
+			return nil, err
+		}
+	}
+	if problem != "" {
+		errMsg := "{ \"customerID\" : \""+customerId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	err = points.PutBalance(stub, balance)
+	if err != nil {
+		return nil, err
+	}
+	customerAsBytes, _ = json.Marshal(res)
+	fmt.Println("customer_json: " + string(customerAsBytes))
+	err = stub.PutState(customerId, customerAsBytes)									//store Customer with customerId as key
+	if err != nil {
+		return nil, err
+	}
+
+	// build the Transaction json string manually
+	transaction_json := `{`+
+		`"transactionId": "` + res_trans.TransactionID + `" , `+
+		`"transactionDateTime": "` + res_trans.TransactionDateTime + `" , `+
+		`"transactionTime": ` + strconv.FormatInt(res_trans.TransactionTime, 10) + ` , `+
+		`"transactionType": "` + res_trans.TransactionType + `" , `+
+		`"transactionFrom": "` + res_trans.TransactionFrom + `" , `+ 
+		`"transactionTo": "` + res_trans.TransactionTo + `" , `+ 
+		`"credit": "` + res_trans.Credit + `" , `+ 
+		`"debit": "` + res_trans.Debit + `" , `+ 
+		`"customerId": "` +  res_trans.CustomerID + `" , `+ 
+		`"merchantId": "` +  merchantId + `" `+ 
+	`}`
+	err = stub.PutState(res_trans.TransactionID, []byte(transaction_json))					//store Transaction with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	//get the Transaction index
+	transactionAsBytes, err := stub.GetState(transactions.TransactionIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Transaction index")
+	}
+	var transactionIndex []string	
+	json.Unmarshal(transactionAsBytes, &transactionIndex)							//un stringify it aka JSON.parse()
+	
+	//append
+	transactionIndex = append(transactionIndex, res_trans.TransactionID)			//add Transaction res_trans.TransactionID to index list
+	
+	jsonAsBytes, _ := json.Marshal(transactionIndex)
+	fmt.Print("update transaction jsonAsBytes: ")
+	fmt.Println(jsonAsBytes)
+	err = stub.PutState(transactions.TransactionIndexStr, jsonAsBytes)						//store name of Transaction
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"customerID\" : \""+customerId+"\", \"message\" : \"Customer associated succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("end associateCustomer")
+	return nil, nil
+}
+// ============================================================================================================================
+// migrateBalances - move every Customer's legacy comma separated merchant lists into Balance records, run once after upgrading
+// ============================================================================================================================
+func (t *ManageLPM) migrateBalances(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	var customerIndex []string
+	migrated := 0
+	fmt.Println("start migrateBalances")
+	if len(args) != 0 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting none\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	customerAsBytes, err := stub.GetState(customers.CustomerIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Customer index")
+	}
+	json.Unmarshal(customerAsBytes, &customerIndex)			//un stringify it aka JSON.parse()
+	for _, val := range customerIndex {
+		valueAsBytes, err := stub.GetState(val)
+		if err != nil {
+			return nil, errors.New("Failed to get state for " + val)
+		}
+		res := customers.Customer{}
+		json.Unmarshal(valueAsBytes, &res)
+		if res.CustomerID != val {
+			continue
+		}
+		moved, err := points.MigrateCustomer(stub, &res)
+		if err != nil {
+			return nil, err
+		}
+		if moved {
+			migrated++
+		}
+	}
+
+	tosend := "{ \"message\" : \"" + strconv.Itoa(migrated) + " Customers migrated to Balance records succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("end migrateBalances")
+	return nil, nil
+}
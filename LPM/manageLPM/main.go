@@ -0,0 +1,325 @@
+/*/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+"errors"
+"fmt"
+"encoding/json"
+"strings"
+
+"github.com/hyperledger/fabric/core/chaincode/shim"	
+
+"Blockchain/LPM/customers"
+"Blockchain/LPM/merchants"
+"Blockchain/LPM/transactions"
+)
+
+// ManageLPM example simple Chaincode implementation
+type ManageLPM struct {
+}
+
+var IdempotencyKeyArg = "idempotencyKey="			//last argument of every invoke but init, a replay with the same key gets the first result
+var IdempotencyPrefix = "_Idempotency_"			//prefix of the key the first result of an invoke with an idempotency key is stored under
+
+var CompatibleFunctions = map[string]string{		//old function names of the LPM variants and the function that now handles them
+	"updateCustomerAccumulationSC": "updateCustomerAccumulation",
+	"updateCustomerPurchaseSC": "updateCustomerPurchase",
+	"updateCustomerTransferSC": "updateCustomerTransfer",
+	"updateCustomerRedemption": "updateCustomerPurchase",
+}
+
+type IdempotentResult struct{					// The request an idempotency key was first used for and the event it set
+	IdempotencyKey string `json:"idempotencyKey"`
+	Function string `json:"function"`
+	Args []string `json:"args"`
+	EventName string `json:"eventName"`
+	Payload string `json:"payload"`
+	TxID string `json:"txId"`
+}
+
+// eventRecorder - a stub that remembers the last event set through it, the result an idempotent invoke replays
+type eventRecorder struct {
+	shim.ChaincodeStubInterface
+	name string
+	payload []byte
+}
+
+func (r *eventRecorder) SetEvent(name string, payload []byte) error {
+	r.name = name
+	r.payload = payload
+	return r.ChaincodeStubInterface.SetEvent(name, payload)
+}
+
+// ============================================================================================================================
+// Main - start the chaincode for LPM management
+// ============================================================================================================================
+func main() {			
+	err := shim.Start(new(ManageLPM))
+	if err != nil {
+		fmt.Printf("Error starting LPM management chaincode: %s", err)
+	}
+}
+// ============================================================================================================================
+// Init - reset all the things
+// ============================================================================================================================
+func (t *ManageLPM) Init(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	var msg string
+	var err error
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting ' ' as an argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+
+	// Initialize the chaincode
+	msg = args[0]
+	// Write the state to the ledger
+	err = stub.PutState("abc", []byte(msg))		//making a test var "abc", I find it handy to read/write to it right away to test the network
+	if err != nil {
+		return nil, err
+	}
+	var empty []string
+	jsonAsBytes, _ := json.Marshal(empty)								//marshal an emtpy array of strings to clear the index
+	err = stub.PutState(customers.CustomerIndexStr, jsonAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	err = stub.PutState(transactions.TransactionIndexStr, jsonAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	err = stub.PutState(merchants.MerchantIndexStr, jsonAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"message\" : \"ManageLPM chaincode is deployed successfully.\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+	return nil, nil
+}
+// ============================================================================================================================
+// Run - Our entry point for Invocations - [LEGACY] obc-peer 4/25/2016
+// ============================================================================================================================
+func (t *ManageLPM) Run(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	fmt.Println("run is running " + function)
+	return t.Invoke(stub, function, args)
+}
+// ============================================================================================================================
+// Invoke - Our entry point for Invocations, every invoke but init takes a last argument "idempotencyKey=<key>" and runs once per key
+// ============================================================================================================================
+func (t *ManageLPM) Invoke(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	fmt.Println("invoke is running " + function)
+	if name, ok := CompatibleFunctions[function]; ok {
+		fmt.Println(function + " is handled by " + name)
+		function = name
+	}
+	if len(args) > 0 && strings.HasPrefix(args[len(args)-1], IdempotencyKeyArg) {
+		return t.invokeOnce(stub, function, args[:len(args)-1], strings.TrimPrefix(args[len(args)-1], IdempotencyKeyArg))
+	}
+	if function == "init" {
+		return t.invokeFunction(stub, function, args)
+	}
+	errMsg := "{ \"message\" : \"Expecting a last argument " + IdempotencyKeyArg + "<key>\", \"code\" : \"503\"}"
+	err := stub.SetEvent("errEvent", []byte(errMsg))
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+// ============================================================================================================================
+// invokeOnce - run an invoke once per idempotency key. A replay with the same function and arguments sets the event of the
+// first run again without changing anything, a used key with other arguments is rejected. Invokes that fail aren't
+// recorded, so they can be retried with the same key
+// ============================================================================================================================
+func (t *ManageLPM) invokeOnce(stub shim.ChaincodeStubInterface, function string, args []string, idempotencyKey string) ([]byte, error) {
+	var err error
+	resultAsBytes, err := stub.GetState(IdempotencyPrefix + idempotencyKey)
+	if err != nil {
+		return nil, errors.New("Failed to get idempotency key " + idempotencyKey)
+	}
+	result := IdempotentResult{}
+	json.Unmarshal(resultAsBytes, &result)
+	replay := result.IdempotencyKey == idempotencyKey && result.Function == function && len(result.Args) == len(args)
+	for i := 0; replay && i < len(args); i++ {
+		replay = result.Args[i] == args[i]
+	}
+	problem := ""
+	if idempotencyKey == "" {
+		problem = "Expecting an idempotency key"
+	} else if result.IdempotencyKey == idempotencyKey && !replay {
+		problem = "Idempotency key " + idempotencyKey + " was already used for another request"
+	}
+	if problem != "" {
+		errMsg := "{ \"idempotencyKey\" : \""+idempotencyKey+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	if replay {
+		fmt.Println("replaying " + function + " of transaction " + result.TxID + " for idempotency key " + idempotencyKey)
+		err = stub.SetEvent(result.EventName, []byte(result.Payload))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	recorder := &eventRecorder{ChaincodeStubInterface: stub}
+	response, err := t.invokeFunction(recorder, function, args)
+	if err != nil || recorder.name != "evtsender" {
+		return response, err
+	}
+	result = IdempotentResult{idempotencyKey, function, args, recorder.name, string(recorder.payload), stub.GetTxID()}
+	resultAsBytes, _ = json.Marshal(result)
+	err = stub.PutState(IdempotencyPrefix + idempotencyKey, resultAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+// ============================================================================================================================
+// invokeFunction - run the invoke function asked for
+// ============================================================================================================================
+func (t *ManageLPM) invokeFunction(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	// Handle different functions
+	if function == "init" {													//initialize the chaincode state, used as reset
+		return t.Init(stub, "init", args)
+	} else if function == "createCustomer" {											//create a new Customer
+		return t.createCustomer(stub, args)
+	}else if function == "updateCustomerAccumulation" {									//update a Customer
+		return t.updateCustomerAccumulation(stub, args)
+	}else if function == "updateCustomerPurchase" {									//update a Customer
+		return t.updateCustomerPurchase(stub, args)
+	}else if function == "updateCustomerTransfer" {									//update a Customer
+		return t.updateCustomerTransfer(stub, args)
+	}else if function == "deleteCustomer" {									// delete a Customer
+		return t.deleteCustomer(stub, args)
+	}else if function == "createMerchant" {											//create a new Merchant
+		return t.createMerchant(stub, args)
+	}else if function == "updateMerchant" {									//update a Merchant
+		return t.updateMerchant(stub, args)
+	}else if function == "deleteMerchant" {									// delete a Merchant
+		return t.deleteMerchant(stub, args)
+	}else if function == "createOwner" {									// create a owner
+		return t.createOwner(stub, args)
+	}else if function == "updateMerchantsPPDS" {									//update a Merchant's PPDS
+		return t.updateMerchantsPPDS(stub, args)
+	}else if function == "associateCustomer" {									// associate a customer to Merchant
+		return t.associateCustomer(stub, args)
+	}else if function == "updateMerchantsExchangeRate" {									// update a Merchant's Exchange Rate
+		return t.updateMerchantsExchangeRate(stub, args)
+	}else if function == "updateMerchantsExpiryPolicy" {									// update a Merchant's points expiry policy
+		return t.updateMerchantsExpiryPolicy(stub, args)
+	}else if function == "updateMerchantsTiers" {									// update a Merchant's tiers
+		return t.updateMerchantsTiers(stub, args)
+	}else if function == "reviewMerchantTiers" {									// promote and demote a Merchant's Customers
+		return t.reviewMerchantTiers(stub, args)
+	}else if function == "expire_points" {									// expire the points of a Merchant past their expiry date
+		return t.expire_points(stub, args)
+	}else if function == "exchangePoints" {									// exchange a Customer's points between two Merchants
+		return t.exchangePoints(stub, args)
+	}else if function == "setExchangeSpread" {									// the Owner sets the spread kept on exchanges
+		return t.setExchangeSpread(stub, args)
+	}else if function == "createSettlementStatements" {									// the Owner settles a period for every Merchant
+		return t.createSettlementStatements(stub, args)
+	}else if function == "markStatementPaid" {									// the Owner marks a settlement Statement paid
+		return t.markStatementPaid(stub, args)
+	}else if function == "createEarnRule" {									// a Merchant drafts an earn rule
+		return t.createEarnRule(stub, args)
+	}else if function == "activateEarnRule" {									// a Merchant activates an earn rule
+		return t.activateEarnRule(stub, args)
+	}else if function == "expireEarnRule" {									// a Merchant ends an earn rule
+		return t.expireEarnRule(stub, args)
+	}else if function == "migrateBalances" {									// move legacy merchant lists into Balance records
+		return t.migrateBalances(stub, args)
+	}
+	fmt.Println("invoke did not find func: " + function)
+	errMsg := "{ \"message\" : \"Received unknown function invocation\", \"code\" : \"503\"}"
+	err := stub.SetEvent("errEvent", []byte(errMsg))
+	if err != nil {
+		return nil, err
+	} 
+	return nil, nil			//error
+}
+// ============================================================================================================================
+// Query - Our entry point for Queries
+// ============================================================================================================================
+func (t *ManageLPM) Query(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	fmt.Println("query is running " + function)
+
+	// Handle different functions
+	if function == "getCustomerByID" {													//Read a Customer by Id
+		return t.getCustomerByID(stub, args)
+	}else if function == "getCustomerDetailsByID" {													//Read all transactions 
+		return t.getCustomerDetailsByID(stub, args)
+	}else if function == "getActivityHistory" {													//Read all transactions 
+		return t.getActivityHistory(stub, args)
+	}else if function == "getActivityHistoryForMerchant" {													//Read all transactions 
+		return t.getActivityHistoryForMerchant(stub, args)
+	}else if function == "getActivityStatement" {													//Read a page of an activity statement
+		return t.getActivityStatement(stub, args)
+	}else if function == "getActivityStatementCSV" {													//Export an activity statement as CSV
+		return t.getActivityStatementCSV(stub, args)
+	}else if function == "getAllCustomers" {													//Read all Customers
+		return t.getAllCustomers(stub, args)
+	}else if function == "getCustomersByMerchantID" {													//Read a Customer by transId
+		return t.getCustomersByMerchantID(stub, args)
+	}else if function == "getMerchantByName" {													//Read all Merchants by Name
+		return t.getMerchantByName(stub, args)
+	}else if function == "getMerchantByID" {													//Read all Merchants
+		return t.getMerchantByID(stub, args)
+	}else if function == "getMerchantDetailsByID" {													//Read all Merchants
+		return t.getMerchantDetailsByID(stub, args)
+	}else if function == "getMerchantsByIndustry" {													//Read all Merchants
+		return t.getMerchantsByIndustry(stub, args)
+	}else if function == "getAllMerchants" {													//Read all Merchants
+		return t.getAllMerchants(stub, args)
+	}else if function == "getMerchantsAccountBalance" {													//Read all Merchants
+		return t.getMerchantsAccountBalance(stub, args)
+	}else if function == "getMerchantsUserCount" {													//Read all Merchants
+		return t.getMerchantsUserCount(stub, args)
+	}else if function == "getOwnersMerchantUserCount" {													//Read all Merchants
+		return t.getOwnersMerchantUserCount(stub, args)
+	}else if function == "getOwnerByID" {													//Read all Merchants
+		return t.getOwnerByID(stub, args)
+	}else if function == "getSettlementStatements" {													//Read settlement Statements
+		return t.getSettlementStatements(stub, args)
+	}else if function == "getEarnRules" {													//Read a Merchant's earn rules
+		return t.getEarnRules(stub, args)
+	}else if function == "getCustomerTier" {													//Read a Customer's tier with a Merchant
+		return t.getCustomerTier(stub, args)
+	}else if function == "getTierMembers" {													//Read the Customers in a Merchant's tier
+		return t.getTierMembers(stub, args)
+	}else if function == "getExchangeQuote" {													//Price an exchange between two Merchants
+		return t.getExchangeQuote(stub, args)
+	}
+	fmt.Println("query did not find func: " + function)						//error
+	errMsg := "{ \"message\" : \"Received unknown function query\", \"code\" : \"503\"}"
+	err := stub.SetEvent("errEvent", []byte(errMsg))
+	if err != nil {
+		return nil, err
+	} 
+	return nil, nil
+}
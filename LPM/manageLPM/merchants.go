@@ -0,0 +1,1192 @@
+/*/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+"errors"
+"fmt"
+"strconv"
+"encoding/json"
+"strings"
+
+"github.com/hyperledger/fabric/core/chaincode/shim"	
+
+"Blockchain/LPM/common"
+"Blockchain/LPM/merchants"
+"Blockchain/LPM/points"
+)
+
+// ============================================================================================================================
+//  getMerchantByName - get Merchant details by name from chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) getMerchantByName(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var jsonResp, merchantName, errResp string
+	var merchantIndex []string
+	var valIndex merchants.Merchant
+	fmt.Println("start getMerchantByName")
+	var err error
+	if len(args) < 1 || len(args) > 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'merchantName' and optionally 'include_archived' as arguments\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set merchant's name
+	merchantName = args[0]
+	fmt.Println("merchantName" + merchantName)
+	merchantAsBytes, err := stub.GetState(merchants.MerchantIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Merchant index string")
+	}
+	json.Unmarshal(merchantAsBytes, &merchantIndex)				//un stringify it aka JSON.parse()
+	fmt.Print("merchantIndex : ")
+	fmt.Println(merchantIndex)
+	jsonResp = "{"
+	for i,val := range merchantIndex{
+		fmt.Println(strconv.Itoa(i) + " - looking at " + val + " for getMerchantByName")
+		valueAsBytes, err := stub.GetState(val)
+		if err != nil {
+			errResp = "{\"Error\":\"Failed to get state for " + val + "\"}"
+			return nil, errors.New(errResp)
+		}
+		fmt.Print("valueAsBytes : ")
+		fmt.Println(valueAsBytes)
+		valIndex = merchants.Merchant{}
+		json.Unmarshal(valueAsBytes, &valIndex)
+		if valIndex.Archive != nil && !common.WithArchived(args, 1) {
+			continue
+		}
+		fmt.Print("valIndex: ")
+		fmt.Print(valIndex)
+		if valIndex.MerchantName == merchantName{
+			fmt.Println("Merchant found")
+			jsonResp = jsonResp + "\""+ val + "\":" + string(valueAsBytes[:])
+			fmt.Println("jsonResp inside if")
+			fmt.Println(jsonResp)
+			/*if i < len(merchantIndex)-1 {
+				jsonResp = jsonResp + ","
+			}*/
+		} 
+	}
+	jsonResp = jsonResp + "}"
+	fmt.Println("jsonResp in getMerchantByName: " + jsonResp)
+	fmt.Print("jsonResp in bytes : ")
+	fmt.Println([]byte(jsonResp))
+	fmt.Println("end getMerchantByName")
+	return []byte(jsonResp), nil											//send it onward
+}
+// ============================================================================================================================
+// getMerchantByID - get Merchant details for a specific ID from chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) getMerchantByID(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var merchantId string
+	var err error
+	fmt.Println("start getMerchantByID")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'merchantId' as an argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set merchantId
+	merchantId = args[0]
+	valAsbytes, err := stub.GetState(merchantId)									//get the merchantId from chaincode state
+	if err != nil {
+		errMsg := "{ \"message\" : \""+ merchantId + " not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	fmt.Println("end getMerchantByID")
+	return valAsbytes, nil													//send it onward
+}
+// ============================================================================================================================
+// getMerchantDetailsByID - get Merchant details for a specific ID from chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) getMerchantDetailsByID(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var merchantId string
+	var err error
+	fmt.Println("start getMerchantDetailsByID")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'merchantId' as an argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set merchantId
+	merchantId = args[0]
+	valAsbytes, err := stub.GetState(merchantId)									//get the merchantId from chaincode state
+	if err != nil {
+		errMsg := "{ \"message\" : \""+ merchantId + " not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	fmt.Println("end getMerchantDetailsByID")
+	return valAsbytes, nil													//send it onward
+}
+// ============================================================================================================================
+// getMerchantsByIndustry - get Merchants for a given Industry from chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) getMerchantsByIndustry(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var jsonResp, industryName, errResp string
+	var err error
+	var merchantIndex []string
+	var valIndex merchants.Merchant
+	fmt.Println("start getMerchantsByIndustry")
+	if len(args) < 1 || len(args) > 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'industryName' and optionally 'include_archived' as arguments\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set merchantId
+	industryName = args[0]
+
+	merchantAsBytes, err := stub.GetState(merchants.MerchantIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Merchant index string")
+	}
+	json.Unmarshal(merchantAsBytes, &merchantIndex)			//un stringify it aka JSON.parse()
+	fmt.Print("merchantIndex : ")
+	fmt.Println(merchantIndex)
+	
+	jsonResp = "{"
+	for i,val := range merchantIndex{
+		fmt.Println(strconv.Itoa(i) + " - looking at " + val + " for getMerchantsByIndustry")
+		valueAsBytes, err := stub.GetState(val)
+		if err != nil {
+			errResp = "{\"Error\":\"Failed to get state for " + val + "\"}"
+			return nil, errors.New(errResp)
+		}
+		//fmt.Print("valueAsBytes : ")
+		//fmt.Println(valueAsBytes)
+		valIndex = merchants.Merchant{}
+		json.Unmarshal(valueAsBytes, &valIndex)
+		if valIndex.Archive != nil && !common.WithArchived(args, 1) {
+			continue
+		}
+		fmt.Print("valIndex: ")
+		fmt.Print(valIndex)
+		if valIndex.MerchantIndustry == industryName{
+			fmt.Println("Merchant found")
+			jsonResp = jsonResp + "\""+ val + "\":" + string(valueAsBytes[:])
+			fmt.Println("jsonResp inside if")
+			fmt.Println(jsonResp)
+			if i < len(merchantIndex)-1 {
+				jsonResp = jsonResp + ","
+			}
+		} 
+	}
+	jsonResp = jsonResp + "}"
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
+	if strings.Contains(jsonResp, "},}"){
+		fmt.Println("in if for jsonResp contains wrong json")	
+		jsonResp = strings.Replace(jsonResp, "},}", "}}", -1)
+	}
+	fmt.Println("jsonResp : " + jsonResp)
+	fmt.Print("jsonResp in bytes : ")
+	fmt.Println([]byte(jsonResp))
+	fmt.Println("end getMerchantsByIndustry")
+	return []byte(jsonResp), nil											//send it onward
+}
+// ============================================================================================================================
+//  getAllMerchants- get details of all Merchants from chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) getAllMerchants(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var jsonResp, errResp string
+	var merchantIndex []string
+	var valIndex merchants.Merchant
+	var err error
+	fmt.Println("start getAllMerchants")
+		
+	merchantAsBytes, err := stub.GetState(merchants.MerchantIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Merchant index")
+	}
+	json.Unmarshal(merchantAsBytes, &merchantIndex)								//un stringify it aka JSON.parse()
+	jsonResp = "{"
+	for i,val := range merchantIndex{
+		fmt.Println(strconv.Itoa(i) + " - looking at " + val + " for all Merchant")
+		valueAsBytes, err := stub.GetState(val)
+		if err != nil {
+			errResp = "{\"Error\":\"Failed to get state for " + val + "\"}"
+			return nil, errors.New(errResp)
+		}
+		fmt.Print("valueAsBytes : ")
+		fmt.Println(valueAsBytes)
+		valIndex = merchants.Merchant{}
+		json.Unmarshal(valueAsBytes, &valIndex)
+		if valIndex.Archive != nil && !common.WithArchived(args, 0) {
+			continue
+		}
+		jsonResp = jsonResp + "\""+ val + "\":" + string(valueAsBytes[:])
+		if i < len(merchantIndex)-1 {
+			jsonResp = jsonResp + ","
+		}
+	}
+	jsonResp = jsonResp + "}"
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
+	if strings.Contains(jsonResp, "},}"){
+		fmt.Println("in if for jsonResp contains wrong json")	
+		jsonResp = strings.Replace(jsonResp, "},}", "}}", -1)
+	}
+	fmt.Println("jsonResp in getAllMerchants::")
+	fmt.Println(jsonResp)
+
+	fmt.Println("end getAllMerchants")
+	return []byte(jsonResp), nil			//send it onward
+}
+// ============================================================================================================================
+// getMerchantsAccountBalance - get merchants account balance from chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) getMerchantsAccountBalance(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var jsonResp, merchantId string
+	var err error
+	accountBalance := float64(0.0)
+	var merchantIndex merchants.Merchant
+	fmt.Println("start getMerchantsAccountBalance")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'merchantId' as argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set merchantName
+	merchantId = args[0]
+	
+	// Get Merchants Balance from Merchant Struct START
+	merchantAsbytes, err := stub.GetState(merchantId)
+	if err != nil {
+		errMsg := "{ \"message\" : \""+ merchantId + " not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	json.Unmarshal(merchantAsbytes, &merchantIndex)
+	fmt.Print("purchaseBalance for merchant : ")
+	fmt.Print(merchantIndex.PurchaseBalance)
+	accountBalanceMerchant, _ := strconv.ParseFloat(merchantIndex.PurchaseBalance, 64)
+	// Get Merchants Balance from Merchant Struct END
+
+	// Sum the worth of the points every Customer holds with the merchant, then add the merchant's own balance once
+	balances, err := points.MerchantBalances(stub, merchantId)
+	if err != nil {
+		return nil, err
+	}
+	jsonResp = "{"
+	for _, balance := range balances{
+		fmt.Println("looking at " + balance.CustomerID + " for getMerchantsAccountBalance")
+		accountBalance = accountBalance + balance.PointsWorth
+	}
+	accountBalance = accountBalance + accountBalanceMerchant
+	jsonResp = jsonResp + "\"merchantAccountBalance\":" + strconv.FormatFloat(accountBalance, 'f', 2, 64)
+	jsonResp = jsonResp + "}"
+	if strings.Contains(jsonResp, "},}"){
+		fmt.Println("in if for jsonResp contains wrong json")	
+		jsonResp = strings.Replace(jsonResp, "},}", "}}", -1)
+	}
+	fmt.Println("jsonResp : " + jsonResp)
+	fmt.Print("jsonResp in bytes : ")
+	fmt.Println([]byte(jsonResp))
+	fmt.Println("end getMerchantsAccountBalance")
+	return []byte(jsonResp), nil											//send it onward
+}
+// ============================================================================================================================
+// getMerchantsUserCount - get merchants user count from chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) getMerchantsUserCount(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var jsonResp, merchantId string
+	var err error
+	var userCount = 0;
+	fmt.Println("start getMerchantsUserCount")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'merchantId' as an argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set merchantName
+	merchantId = args[0]
+
+	balances, err := points.MerchantBalances(stub, merchantId)
+	if err != nil {
+		return nil, err
+	}
+	jsonResp = "{"
+	for _, balance := range balances{
+		fmt.Println("looking at " + balance.CustomerID + " for getMerchantsUserCount")
+		if common.ArchivedRecord(stub, balance.CustomerID) == ""{
+			fmt.Println("Merchant found")
+			userCount++;
+		}
+	}
+	jsonResp = jsonResp + "\"merchantUsersCount\":" + strconv.Itoa(userCount)
+	jsonResp = jsonResp + "}"
+	
+	fmt.Println("jsonResp : " + jsonResp)
+	fmt.Print("jsonResp in bytes : ")
+	fmt.Println([]byte(jsonResp))
+	fmt.Println("end getMerchantsUserCount")
+	return []byte(jsonResp), nil											//send it onward
+}
+// ============================================================================================================================
+// create Merchant - create a new Merchant, store into chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) createMerchant(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	if len(args) != 10 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 10\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	fmt.Println("start createMerchant")
+	merchantID := args[0]
+	merchantUserName := args[1]
+	merchantName := args[2]
+	merchantIndustry := args[3]
+	industryColor := args[4]
+	pointsPerDollarSpent := args[5]
+	exchangeRate := args[6]
+	purchaseBalance := args[7]
+	merchantCurrency := args[8]
+	merchantCU_date := args[9]
+	merchantAsBytes, err := stub.GetState(merchantID)
+	if err != nil {
+		return nil, errors.New("Failed to get Merchant merchantID")
+	}
+	res := merchants.Merchant{}
+	json.Unmarshal(merchantAsBytes, &res)
+	fmt.Print("res: ")
+	fmt.Println(res)
+	if res.MerchantID == merchantID{
+		fmt.Println("This Merchant arleady exists: " + merchantID)
+		fmt.Println(res);
+		errMsg := "{ \"message\" : \"This Merchant arleady exists\", \"code\" : \"503\"}"
+		err := stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil				//all stop a Merchant by this name exists
+	}
+	//build the Merchant json string manually
+	merchant_json := 	`{`+
+		`"merchantId": "` + merchantID + `" , `+
+		`"merchantUserName": "` + merchantUserName + `" , `+
+		`"merchantName": "` + merchantName + `" , `+
+		`"merchantIndustry": "` + merchantIndustry + `" , `+ 
+		`"industryColor": "` + industryColor + `" , `+
+		`"pointsPerDollarSpent": "` + pointsPerDollarSpent + `" , `+ 
+		`"exchangeRate": "` + exchangeRate + `" , `+ 
+		`"purchaseBalance": "` + purchaseBalance + `" , `+
+		`"merchantCurrency": "` + merchantCurrency + `" , `+
+		`"merchantCU_date": "` + merchantCU_date + `" `+ 
+	`}`
+	fmt.Println("merchant_json: " + merchant_json)
+	fmt.Print("merchant_json in bytes array: ")
+	fmt.Println([]byte(merchant_json))
+	err = stub.PutState(merchantID, []byte(merchant_json))		//store Merchant with merchantId as key
+	if err != nil {
+		return nil, err
+	}
+	//get the Merchant index
+	merchantIndexAsBytes, err := stub.GetState(merchants.MerchantIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Merchant index")
+	}
+	var merchantIndex []string
+	json.Unmarshal(merchantIndexAsBytes, &merchantIndex)							//un stringify it aka JSON.parse()
+	
+	//append
+	merchantIndex = append(merchantIndex, merchantID)									//add Merchant merchantID to index list
+	fmt.Println("! Merchant index after appending merchantID: ", merchantIndex)
+	jsonAsBytes, _ := json.Marshal(merchantIndex)
+	fmt.Print("jsonAsBytes: ")
+	fmt.Println(jsonAsBytes)
+	err = stub.PutState(merchants.MerchantIndexStr, jsonAsBytes)						//store name of Merchant
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"merchantID\" : \""+merchantID+"\", \"message\" : \"Merchant created succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("end createMerchant")
+	return nil, nil
+}
+// ============================================================================================================================
+// Write - update merchant into chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) updateMerchant(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("Updating Merchant")
+	if len(args) != 10 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 10\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set merchantId
+	merchantId := args[0]
+	archivedId := common.ArchivedRecord(stub, merchantId)
+	if archivedId != "" {
+		errMsg := "{ \"message\" : \"" + archivedId + " is archived and can't be updated.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	merchantAsBytes, err := stub.GetState(merchantId)									//get the Merchant for the specified merchant from chaincode state
+	if err != nil {
+		errMsg := "{ \"message\" : \"Failed to get state for " + merchantId + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	res := merchants.Merchant{}
+	json.Unmarshal(merchantAsBytes, &res)
+	if res.MerchantID == merchantId{
+		fmt.Println("Merchant found with merchantId : " + merchantId)
+		//fmt.Println(res);
+		res.MerchantUserName = args[1]
+		res.MerchantName = args[2]
+		res.MerchantIndustry = args[3]
+		res.IndustryColor = args[4]
+		res.PointsPerDollarSpent = args[5]
+		res.ExchangeRate = args[6]
+		res.PurchaseBalance = args[7]
+		res.MerchantCurrency = args[8]
+		res.MerchantCU_date = args[9]
+	}else{
+		errMsg := "{ \"message\" : \""+ merchantId+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	
+	//store the Merchant with every field, including the ones the client doesn't send
+	merchantAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(merchantId, merchantAsBytes)						//store Merchant with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"merchantId\" : \""+merchantId+"\", \"message\" : \"Merchant details updated succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("Merchant details updated succcessfully")
+	return nil, nil
+}
+// ============================================================================================================================
+// Write - update merchant's purchase balance into chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) updateMerchantsPurchaseBal(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("Updating Merchant - Purchase Balance")
+	if len(args) != 3 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 3\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set merchantId
+	merchantId := args[0]
+	newPurchaseBal := args[1]
+	archivedId := common.ArchivedRecord(stub, merchantId)
+	if archivedId != "" {
+		errMsg := "{ \"message\" : \"" + archivedId + " is archived and can't be updated.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	merchantAsBytes, err := stub.GetState(merchantId)									//get the Merchant for the specified merchant from chaincode state
+	if err != nil {
+		errMsg := "{ \"message\" : \"Failed to get state for " + merchantId + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	res := merchants.Merchant{}
+	json.Unmarshal(merchantAsBytes, &res)
+	if res.MerchantID == merchantId{
+		fmt.Println("Merchant found with merchantId : " + merchantId)
+		fmt.Println("Merchants old purchaseBalance : " + res.PurchaseBalance)
+		fmt.Println("Merchants new purchaseBalance : " + newPurchaseBal)
+
+		currentPurchaseBalanceFloat, _ := strconv.ParseFloat(res.PurchaseBalance, 64)
+		newPurchaseBalFloat, _ := strconv.ParseFloat(newPurchaseBal, 64) 
+		purchaseBalanceCalculated := currentPurchaseBalanceFloat + newPurchaseBalFloat
+
+		res.PurchaseBalance = strconv.FormatFloat(purchaseBalanceCalculated, 'f', 2, 64)
+		res.MerchantCU_date = args[2]
+	}else{
+		errMsg := "{ \"message\" : \""+ merchantId+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	
+	//store the Merchant with every field, including the ones the client doesn't send
+	merchantAsBytes, _ = json.Marshal(res)
+	fmt.Println("merchant_json in updateMerchantsPurchaseBal::" + string(merchantAsBytes))
+	err = stub.PutState(merchantId, merchantAsBytes)						//store Merchant with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"merchantId\" : \""+merchantId+"\", \"message\" : \"Merchant purchase balance details updated succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("Merchant purchase balance updated succcessfully")
+	return nil, nil
+}
+// ============================================================================================================================
+// Write - update merchant's PPDS into chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) updateMerchantsPPDS(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("Updating Merchant - Points Per Dolllar Spent")
+	if len(args) != 3 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 3\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set merchantId
+	merchantId := args[0]
+	newPPDS := args[1]
+	archivedId := common.ArchivedRecord(stub, merchantId)
+	if archivedId != "" {
+		errMsg := "{ \"message\" : \"" + archivedId + " is archived and can't be updated.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	merchantAsBytes, err := stub.GetState(merchantId)									//get the Merchant for the specified merchant from chaincode state
+	if err != nil {
+		errMsg := "{ \"message\" : \"Failed to get state for " + merchantId + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	res := merchants.Merchant{}
+	json.Unmarshal(merchantAsBytes, &res)
+	if res.MerchantID == merchantId{
+		fmt.Println("Merchant found with merchantId : " + merchantId)
+		fmt.Println("Merchants old pointsPerDollarSpent : " + res.PointsPerDollarSpent)
+		fmt.Println("Merchants new pointsPerDollarSpent : " + newPPDS)
+		res.PointsPerDollarSpent = newPPDS 
+		res.MerchantCU_date = args[2]
+	}else{
+		errMsg := "{ \"message\" : \""+ merchantId+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	
+	//store the Merchant with every field, including the ones the client doesn't send
+	merchantAsBytes, _ = json.Marshal(res)
+	fmt.Println("merchant_json in updateMerchantsPPDS::" + string(merchantAsBytes))
+	err = stub.PutState(merchantId, merchantAsBytes)						//store Merchant with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"merchantId\" : \""+merchantId+"\", \"message\" : \"Merchant points per dollar spent updated succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("Merchant points per dollar spent updated succcessfully")
+	return nil, nil
+}
+// ============================================================================================================================
+// Write - update merchant's Exchange Rate into chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) updateMerchantsExchangeRate(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("Updating Merchant - ExchangeRate")
+	if len(args) != 3 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 3\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set merchantId
+	merchantId := args[0]
+	newExchangeRate := args[1]
+	archivedId := common.ArchivedRecord(stub, merchantId)
+	if archivedId != "" {
+		errMsg := "{ \"message\" : \"" + archivedId + " is archived and can't be updated.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	merchantAsBytes, err := stub.GetState(merchantId)				//get the Merchant for the specified merchant from chaincode state
+	if err != nil {
+		errMsg := "{ \"message\" : \"Failed to get state for " + merchantId + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	res := merchants.Merchant{}
+	json.Unmarshal(merchantAsBytes, &res)
+	if res.MerchantID == merchantId{
+		fmt.Println("Merchant found with merchantId : " + merchantId)
+		fmt.Println("Merchants old exchangeRate : " + res.ExchangeRate)
+		fmt.Println("Merchants new exchangeRate : " + newExchangeRate)
+		res.ExchangeRate = newExchangeRate
+		res.MerchantCU_date = args[2]
+	}else{
+		errMsg := "{ \"message\" : \""+ merchantId+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	
+	//store the Merchant with every field, including the ones the client doesn't send
+	merchantAsBytes, _ = json.Marshal(res)
+	fmt.Println("merchant_json in updateMerchantsExchangeRate::" + string(merchantAsBytes))
+	err = stub.PutState(merchantId, merchantAsBytes)						//store Merchant with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"merchantId\" : \""+merchantId+"\", \"message\" : \"Merchant exchange rate updated succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("Merchant exchange rate updated succcessfully")
+	return nil, nil
+}
+// ============================================================================================================================
+// Write - update merchant's points expiry policy into chaincode state, it applies to the points earned from now on
+// ============================================================================================================================
+func (t *ManageLPM) updateMerchantsExpiryPolicy(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("Updating Merchant - Expiry Policy")
+	// updateMerchantsExpiryPolicy("merchantId", "never|months|yearEnd", "expiryMonths", "merchantCU_date")
+	if len(args) != 4 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 4\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set merchantId
+	merchantId := args[0]
+	archivedId := common.ArchivedRecord(stub, merchantId)
+	if archivedId != "" {
+		errMsg := "{ \"message\" : \"" + archivedId + " is archived and can't be updated.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	merchantAsBytes, err := stub.GetState(merchantId)				//get the Merchant for the specified merchant from chaincode state
+	if err != nil {
+		errMsg := "{ \"message\" : \"Failed to get state for " + merchantId + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	res := merchants.Merchant{}
+	json.Unmarshal(merchantAsBytes, &res)
+	months, errMonths := strconv.Atoi(args[2])
+	problem := ""
+	if res.MerchantID != merchantId {
+		problem = merchantId + " Not Found."
+	} else if args[1] != "never" && args[1] != merchants.ExpiryMonthsPolicy && args[1] != merchants.ExpiryYearEndPolicy {
+		problem = "Expiry policy must be never, " + merchants.ExpiryMonthsPolicy + " or " + merchants.ExpiryYearEndPolicy
+	} else if args[1] == merchants.ExpiryMonthsPolicy && (errMonths != nil || months <= 0) {
+		problem = "Expiry months must be a positive whole number"
+	}
+	if problem != "" {
+		errMsg := "{ \"merchantId\" : \""+merchantId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	res.ExpiryPolicy = ""
+	res.ExpiryMonths = ""
+	if args[1] != "never" {
+		res.ExpiryPolicy = args[1]
+	}
+	if args[1] == merchants.ExpiryMonthsPolicy {
+		res.ExpiryMonths = strconv.Itoa(months)
+	}
+	res.MerchantCU_date = args[3]
+	merchantAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(merchantId, merchantAsBytes)						//store Merchant with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"merchantId\" : \""+merchantId+"\", \"message\" : \"Merchant expiry policy updated succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("Merchant expiry policy updated succcessfully")
+	return nil, nil
+}
+// ============================================================================================================================
+// Write - update merchant's tiers into chaincode state, Customers move to the new tiers as they earn points or when the
+// Merchant's tiers are reviewed. Empty tierNames removes the tiers
+// ============================================================================================================================
+func (t *ManageLPM) updateMerchantsTiers(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("Updating Merchant - Tiers")
+	// updateMerchantsTiers("merchantId", "tierWindowDays", "Silver,Gold,Platinum", "100,500,1000", "1,1.25,1.5", "merchantCU_date")
+	if len(args) != 6 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 6\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set merchantId
+	merchantId := args[0]
+	archivedId := common.ArchivedRecord(stub, merchantId)
+	if archivedId != "" {
+		errMsg := "{ \"message\" : \"" + archivedId + " is archived and can't be updated.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	merchantAsBytes, err := stub.GetState(merchantId)				//get the Merchant for the specified merchant from chaincode state
+	if err != nil {
+		errMsg := "{ \"message\" : \"Failed to get state for " + merchantId + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	res := merchants.Merchant{}
+	json.Unmarshal(merchantAsBytes, &res)
+	windowDays, errWindow := strconv.Atoi(args[1])
+	var tiers []merchants.Tier
+	listed := map[string]bool{}
+	var tierNames, thresholds, multipliers []string
+	if args[2] != "" {
+		tierNames = strings.Split(args[2], ",")
+		thresholds = strings.Split(args[3], ",")
+		multipliers = strings.Split(args[4], ",")
+	}
+	problem := ""
+	if res.MerchantID != merchantId {
+		problem = merchantId + " Not Found."
+	} else if len(tierNames) > 0 && (errWindow != nil || windowDays <= 0) {
+		problem = "Tier window must be a positive number of days"
+	} else if len(thresholds) != len(tierNames) || len(multipliers) != len(tierNames) {
+		problem = "Expecting a threshold and a multiplier for each tier"
+	}
+	for i := 0; problem == "" && i < len(tierNames); i++ {
+		tier := merchants.Tier{TierName: strings.TrimSpace(tierNames[i])}
+		threshold, errThreshold := strconv.ParseFloat(strings.TrimSpace(thresholds[i]), 64)
+		multiplier, errMultiplier := strconv.ParseFloat(strings.TrimSpace(multipliers[i]), 64)
+		if tier.TierName == "" {
+			problem = "Tier names can't be empty"
+		} else if listed[tier.TierName] {
+			problem = "Tier " + tier.TierName + " is listed twice"
+		} else if errThreshold != nil || threshold < 0 || (i > 0 && threshold <= tiers[i-1].Threshold) {
+			problem = "Tier thresholds must be points from 0, lowest tier first"
+		} else if errMultiplier != nil || multiplier <= 0 {
+			problem = "Tier multipliers must be positive numbers"
+		}
+		tier.Threshold = threshold
+		tier.Multiplier = multiplier
+		tiers = append(tiers, tier)
+		listed[tier.TierName] = true
+	}
+	if problem != "" {
+		errMsg := "{ \"merchantId\" : \""+merchantId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	res.Tiers = tiers
+	res.TierWindowDays = ""
+	if len(tiers) > 0 {
+		res.TierWindowDays = strconv.Itoa(windowDays)
+	}
+	res.MerchantCU_date = args[5]
+	merchantAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(merchantId, merchantAsBytes)						//store Merchant with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"merchantId\" : \""+merchantId+"\", \"message\" : \"Merchant tiers updated succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("Merchant tiers updated succcessfully")
+	return nil, nil
+}
+// ============================================================================================================================
+// Delete - archive a merchant with a reason once no active Customer holds the merchant's points
+// ============================================================================================================================
+func (t *ManageLPM) deleteMerchant(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// deleteMerchant("merchantId", "reason", "archivedAt")
+	if len(args) != 3 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'merchantId', 'reason' and 'archivedAt' as arguments\", \"code\" : \"503\"}"
+		err := stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set merchantId
+	merchantId := args[0]
+	merchantAsBytes, err := stub.GetState(merchantId)
+	if err != nil {
+		return nil, errors.New("Failed to get Merchant merchantID")
+	}
+	res := merchants.Merchant{}
+	json.Unmarshal(merchantAsBytes, &res)
+	problem := ""
+	if res.MerchantID != merchantId {
+		problem = merchantId + " Not Found."
+	} else if res.Archive != nil {
+		problem = "Merchant is already archived"
+	} else if args[1] == "" || args[2] == "" {
+		problem = "Expecting a reason and the archive date"
+	}
+	if problem == "" {
+		//a Merchant whose points are still held by an active Customer can't be archived
+		balances, err := points.MerchantBalances(stub, merchantId)
+		if err != nil {
+			return nil, err
+		}
+		for _, balance := range balances {
+			if problem == "" && balance.PointsCount > 0 && common.ArchivedRecord(stub, balance.CustomerID) == "" {
+				problem = "Customer " + balance.CustomerID + " still holds points of this Merchant"
+			}
+		}
+	}
+	if problem != "" {
+		errMsg := "{ \"merchantID\" : \""+merchantId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	res.Archive = &common.Archive{Reason: args[1], ArchivedBy: common.CallerName(stub), ArchivedAt: args[2]}
+	merchantAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(merchantId, merchantAsBytes)								//store the archived Merchant, the index keeps it
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"merchantID\" : \""+merchantId+"\", \"message\" : \"Merchant archived succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("Merchant archived succcessfully")
+	return nil, nil
+}
+// ============================================================================================================================
+// getEarnRules - a Merchant's EarnRules in every status, in rule id order
+// ============================================================================================================================
+func (t *ManageLPM) getEarnRules(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start getEarnRules")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'merchantId' as an argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	rules, err := merchants.EarnRules(stub, args[0])
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		rules = []merchants.EarnRule{}
+	}
+	rulesAsBytes, _ := json.Marshal(rules)
+	fmt.Println("end getEarnRules")
+	return rulesAsBytes, nil											//send it onward
+}
+// ============================================================================================================================
+// createEarnRule - a Merchant drafts an EarnRule, it applies to purchases once the Merchant activates it
+// ============================================================================================================================
+func (t *ManageLPM) createEarnRule(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start createEarnRule")
+	// createEarnRule("merchantId", "ruleId", "ruleName", "weekend|basket|category|firstPurchase", "multiplier", "bonusPoints", "minimumBasket", "category", "validFrom", "validTo", "createdAt")
+	if len(args) != 11 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 11\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	merchantId := args[0]
+	ruleId := args[1]
+	merchantAsBytes, err := stub.GetState(merchantId)
+	if err != nil {
+		return nil, errors.New("Failed to get Merchant merchantID")
+	}
+	rule, err := merchants.GetEarnRule(stub, merchantId, ruleId)
+	if err != nil {
+		return nil, err
+	}
+	res := merchants.Merchant{}
+	json.Unmarshal(merchantAsBytes, &res)
+	multiplier, errMultiplier := strconv.ParseFloat(args[4], 64)
+	bonusPoints, errBonus := strconv.ParseFloat(args[5], 64)
+	minimumBasket := float64(0)
+	errBasket := error(nil)
+	if args[6] != "" {
+		minimumBasket, errBasket = strconv.ParseFloat(args[6], 64)
+	}
+	knownType := false
+	for _, ruleType := range merchants.EarnRuleTypes {
+		if args[3] == ruleType {
+			knownType = true
+		}
+	}
+	problem := ""
+	if res.MerchantID != merchantId {
+		problem = merchantId + " Not Found."
+	} else if common.ArchivedRecord(stub, merchantId) != "" {
+		problem = merchantId + " is archived and can't create rules."
+	} else if common.CallerName(stub) != res.MerchantUserName {
+		problem = "Only the Merchant can create its earn rules"
+	} else if ruleId == "" || strings.Contains(ruleId, common.KeySeparator) {
+		problem = "Expecting a rule id"
+	} else if rule.RuleID == ruleId {
+		problem = "Rule " + ruleId + " already exists."
+	} else if !knownType {
+		problem = "Rule type must be one of " + strings.Join(merchants.EarnRuleTypes, ", ")
+	} else if errMultiplier != nil || multiplier < 0 {
+		problem = "Multiplier must be a number from 0"
+	} else if errBonus != nil || bonusPoints < 0 {
+		problem = "Bonus points must be a number from 0"
+	} else if errBasket != nil || minimumBasket < 0 || (args[3] == "basket" && minimumBasket <= 0) {
+		problem = "Minimum basket must be a positive amount for basket rules"
+	} else if args[3] == "category" && args[7] == "" {
+		problem = "Expecting the category a category rule applies to"
+	} else if !common.ValidDay(args[8]) || !common.ValidDay(args[9]) {
+		problem = "validFrom and validTo must be empty or a YYYY-MM-DD date"
+	} else if args[8] != "" && args[9] != "" && args[9] < args[8] {
+		problem = "validTo can't be before validFrom"
+	}
+	if problem != "" {
+		errMsg := "{ \"merchantId\" : \""+merchantId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	rule = merchants.EarnRule{}
+	rule.RuleID = ruleId
+	rule.MerchantID = merchantId
+	rule.RuleName = args[2]
+	rule.RuleType = args[3]
+	rule.Multiplier = multiplier
+	rule.BonusPoints = bonusPoints
+	rule.MinimumBasket = minimumBasket
+	if args[3] == "category" {
+		rule.Category = args[7]
+	}
+	rule.ValidFrom = args[8]
+	rule.ValidTo = args[9]
+	rule.Status = merchants.EarnRuleDraft
+	rule.UpdatedAt = args[10]
+	ruleAsBytes, _ := json.Marshal(rule)
+	err = stub.PutState(common.CompositeKey(merchants.EarnRulePrefix, merchantId, ruleId), ruleAsBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"merchantId\" : \""+merchantId+"\", \"ruleId\" : \""+ruleId+"\", \"message\" : \"Earn rule created succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("end createEarnRule")
+	return nil, nil
+}
+// ============================================================================================================================
+// activateEarnRule - a Merchant activates a drafted EarnRule
+// ============================================================================================================================
+func (t *ManageLPM) activateEarnRule(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	fmt.Println("start activateEarnRule")
+	// activateEarnRule("merchantId", "ruleId", "updatedAt")
+	return t.changeEarnRuleStatus(stub, args, merchants.EarnRuleActive)
+}
+// ============================================================================================================================
+// expireEarnRule - a Merchant ends an EarnRule, drafted or active. An expired rule can't be activated again
+// ============================================================================================================================
+func (t *ManageLPM) expireEarnRule(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	fmt.Println("start expireEarnRule")
+	// expireEarnRule("merchantId", "ruleId", "updatedAt")
+	return t.changeEarnRuleStatus(stub, args, merchants.EarnRuleExpired)
+}
+// ============================================================================================================================
+// changeEarnRuleStatus - move a Merchant's EarnRule from Draft to Active, or from Draft or Active to Expired
+// ============================================================================================================================
+func (t *ManageLPM) changeEarnRuleStatus(stub shim.ChaincodeStubInterface, args []string, status string) ([]byte, error) {
+	var err error
+	if len(args) != 3 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 3\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	merchantId := args[0]
+	ruleId := args[1]
+	merchantAsBytes, err := stub.GetState(merchantId)
+	if err != nil {
+		return nil, errors.New("Failed to get Merchant merchantID")
+	}
+	rule, err := merchants.GetEarnRule(stub, merchantId, ruleId)
+	if err != nil {
+		return nil, err
+	}
+	res := merchants.Merchant{}
+	json.Unmarshal(merchantAsBytes, &res)
+	problem := ""
+	if res.MerchantID != merchantId {
+		problem = merchantId + " Not Found."
+	} else if common.CallerName(stub) != res.MerchantUserName {
+		problem = "Only the Merchant can change its earn rules"
+	} else if rule.RuleID != ruleId || ruleId == "" {
+		problem = "Rule " + ruleId + " Not Found."
+	} else if rule.Status == merchants.EarnRuleExpired || rule.Status == status {
+		problem = "Rule " + ruleId + " is already " + rule.Status
+	} else if status == merchants.EarnRuleActive && common.ArchivedRecord(stub, merchantId) != "" {
+		problem = merchantId + " is archived and can't activate rules."
+	}
+	if problem != "" {
+		errMsg := "{ \"merchantId\" : \""+merchantId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	rule.Status = status
+	rule.UpdatedAt = args[2]
+	ruleAsBytes, _ := json.Marshal(rule)
+	err = stub.PutState(common.CompositeKey(merchants.EarnRulePrefix, merchantId, ruleId), ruleAsBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"merchantId\" : \""+merchantId+"\", \"ruleId\" : \""+ruleId+"\", \"message\" : \"Earn rule " + strings.ToLower(status) + " succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("Earn rule " + ruleId + " is " + status)
+	return nil, nil
+}
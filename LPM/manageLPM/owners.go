@@ -0,0 +1,524 @@
+/*/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+"errors"
+"fmt"
+"strconv"
+"encoding/json"
+"strings"
+"time"
+
+"github.com/hyperledger/fabric/core/chaincode/shim"	
+
+"Blockchain/LPM/common"
+"Blockchain/LPM/customers"
+"Blockchain/LPM/merchants"
+"Blockchain/LPM/owners"
+"Blockchain/LPM/transactions"
+)
+
+// ============================================================================================================================
+// getOwnerByID - get Owner details for a specific ID from chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) getOwnerByID(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var ownerId string
+	var err error
+	fmt.Println("start getOwnerByID")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'ownerId' as an argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set ownerId
+	ownerId = args[0]
+	fmt.Print("ownerId in getOwnerByID : "+ownerId)
+	valAsbytes, err := stub.GetState(ownerId)									//get the ownerId from chaincode state
+	if err != nil {
+		errMsg := "{ \"message\" : \""+ ownerId + " not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	fmt.Print("valAsbytes : ")
+	fmt.Println(valAsbytes)
+	fmt.Println("end getOwnerByID")
+	return valAsbytes, nil													//send it onward
+}
+// ============================================================================================================================
+// getOwnersMerchantUserCount - get owners merchants and users count from chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) getOwnersMerchantUserCount(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var jsonResp string
+	var err error
+	var merchantIndex []string
+	var customerIndex []string
+	var merchantCount = 0;
+	var userCount = 0;
+	fmt.Println("start getOwnersMerchantUserCount")
+	
+	merchantAsBytes, err := stub.GetState(merchants.MerchantIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Merchant index string")
+	}
+	json.Unmarshal(merchantAsBytes, &merchantIndex)			//un stringify it aka JSON.parse()
+	fmt.Print("merchantIndex : ")
+	fmt.Println(merchantIndex)
+	jsonResp = "{"
+	for i,val := range merchantIndex{
+		fmt.Println("Merchant found")
+		fmt.Println(strconv.Itoa(i) + " - looking at " + val)
+		merchantCount++;
+	}
+	customerAsBytes, err := stub.GetState(customers.CustomerIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Customer index string")
+	}
+	json.Unmarshal(customerAsBytes, &customerIndex)			//un stringify it aka JSON.parse()
+	fmt.Print("customerIndex : ")
+	fmt.Println(customerIndex)
+	jsonResp = "{"
+	for j,valCustomer := range customerIndex{
+		fmt.Println("Customer found")
+		fmt.Println(strconv.Itoa(j) + " - looking at " + valCustomer)
+		userCount++;
+	}
+
+	jsonResp = jsonResp + "\"merchantCount\":" + strconv.Itoa(merchantCount) + "," + "\"userCount\":" + strconv.Itoa(userCount)
+	jsonResp = jsonResp + "}"
+	
+	fmt.Println("jsonResp : " + jsonResp)
+	fmt.Print("jsonResp in bytes : ")
+	fmt.Println([]byte(jsonResp))
+	fmt.Println("end getOwnersMerchantUserCount")
+	return []byte(jsonResp), nil
+}
+// ============================================================================================================================
+// create Owner - create a Owner, store into chaincode state. Only an admin can create one, an Owner can set the exchange
+// spread and settle the Merchants' statements
+// ============================================================================================================================
+func (t *ManageLPM) createOwner(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	if len(args) != 3 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 3\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	fmt.Println("start createOwner")
+	role, err := stub.ReadCertAttribute("role")
+	if err != nil || string(role) != "admin" {
+		errMsg := "{ \"message\" : \"Only an admin can create an Owner\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	ownerId := args[0]
+	ownerUserName := args[1]
+	ownerName := args[2]
+	
+	ownerAsBytes, err := stub.GetState(ownerId)
+	if err != nil {
+		return nil, errors.New("Failed to get Owner ownerID")
+	}
+	res := owners.Owner{}
+	json.Unmarshal(ownerAsBytes, &res)
+	if res.OwnerID == ownerId{
+		errMsg := "{ \"message\" : \"This Owner arleady exists\", \"code\" : \"503\"}"
+		err := stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil				//all stop a Owner by this name exists
+	}
+	
+	//build the Owner json string manually
+	owner_json := 	`{`+
+		`"ownerId": "` + ownerId + `" , `+
+		`"ownerName": "` + ownerName + `" , `+
+		`"ownerUserName": "` +  ownerUserName + `" `+
+	`}`
+	fmt.Println("owner_json: " + owner_json)
+	fmt.Print("owner_json in bytes array: ")
+	fmt.Println([]byte(owner_json))
+	err = stub.PutState(ownerId, []byte(owner_json))									//store Owner with ownerId as key
+	if err != nil {
+		return nil, err
+	}
+	//get the Owner index
+	ownerIndexAsBytes, err := stub.GetState(owners.OwnerIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Owner index")
+	}
+	var ownerIndex []string
+	json.Unmarshal(ownerIndexAsBytes, &ownerIndex)							//un stringify it aka JSON.parse()
+	
+	//append
+	ownerIndex = append(ownerIndex, ownerId)									//add Owner ownerID to index list
+	
+	jsonAsBytes, _ := json.Marshal(ownerIndex)
+	fmt.Print("jsonAsBytes: ")
+	fmt.Println(jsonAsBytes)
+	err = stub.PutState(owners.OwnerIndexStr, jsonAsBytes)						//store name of Owner
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"ownerID\" : \""+ownerId+"\", \"message\" : \"Owner created succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("end createOwner")
+	return nil, nil
+}
+// ============================================================================================================================
+// setExchangeSpread - the Owner sets the share of the value kept when points are exchanged between Merchants
+// ============================================================================================================================
+func (t *ManageLPM) setExchangeSpread(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start setExchangeSpread")
+	// setExchangeSpread("ownerId", "spread", "setAt")
+	if len(args) != 3 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'ownerId', 'spread' and 'setAt' as arguments\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	ownerId := args[0]
+	ownerAsBytes, err := stub.GetState(ownerId)
+	if err != nil {
+		return nil, errors.New("Failed to get Owner ownerID")
+	}
+	res := owners.Owner{}
+	json.Unmarshal(ownerAsBytes, &res)
+	spread, err := owners.GetExchangeSpread(stub)
+	if err != nil {
+		return nil, err
+	}
+	spreadRate, err := strconv.ParseFloat(args[1], 64)
+	problem := ""
+	if res.OwnerID != ownerId {
+		problem = ownerId + " Not Found."
+	} else if common.CallerName(stub) != res.OwnerUserName {
+		problem = "Only the Owner can set the exchange spread"
+	} else if err != nil || spreadRate < 0 || spreadRate >= 1 {
+		problem = "Spread must be a fraction from 0 up to 1"
+	}
+	if problem != "" {
+		errMsg := "{ \"ownerID\" : \""+ownerId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	spread.Spread = args[1]
+	spread.SetBy = ownerId
+	spread.SetAt = args[2]
+	spreadAsBytes, _ := json.Marshal(spread)
+	err = stub.PutState(owners.ExchangeSpreadStr, spreadAsBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"ownerID\" : \""+ownerId+"\", \"message\" : \"Exchange spread set succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("end setExchangeSpread")
+	return nil, nil
+}
+// ============================================================================================================================
+// getSettlementStatements - settlement Statements of one Merchant, or of all when merchantId is empty
+// ============================================================================================================================
+func (t *ManageLPM) getSettlementStatements(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var jsonResp, errResp string
+	var statementIndex []string
+	var err error
+	fmt.Println("start getSettlementStatements")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'merchantId' as an argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	statementAsBytes, err := stub.GetState(transactions.StatementIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Statement index")
+	}
+	json.Unmarshal(statementAsBytes, &statementIndex)			//un stringify it aka JSON.parse()
+	jsonResp = "{"
+	for _, val := range statementIndex {
+		valueAsBytes, err := stub.GetState(val)
+		if err != nil {
+			errResp = "{\"Error\":\"Failed to get state for " + val + "\"}"
+			return nil, errors.New(errResp)
+		}
+		statement := transactions.Statement{}
+		json.Unmarshal(valueAsBytes, &statement)
+		if args[0] == "" || statement.MerchantID == args[0] {
+			jsonResp = jsonResp + "\""+ val + "\":" + string(valueAsBytes[:]) + ","
+		}
+	}
+	jsonResp = jsonResp + "}"
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
+	fmt.Println("end getSettlementStatements")
+	return []byte(jsonResp), nil											//send it onward
+}
+// ============================================================================================================================
+// createSettlementStatements - the Owner settles a period, one Statement per Merchant with points moved in it, with id
+// statementId-merchantId. A Merchant can't be settled twice for the same day and a statementId can't be reused
+// ============================================================================================================================
+func (t *ManageLPM) createSettlementStatements(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	var merchantIndex, transactionIndex, statementIndex []string
+	fmt.Println("start createSettlementStatements")
+	// createSettlementStatements("ownerId", "periodFrom", "periodTo", "statementId", "createdAt")
+	if len(args) != 5 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 5\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	ownerId := args[0]
+	periodFrom := args[1]
+	periodTo := args[2]
+	ownerAsBytes, err := stub.GetState(ownerId)
+	if err != nil {
+		return nil, errors.New("Failed to get Owner ownerID")
+	}
+	res := owners.Owner{}
+	json.Unmarshal(ownerAsBytes, &res)
+	_, errFrom := time.Parse(common.DateLayout, periodFrom)
+	_, errTo := time.Parse(common.DateLayout, periodTo)
+	problem := ""
+	if res.OwnerID != ownerId {
+		problem = ownerId + " Not Found."
+	} else if common.CallerName(stub) != res.OwnerUserName {
+		problem = "Only the Owner can settle merchants"
+	} else if errFrom != nil || errTo != nil || periodFrom > periodTo {
+		problem = "The period must run from one YYYY-MM-DD date to a later one"
+	} else if args[3] == "" {
+		problem = "Expecting a statementId"
+	}
+	if problem != "" {
+		errMsg := "{ \"ownerID\" : \""+ownerId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	merchantAsBytes, err := stub.GetState(merchants.MerchantIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Merchant index")
+	}
+	json.Unmarshal(merchantAsBytes, &merchantIndex)
+	transactionAsBytes, err := stub.GetState(transactions.TransactionIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Transaction index")
+	}
+	json.Unmarshal(transactionAsBytes, &transactionIndex)
+	statementAsBytes, err := stub.GetState(transactions.StatementIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Statement index")
+	}
+	json.Unmarshal(statementAsBytes, &statementIndex)
+
+	statements := map[string]*transactions.Statement{}
+	var merchantList []merchants.Merchant
+	for _, val := range merchantIndex {
+		valueAsBytes, err := stub.GetState(val)
+		if err != nil {
+			return nil, errors.New("Failed to get state for " + val)
+		}
+		merchant := merchants.Merchant{}
+		json.Unmarshal(valueAsBytes, &merchant)
+		merchantList = append(merchantList, merchant)
+		statements[merchant.MerchantID] = &transactions.Statement{StatementID: args[3] + "-" + merchant.MerchantID, MerchantID: merchant.MerchantID,
+			PeriodFrom: periodFrom, PeriodTo: periodTo, ExchangeRate: merchant.ExchangeRate, Status: transactions.StatementOpen, CreatedBy: ownerId, CreatedAt: args[4]}
+	}
+	for _, val := range transactionIndex {
+		valueAsBytes, err := stub.GetState(val)
+		if err != nil {
+			return nil, errors.New("Failed to get state for " + val)
+		}
+		transaction := transactions.Transaction{}
+		json.Unmarshal(valueAsBytes, &transaction)
+		if len(transaction.TransactionDateTime) < len(common.DateLayout) {
+			continue
+		}
+		day := transaction.TransactionDateTime[:len(common.DateLayout)]
+		statement := statements[transactions.MerchantOf(transaction, merchantList)]
+		if day < periodFrom || day > periodTo || statement == nil {
+			continue
+		}
+		transactions.Settle(statement, transaction)
+	}
+	for _, val := range statementIndex {
+		valueAsBytes, err := stub.GetState(val)
+		if err != nil {
+			return nil, errors.New("Failed to get state for " + val)
+		}
+		settled := transactions.Statement{}
+		json.Unmarshal(valueAsBytes, &settled)
+		if statements[settled.MerchantID] != nil && settled.PeriodFrom <= periodTo && settled.PeriodTo >= periodFrom {
+			problem = "Merchant " + settled.MerchantID + " is already settled by " + settled.StatementID
+			break
+		}
+	}
+	for _, merchant := range merchantList {
+		if problem != "" {
+			break
+		}
+		existingAsBytes, err := stub.GetState(statements[merchant.MerchantID].StatementID)
+		if err != nil {
+			return nil, errors.New("Failed to get state for " + statements[merchant.MerchantID].StatementID)
+		}
+		if len(existingAsBytes) > 0 {
+			problem = statements[merchant.MerchantID].StatementID + " already exists"		//a statementId is used once
+		}
+	}
+	if problem != "" {
+		errMsg := "{ \"ownerID\" : \""+ownerId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	created := 0
+	for _, merchant := range merchantList {
+		statement := statements[merchant.MerchantID]
+		if statement.PointsIssued == 0 && statement.PointsRedeemed == 0 && statement.PointsTransferredIn == 0 &&
+			statement.PointsTransferredOut == 0 && statement.PointsExpired == 0 {
+			continue
+		}
+		exchangeRate, _ := strconv.ParseFloat(statement.ExchangeRate, 64)
+		statement.NetPoints = common.RoundPoints(statement.PointsIssued + statement.PointsTransferredIn - statement.PointsRedeemed - statement.PointsTransferredOut - statement.PointsExpired)
+		statement.NetLiability = common.RoundPoints(statement.NetPoints * exchangeRate)
+		statementAsBytes, _ := json.Marshal(statement)
+		err = stub.PutState(statement.StatementID, statementAsBytes)				//store Statement with id as key
+		if err != nil {
+			return nil, err
+		}
+		statementIndex = append(statementIndex, statement.StatementID)
+		created++
+	}
+	jsonAsBytes, _ := json.Marshal(statementIndex)
+	err = stub.PutState(transactions.StatementIndexStr, jsonAsBytes)							//store name of Statement
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"ownerID\" : \""+ownerId+"\", \"message\" : \"" + strconv.Itoa(created) + " settlement statements created succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("end createSettlementStatements")
+	return nil, nil
+}
+// ============================================================================================================================
+// markStatementPaid - the Owner records the payment settling a Statement
+// ============================================================================================================================
+func (t *ManageLPM) markStatementPaid(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start markStatementPaid")
+	// markStatementPaid("ownerId", "statementId", "paymentReference", "paidAt")
+	if len(args) != 4 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 4\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	ownerId := args[0]
+	statementId := args[1]
+	ownerAsBytes, err := stub.GetState(ownerId)
+	if err != nil {
+		return nil, errors.New("Failed to get Owner ownerID")
+	}
+	statementAsBytes, err := stub.GetState(statementId)
+	if err != nil {
+		return nil, errors.New("Failed to get Statement statementID")
+	}
+	res := owners.Owner{}
+	statement := transactions.Statement{}
+	json.Unmarshal(ownerAsBytes, &res)
+	json.Unmarshal(statementAsBytes, &statement)
+	problem := ""
+	if res.OwnerID != ownerId {
+		problem = ownerId + " Not Found."
+	} else if common.CallerName(stub) != res.OwnerUserName {
+		problem = "Only the Owner can mark statements paid"
+	} else if statement.StatementID != statementId {
+		problem = statementId + " Not Found."
+	} else if statement.Status != transactions.StatementOpen {
+		problem = "Statement is already " + statement.Status
+	} else if args[2] == "" || args[3] == "" {
+		problem = "Expecting a payment reference and the payment date"
+	}
+	if problem != "" {
+		errMsg := "{ \"statementID\" : \""+statementId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	statement.Status = transactions.StatementPaid
+	statement.PaymentReference = args[2]
+	statement.PaidAt = args[3]
+	statementAsBytes, _ = json.Marshal(statement)
+	err = stub.PutState(statementId, statementAsBytes)							//store Statement with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"statementID\" : \""+statementId+"\", \"message\" : \"Statement marked paid succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("end markStatementPaid")
+	return nil, nil
+}
@@ -0,0 +1,586 @@
+/*/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+"errors"
+"fmt"
+"strconv"
+"encoding/json"
+"time"
+
+"github.com/hyperledger/fabric/core/chaincode/shim"	
+
+"Blockchain/LPM/common"
+"Blockchain/LPM/customers"
+"Blockchain/LPM/merchants"
+"Blockchain/LPM/owners"
+"Blockchain/LPM/transactions"
+"Blockchain/LPM/points"
+)
+
+// ============================================================================================================================
+// getExchangeQuote - points of toMerchant a Customer would get for points of fromMerchant, and the spread kept
+// ============================================================================================================================
+func (t *ManageLPM) getExchangeQuote(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start getExchangeQuote")
+	// getExchangeQuote("fromMerchantId", "toMerchantId", "points")
+	if len(args) != 3 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'fromMerchantId', 'toMerchantId' and 'points' as arguments\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	fromMerchantAsBytes, err := stub.GetState(args[0])
+	if err != nil {
+		return nil, errors.New("Failed to get state for " + args[0])
+	}
+	toMerchantAsBytes, err := stub.GetState(args[1])
+	if err != nil {
+		return nil, errors.New("Failed to get state for " + args[1])
+	}
+	spread, err := owners.GetExchangeSpread(stub)
+	if err != nil {
+		return nil, err
+	}
+	fromMerchant := merchants.Merchant{}
+	toMerchant := merchants.Merchant{}
+	json.Unmarshal(fromMerchantAsBytes, &fromMerchant)
+	json.Unmarshal(toMerchantAsBytes, &toMerchant)
+	pointsExchanged, err := strconv.ParseFloat(args[2], 64)
+	var pointsCredited, valueOut, valueIn float64
+	problem := ""
+	if fromMerchant.MerchantID != args[0] {
+		problem = args[0] + " Not Found."
+	} else if toMerchant.MerchantID != args[1] {
+		problem = args[1] + " Not Found."
+	} else if err != nil || pointsExchanged <= 0 {
+		problem = "Points must be a positive number"
+	} else {
+		pointsCredited, valueOut, valueIn, problem = points.ExchangeQuote(fromMerchant, toMerchant, pointsExchanged, spread)
+	}
+	if problem != "" {
+		errMsg := "{ \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	jsonResp := "{" +
+		"\"pointsCredited\":" + strconv.FormatFloat(pointsCredited, 'f', 2, 64) + "," +
+		"\"valueOut\":" + strconv.FormatFloat(valueOut, 'f', 2, 64) + "," +
+		"\"valueIn\":" + strconv.FormatFloat(valueIn, 'f', 2, 64) + "," +
+		"\"spread\":\"" + spread.Spread + "\"" +
+	"}"
+	fmt.Println("end getExchangeQuote")
+	return []byte(jsonResp), nil											//send it onward
+}
+// ============================================================================================================================
+// Write - exchange a Customer's points of one Merchant for points of another, priced at both exchange rates less the
+// Owner's spread. The first Merchant's purchase balance is debited the value given up, the second's credited the value received
+// ============================================================================================================================
+func (t *ManageLPM) exchangePoints(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("Updating Customer - exchange")
+	// exchangePoints("customerId", "fromMerchantId", "toMerchantId", "points", "transactionId1", "transactionId2", "transactionDateTime")
+	if len(args) != 7 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 7\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	customerId := args[0]
+	fromMerchantId := args[1]
+	toMerchantId := args[2]
+	transactionId1 := args[4]
+	transactionId2 := args[5]
+	archivedId := common.ArchivedRecord(stub, customerId, fromMerchantId, toMerchantId)
+	if archivedId != "" {
+		errMsg := "{ \"message\" : \"" + archivedId + " is archived and can't be updated.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	customerAsBytes, err := stub.GetState(customerId)
+	if err != nil {
+		return nil, errors.New("Failed to get state for " + customerId)
+	}
+	fromMerchantAsBytes, err := stub.GetState(fromMerchantId)
+	if err != nil {
+		return nil, errors.New("Failed to get state for " + fromMerchantId)
+	}
+	toMerchantAsBytes, err := stub.GetState(toMerchantId)
+	if err != nil {
+		return nil, errors.New("Failed to get state for " + toMerchantId)
+	}
+	spread, err := owners.GetExchangeSpread(stub)
+	if err != nil {
+		return nil, err
+	}
+	res := customers.Customer{}
+	fromMerchant := merchants.Merchant{}
+	toMerchant := merchants.Merchant{}
+	json.Unmarshal(customerAsBytes, &res)
+	json.Unmarshal(fromMerchantAsBytes, &fromMerchant)
+	json.Unmarshal(toMerchantAsBytes, &toMerchant)
+	_, err = points.MigrateCustomer(stub, &res)								//a Customer still on the legacy merchant lists is migrated first
+	if err != nil {
+		return nil, err
+	}
+	pointsExchanged, err := strconv.ParseFloat(args[3], 64)
+	var fromBalance, toBalance points.Balance
+	var lot points.PointsLot
+	var pointsCredited, valueOut, valueIn float64
+	var errBalance error
+	idProblem, errIds := transactions.IdProblem(stub, transactionId1, transactionId2)
+	if errIds != nil {
+		return nil, errIds
+	}
+	transactionTime, timeProblem := transactions.Stamp(args[6])
+	problem := ""
+	if res.CustomerID != customerId {
+		problem = customerId + " Not Found."
+	} else if fromMerchant.MerchantID != fromMerchantId {
+		problem = fromMerchantId + " Not Found."
+	} else if toMerchant.MerchantID != toMerchantId {
+		problem = toMerchantId + " Not Found."
+	} else if fromMerchantId == toMerchantId {
+		problem = "Points can only be exchanged for another Merchant's points"
+	} else if err != nil || pointsExchanged <= 0 {
+		problem = "Points must be a positive number"
+	} else if idProblem != "" {
+		problem = idProblem
+	} else if timeProblem != "" {
+		problem = timeProblem
+	} else {
+		pointsCredited, valueOut, valueIn, problem = points.ExchangeQuote(fromMerchant, toMerchant, pointsExchanged, spread)
+		if problem == "" {
+			lot, problem = points.EarnedLot(toMerchant, pointsCredited, args[6])
+		}
+		if problem == "" {
+			fromBalance, _, problem, errBalance = points.AdjustPoints(stub, &res, fromMerchant, pointsExchanged, nil)
+		}
+		if problem == "" && errBalance == nil {
+			toBalance, _, problem, errBalance = points.AdjustPoints(stub, &res, toMerchant, 0, []points.PointsLot{lot})
+		}
+	}
+	if errBalance != nil {
+		return nil, errBalance
+	}
+	if problem != "" {
+		errMsg := "{ \"customerID\" : \""+customerId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	res.WalletWorth, err = points.WalletWorth(stub, fromBalance, toBalance)				//both Balances changed
+	if err != nil {
+		return nil, err
+	}
+	err = points.PutBalance(stub, fromBalance)
+	if err != nil {
+		return nil, err
+	}
+	err = points.PutBalance(stub, toBalance)
+	if err != nil {
+		return nil, err
+	}
+	customerAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(customerId, customerAsBytes)							//store Customer with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	// both legs point at each other
+	transaction1AsBytes, _ := json.Marshal(transactions.Transaction{TransactionID: transactionId1, TransactionDateTime: args[6], TransactionTime: transactionTime, TransactionType: "Exchange", TransactionFrom: res.UserName, TransactionTo: fromMerchant.MerchantName,
+		Credit: "0", Debit: strconv.FormatFloat(pointsExchanged, 'f', 2, 64), CustomerID: customerId, MerchantID: fromMerchantId, LinkedTransactionID: transactionId2})
+	err = stub.PutState(transactionId1, transaction1AsBytes)					//store Transaction with id as key
+	if err != nil {
+		return nil, err
+	}
+	transaction2AsBytes, _ := json.Marshal(transactions.Transaction{TransactionID: transactionId2, TransactionDateTime: args[6], TransactionTime: transactionTime, TransactionType: "Exchange", TransactionFrom: toMerchant.MerchantName, TransactionTo: res.UserName,
+		Credit: strconv.FormatFloat(pointsCredited, 'f', 2, 64), Debit: "0", CustomerID: customerId, MerchantID: toMerchantId, LinkedTransactionID: transactionId1})
+	err = stub.PutState(transactionId2, transaction2AsBytes)					//store Transaction with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	//get the Transaction index
+	transactionAsBytes, err := stub.GetState(transactions.TransactionIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Transaction index")
+	}
+	var transactionIndex []string	
+	json.Unmarshal(transactionAsBytes, &transactionIndex)							//un stringify it aka JSON.parse()
+	transactionIndex = append(transactionIndex, transactionId1, transactionId2)		//add both legs to index list
+	jsonAsBytes, _ := json.Marshal(transactionIndex)
+	err = stub.PutState(transactions.TransactionIndexStr, jsonAsBytes)							//store name of Transaction
+	if err != nil {
+		return nil, err
+	}
+
+	// update the Merchants START
+	_, err = t.updateMerchantsPurchaseBal(stub, []string{fromMerchantId, strconv.FormatFloat(-valueOut, 'f', 2, 64), args[6]})
+	if err != nil {
+		return nil, err
+	}
+	_, err = t.updateMerchantsPurchaseBal(stub, []string{toMerchantId, strconv.FormatFloat(valueIn, 'f', 2, 64), args[6]})
+	if err != nil {
+		return nil, err
+	}
+	// update the Merchants END
+
+	// the Owner keeps the spread
+	spreadEarned, _ := strconv.ParseFloat(spread.SpreadEarned, 64)
+	spread.SpreadEarned = strconv.FormatFloat(spreadEarned + valueOut - valueIn, 'f', 2, 64)
+	spreadAsBytes, _ := json.Marshal(spread)
+	err = stub.PutState(owners.ExchangeSpreadStr, spreadAsBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"customerID\" : \""+customerId+"\", \"message\" : \"Points exchanged succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("Points exchanged succcessfully")
+	return nil, nil
+}
+// ============================================================================================================================
+// expire_points - take the points of a Merchant's lots that expired before asOfDate from every Customer, each Customer gets
+// an Expiry transaction with id transactionId-customerId. Only the Merchant or an Owner can expire points, and not as of
+// a date after the transaction
+// ============================================================================================================================
+func (t *ManageLPM) expire_points(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start expire_points")
+	// expire_points("merchantId", "asOfDate", "transactionId", "transactionDateTime")
+	if len(args) != 4 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 4\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	merchantId := args[0]
+	asOfDate := args[1]
+	merchantAsBytes, err := stub.GetState(merchantId)
+	if err != nil {
+		return nil, errors.New("Failed to get Merchant merchantID")
+	}
+	res_Merchant := merchants.Merchant{}
+	json.Unmarshal(merchantAsBytes, &res_Merchant)
+	_, errDate := time.Parse(common.DateLayout, asOfDate)
+	transactionTime, timeProblem := transactions.Stamp(args[3])
+	caller := common.CallerName(stub)
+	isOwner, err := owners.IsOwner(stub, caller)
+	if err != nil {
+		return nil, err
+	}
+	txDate, err := common.TxDate(stub)
+	if err != nil {
+		return nil, err
+	}
+	problem := ""
+	if res_Merchant.MerchantID != merchantId {
+		problem = merchantId + " Not Found."
+	} else if caller != res_Merchant.MerchantUserName && !isOwner {
+		problem = "Only the Merchant or an Owner can expire the Merchant's points"
+	} else if errDate != nil {
+		problem = "asOfDate must be a YYYY-MM-DD date"
+	} else if args[2] == "" {
+		problem = "Expecting a transactionId"
+	} else if timeProblem != "" {
+		problem = timeProblem
+	} else if asOfDate > txDate {
+		problem = "asOfDate cannot be after the transaction date " + txDate
+	}
+	if problem != "" {
+		errMsg := "{ \"merchantID\" : \""+merchantId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	balances, err := points.MerchantBalances(stub, merchantId)
+	if err != nil {
+		return nil, err
+	}
+	var transactionIds []string
+	for _, balance := range balances {
+		if expired, _ := points.ExpiredLots(balance.Lots, asOfDate); expired > 0 {
+			transactionIds = append(transactionIds, args[2] + "-" + balance.CustomerID)
+		}
+	}
+	problem, err = transactions.IdProblem(stub, transactionIds...)
+	if err != nil {
+		return nil, err
+	}
+	if problem != "" {
+		errMsg := "{ \"merchantID\" : \""+merchantId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	for _, balance := range balances {
+		expired, kept := points.ExpiredLots(balance.Lots, asOfDate)
+		if expired <= 0 {
+			continue
+		}
+		customerAsBytes, err := stub.GetState(balance.CustomerID)
+		if err != nil {
+			return nil, errors.New("Failed to get state for " + balance.CustomerID)
+		}
+		res := customers.Customer{}
+		json.Unmarshal(customerAsBytes, &res)
+		balance.Lots = kept
+		balance.PointsCount = balance.PointsCount - expired
+		points.Revalue(&balance, res_Merchant)
+		res.WalletWorth, err = points.WalletWorth(stub, balance)
+		if err != nil {
+			return nil, err
+		}
+		err = points.PutBalance(stub, balance)
+		if err != nil {
+			return nil, err
+		}
+		customerAsBytes, _ = json.Marshal(res)
+		err = stub.PutState(res.CustomerID, customerAsBytes)							//store Customer with id as key
+		if err != nil {
+			return nil, err
+		}
+		transactionId := args[2] + "-" + res.CustomerID
+		transactionAsBytes, _ := json.Marshal(transactions.Transaction{TransactionID: transactionId, TransactionDateTime: args[3], TransactionTime: transactionTime, TransactionType: "Expiry", TransactionFrom: res.UserName, TransactionTo: res_Merchant.MerchantName,
+			Credit: "0", Debit: strconv.FormatFloat(expired, 'f', 2, 64), CustomerID: res.CustomerID, MerchantID: merchantId})
+		err = stub.PutState(transactionId, transactionAsBytes)					//store Transaction with id as key
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	//get the Transaction index
+	transactionAsBytes, err := stub.GetState(transactions.TransactionIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Transaction index")
+	}
+	var transactionIndex []string	
+	json.Unmarshal(transactionAsBytes, &transactionIndex)							//un stringify it aka JSON.parse()
+	transactionIndex = append(transactionIndex, transactionIds...)					//add the Expiry transactions to index list
+	jsonAsBytes, _ := json.Marshal(transactionIndex)
+	err = stub.PutState(transactions.TransactionIndexStr, jsonAsBytes)							//store name of Transaction
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"merchantID\" : \""+merchantId+"\", \"message\" : \"Points of " + strconv.Itoa(len(transactionIds)) + " Customers expired succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("end expire_points")
+	return nil, nil
+}
+// ============================================================================================================================
+// getCustomerTier - a Customer's tier with a Merchant, the tier's earn multiplier and the points still missing for the next tier
+// ============================================================================================================================
+func (t *ManageLPM) getCustomerTier(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start getCustomerTier")
+	// getCustomerTier("customerId", "merchantId")
+	if len(args) != 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'customerId' and 'merchantId' as arguments\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	merchantAsBytes, err := stub.GetState(args[1])
+	if err != nil {
+		return nil, errors.New("Failed to get state for " + args[1])
+	}
+	merchant := merchants.Merchant{}
+	json.Unmarshal(merchantAsBytes, &merchant)
+	balance, err := points.GetBalance(stub, args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	if merchant.MerchantID != args[1] {
+		errMsg := "{ \"message\" : \"" + args[1] + " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	tier := merchants.TierFor(merchant, balance.Tier)
+	nextTier := ""
+	pointsToNextTier := float64(0)
+	for _, level := range merchant.Tiers {
+		if nextTier == "" && level.Threshold > balance.QualifyingPoints && (tier.TierName == "" || level.Threshold > tier.Threshold) {
+			nextTier = level.TierName
+			pointsToNextTier = common.RoundPoints(level.Threshold - balance.QualifyingPoints)
+		}
+	}
+	jsonResp := "{" +
+		"\"customerId\":\"" + args[0] + "\"," +
+		"\"merchantId\":\"" + args[1] + "\"," +
+		"\"tier\":\"" + tier.TierName + "\"," +
+		"\"tierSince\":\"" + balance.TierSince + "\"," +
+		"\"multiplier\":" + strconv.FormatFloat(tier.Multiplier, 'f', -1, 64) + "," +
+		"\"qualifyingPoints\":" + strconv.FormatFloat(balance.QualifyingPoints, 'f', 2, 64) + "," +
+		"\"tierWindowDays\":\"" + merchant.TierWindowDays + "\"," +
+		"\"nextTier\":\"" + nextTier + "\"," +
+		"\"pointsToNextTier\":" + strconv.FormatFloat(pointsToNextTier, 'f', 2, 64) +
+	"}"
+	fmt.Println("end getCustomerTier")
+	return []byte(jsonResp), nil											//send it onward
+}
+// ============================================================================================================================
+// getTierMembers - Balances of the active Customers in one of a Merchant's tiers
+// ============================================================================================================================
+func (t *ManageLPM) getTierMembers(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start getTierMembers")
+	// getTierMembers("merchantId", "tierName")
+	if len(args) != 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'merchantId' and 'tierName' as arguments\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	balances, err := points.MerchantBalances(stub, args[0])
+	if err != nil {
+		return nil, err
+	}
+	members := []points.Balance{}
+	for _, balance := range balances {
+		if balance.Tier == args[1] && common.ArchivedRecord(stub, balance.CustomerID) == "" {
+			members = append(members, balance)
+		}
+	}
+	membersAsBytes, _ := json.Marshal(members)
+	fmt.Println("end getTierMembers")
+	return membersAsBytes, nil											//send it onward
+}
+// ============================================================================================================================
+// reviewMerchantTiers - promote and demote every Customer of a Merchant to the tier their points earned in the tier window
+// ending on asOfDate reach, Customers who stopped earning drop out of their tier this way
+// ============================================================================================================================
+func (t *ManageLPM) reviewMerchantTiers(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start reviewMerchantTiers")
+	// reviewMerchantTiers("merchantId", "asOfDate")
+	if len(args) != 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 2\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	merchantId := args[0]
+	asOfDate := args[1]
+	merchantAsBytes, err := stub.GetState(merchantId)
+	if err != nil {
+		return nil, errors.New("Failed to get Merchant merchantID")
+	}
+	res_Merchant := merchants.Merchant{}
+	json.Unmarshal(merchantAsBytes, &res_Merchant)
+	caller := common.CallerName(stub)
+	isOwner, err := owners.IsOwner(stub, caller)
+	if err != nil {
+		return nil, err
+	}
+	txDate, err := common.TxDate(stub)
+	if err != nil {
+		return nil, err
+	}
+	problem := ""
+	if res_Merchant.MerchantID != merchantId {
+		problem = merchantId + " Not Found."
+	} else if caller != res_Merchant.MerchantUserName && !isOwner {
+		problem = "Only the Merchant or an Owner can review the Merchant's tiers"
+	} else if asOfDate == "" || !common.ValidDay(asOfDate) {
+		problem = "asOfDate must be a YYYY-MM-DD date"
+	} else if asOfDate > txDate {
+		problem = "asOfDate cannot be after the transaction date " + txDate
+	}
+	if problem != "" {
+		errMsg := "{ \"merchantID\" : \""+merchantId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	balances, err := points.MerchantBalances(stub, merchantId)
+	if err != nil {
+		return nil, err
+	}
+	qualifying, err := points.QualifyingPoints(stub, res_Merchant, "", asOfDate)
+	if err != nil {
+		return nil, err
+	}
+	changed := 0
+	for _, balance := range balances {
+		if len(res_Merchant.Tiers) == 0 && balance.Tier == "" {
+			continue
+		}
+		qualified := balance.QualifyingPoints
+		tierChanged := points.AssignTier(&balance, res_Merchant, common.RoundPoints(qualifying[balance.CustomerID]), asOfDate)
+		if !tierChanged && qualified == balance.QualifyingPoints {
+			continue
+		}
+		err = points.PutBalance(stub, balance)
+		if err != nil {
+			return nil, err
+		}
+		if tierChanged {
+			changed++
+		}
+	}
+
+	tosend := "{ \"merchantID\" : \""+merchantId+"\", \"message\" : \"Tiers of " + strconv.Itoa(changed) + " Customers changed succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("end reviewMerchantTiers")
+	return nil, nil
+}
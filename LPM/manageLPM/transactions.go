@@ -0,0 +1,999 @@
+/*/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+"errors"
+"fmt"
+"strconv"
+"encoding/json"
+"strings"
+"time"
+
+"github.com/hyperledger/fabric/core/chaincode/shim"	
+
+"Blockchain/LPM/common"
+"Blockchain/LPM/customers"
+"Blockchain/LPM/merchants"
+"Blockchain/LPM/transactions"
+"Blockchain/LPM/points"
+)
+
+// ============================================================================================================================
+//  getActivityHistory - get Customer Transaction Activity details for a given customer from chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) getActivityHistory(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var jsonResp, errResp string
+	var transactionIndex []string
+	var valIndex transactions.Transaction
+	var customerId string
+	var err error
+	fmt.Println("start getActivityHistory")
+	
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'customerId' as argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set customerId
+	customerId = args[0]
+	fmt.Println("customerId in getActivityHistory::" + customerId)
+		
+	transactionAsBytes, err := stub.GetState(transactions.TransactionIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Transaction index string")
+	}
+	json.Unmarshal(transactionAsBytes, &transactionIndex)								//un stringify it aka JSON.parse()
+	jsonResp = "{"
+	for i,val := range transactionIndex{
+		fmt.Println(strconv.Itoa(i) + " - looking at " + val + " for getActivityHistory")
+		valueAsBytes, err := stub.GetState(val)
+		if err != nil {
+			errResp = "{\"Error\":\"Failed to get state for " + val + "\"}"
+			return nil, errors.New(errResp)
+		}
+		fmt.Print("valueAsBytes : ")
+		fmt.Println(valueAsBytes)
+		json.Unmarshal(valueAsBytes, &valIndex)
+		fmt.Print("valIndex: ")
+		fmt.Print(valIndex)
+        if valIndex.CustomerID == customerId{
+			fmt.Println("Customer found")
+			jsonResp = jsonResp + "\""+ val + "\":" + string(valueAsBytes[:])
+			fmt.Println("jsonResp inside if")
+			fmt.Println(jsonResp)
+			fmt.Println("transactionIndex::")
+			fmt.Println(transactionIndex)
+			fmt.Println("length::")
+			fmt.Println(len(transactionIndex))
+			if i < len(transactionIndex)-1 {
+				fmt.Println("i::")
+				fmt.Println(i)
+				jsonResp = jsonResp + ","
+				fmt.Println("jsonResp inside if if")
+				fmt.Println(jsonResp)
+			}
+		} 
+	}
+	jsonResp = jsonResp + "}"
+	if strings.Contains(jsonResp, "},}"){
+		fmt.Println("in if for jsonResp contains wrong json")	
+		jsonResp = strings.Replace(jsonResp, "},}", "}}", -1)
+	}
+	fmt.Println("jsonResp : " + jsonResp)
+	fmt.Print("jsonResp in bytes : ")
+	fmt.Println([]byte(jsonResp))
+	fmt.Println("end getActivityHistory")
+	return []byte(jsonResp), nil											//send it onward
+}
+// ============================================================================================================================
+//  getActivityHistoryForMerchant - get Customer Transaction Activity details for a given merchant from chaincode state
+// ============================================================================================================================
+func (t *ManageLPM) getActivityHistoryForMerchant(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var jsonResp, errResp string
+	var transactionIndex []string
+	var valIndex transactions.Transaction
+	var merchantName string
+	var transactionTypeCustomerOnBoarding string
+	var err error
+	fmt.Println("start getActivityHistoryForMerchant")
+	
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'merchantName' as argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set merchantName
+	merchantName = args[0]
+	fmt.Println("merchantName in getActivityHistoryForMerchant::" + merchantName)
+
+	transactionAsBytes, err := stub.GetState(transactions.TransactionIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Transaction index string")
+	}
+	json.Unmarshal(transactionAsBytes, &transactionIndex)								//un stringify it aka JSON.parse()
+	jsonResp = "{"
+	transactionTypeCustomerOnBoarding = "CustomerOnBoarding"
+	for i,val := range transactionIndex{
+		fmt.Println(strconv.Itoa(i) + " - looking at " + val + " for getActivityHistoryForMerchant")
+		valueAsBytes, err := stub.GetState(val)
+		if err != nil {
+			errResp = "{\"Error\":\"Failed to get state for " + val + "\"}"
+			return nil, errors.New(errResp)
+		}
+		fmt.Print("valueAsBytes : ")
+		fmt.Println(valueAsBytes)
+		json.Unmarshal(valueAsBytes, &valIndex)
+		fmt.Print("valIndex: ")
+		fmt.Print(valIndex)
+
+		if valIndex.TransactionType == transactionTypeCustomerOnBoarding{
+			if valIndex.TransactionFrom == merchantName{
+				fmt.Println("Customer's merchant found for CustomerOnBoarding")
+				jsonResp = jsonResp + "\""+ val + "\":" + string(valueAsBytes[:])
+				fmt.Println("jsonResp inside if")
+				fmt.Println(jsonResp)
+				fmt.Println("transactionIndex::")
+				fmt.Println(transactionIndex)
+				fmt.Println("length::")
+				fmt.Println(len(transactionIndex))
+				if i < len(transactionIndex)-1 {
+					fmt.Println("i::")
+					fmt.Println(i)
+					jsonResp = jsonResp + ","
+					fmt.Println("jsonResp inside if if")
+					fmt.Println(jsonResp)
+				}
+			}
+		} else if valIndex.TransactionTo == merchantName{
+			fmt.Println("Customer's merchant found for other transactions")
+			jsonResp = jsonResp + "\""+ val + "\":" + string(valueAsBytes[:])
+			fmt.Println("jsonResp inside if")
+			fmt.Println(jsonResp)
+			fmt.Println("transactionIndex::")
+			fmt.Println(transactionIndex)
+			fmt.Println("length::")
+			fmt.Println(len(transactionIndex))
+			if i < len(transactionIndex)-1 {
+				fmt.Println("i::")
+				fmt.Println(i)
+				jsonResp = jsonResp + ","
+				fmt.Println("jsonResp inside if if")
+				fmt.Println(jsonResp)
+			}
+		}
+	}
+	jsonResp = jsonResp + "}"
+	if strings.Contains(jsonResp, "},}"){
+		fmt.Println("in if for jsonResp contains wrong json")	
+		jsonResp = strings.Replace(jsonResp, "},}", "}}", -1)
+	}
+	fmt.Println("jsonResp : " + jsonResp)
+	fmt.Print("jsonResp in bytes : ")
+	fmt.Println([]byte(jsonResp))
+	fmt.Println("end getActivityHistoryForMerchant")
+	return []byte(jsonResp), nil											//send it onward
+}
+// ============================================================================================================================
+//  getActivityStatement - get a page of the activity statement of a Customer, of a Customer with one Merchant or of all
+//  the Customers of a Merchant, over a date range and optionally for one transaction type
+// ============================================================================================================================
+func (t *ManageLPM) getActivityStatement(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start getActivityStatement")
+	// getActivityStatement("customerId", "merchantId", "fromDate", "toDate", optional "transactionType", optional "page", optional "pageSize")
+	if len(args) < 4 || len(args) > 7 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 4 to 7\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	page := 1
+	pageSize := transactions.ActivityPageSize
+	var errPage, errPageSize error
+	if len(args) > 5 && args[5] != "" {
+		page, errPage = strconv.Atoi(args[5])
+	}
+	if len(args) > 6 && args[6] != "" {
+		pageSize, errPageSize = strconv.Atoi(args[6])
+	}
+	statement, problem, err := t.activityStatement(stub, args)
+	if err != nil {
+		return nil, err
+	}
+	if problem == "" && (errPage != nil || page < 1) {
+		problem = "page must be a number from 1"
+	} else if problem == "" && (errPageSize != nil || pageSize < 1) {
+		problem = "pageSize must be a number from 1"
+	}
+	if problem != "" {
+		errMsg := "{ \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	//the balances and totals cover the whole range, only the lines are paged
+	first := (page - 1) * pageSize
+	if first > len(statement.Lines) {
+		first = len(statement.Lines)
+	}
+	last := first + pageSize
+	if last > len(statement.Lines) {
+		last = len(statement.Lines)
+	}
+	statement.Lines = statement.Lines[first:last]
+	statement.Page = page
+	statement.PageSize = pageSize
+	statementAsBytes, _ := json.Marshal(statement)
+	fmt.Println("end getActivityStatement")
+	return statementAsBytes, nil											//send it onward
+}
+// ============================================================================================================================
+//  getActivityStatementCSV - get a whole activity statement as CSV, the arguments are those of getActivityStatement
+//  without the paging
+// ============================================================================================================================
+func (t *ManageLPM) getActivityStatementCSV(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start getActivityStatementCSV")
+	// getActivityStatementCSV("customerId", "merchantId", "fromDate", "toDate", optional "transactionType")
+	if len(args) != 4 && len(args) != 5 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 4 or 5\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	statement, problem, err := t.activityStatement(stub, args)
+	if err != nil {
+		return nil, err
+	}
+	if problem != "" {
+		errMsg := "{ \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	fmt.Println("end getActivityStatementCSV")
+	return transactions.ActivityCSV(statement), nil
+}
+// ============================================================================================================================
+//  activityStatement - the whole activity statement asked for by ("customerId", "merchantId", "fromDate", "toDate",
+//  optional "transactionType"), with the problem when it can't be built. Either id can be "" but not both, a fromDate
+//  or toDate of "" leaves the range open and a toDate without a time includes that whole day
+// ============================================================================================================================
+func (t *ManageLPM) activityStatement(stub shim.ChaincodeStubInterface, args []string) (transactions.ActivityStatement, string, error) {
+	customerId := args[0]
+	merchantId := args[1]
+	transactionType := ""
+	if len(args) > 4 {
+		transactionType = args[4]
+	}
+	res := customers.Customer{}
+	if customerId != "" {
+		customerAsBytes, err := stub.GetState(customerId)
+		if err != nil {
+			return transactions.ActivityStatement{}, "", errors.New("Failed to get state for " + customerId)
+		}
+		json.Unmarshal(customerAsBytes, &res)
+	}
+	res_Merchant := merchants.Merchant{}
+	if merchantId != "" {
+		merchantAsBytes, err := stub.GetState(merchantId)
+		if err != nil {
+			return transactions.ActivityStatement{}, "", errors.New("Failed to get state for " + merchantId)
+		}
+		json.Unmarshal(merchantAsBytes, &res_Merchant)
+	}
+	var from, to time.Time
+	var errFrom, errTo error
+	if args[2] != "" {
+		from, errFrom = transactions.ParseDateTime(args[2])
+	}
+	if args[3] != "" {
+		to, errTo = transactions.ParseDateTime(args[3])
+		if errTo == nil && len(args[3]) == len(common.DateLayout) {
+			to = to.Add(24 * time.Hour - time.Second)
+		}
+	}
+	problem := ""
+	if customerId == "" && merchantId == "" {
+		problem = "Expecting a customerId or a merchantId"
+	} else if customerId != "" && res.CustomerID != customerId {
+		problem = customerId + " Not Found."
+	} else if merchantId != "" && res_Merchant.MerchantID != merchantId {
+		problem = merchantId + " Not Found."
+	} else if errFrom != nil || errTo != nil {
+		problem = "fromDate and toDate must be YYYY-MM-DD dates, optionally followed by a time"
+	} else if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		problem = "toDate can't be before fromDate"
+	}
+	if problem != "" {
+		return transactions.ActivityStatement{}, problem, nil
+	}
+	statement, err := transactions.Activity(stub, customerId, res_Merchant, from, to, transactionType)
+	statement.FromDate = args[2]
+	statement.ToDate = args[3]
+	return statement, "", err
+}
+// ============================================================================================================================
+// Write - update customer during accumulation into chaincode state, the points earned are derived from the merchant's rates
+// ============================================================================================================================
+func (t *ManageLPM) updateCustomerAccumulation(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("Updating Customer - accumulation")
+	// updateCustomerAccumulation("customerId", "merchantId", "purchaseAmount", "transactionId", "transactionDateTime", optional "category")
+	if len(args) != 5 && len(args) != 6 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 5 or 6\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set customerId
+	customerId := args[0]
+	merchantId := args[1]
+	archivedId := common.ArchivedRecord(stub, customerId, merchantId)
+	if archivedId != "" {
+		errMsg := "{ \"message\" : \"" + archivedId + " is archived and can't be updated.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	customerAsBytes, err := stub.GetState(customerId)					//get the Customer for the specified customerId from chaincode state
+	if err != nil {
+		errMsg := "{ \"message\" : \"Failed to get state for " + customerId + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	merchantAsBytes, err := stub.GetState(merchantId)					//get the Merchant whose rates price the points
+	if err != nil {
+		errMsg := "{ \"message\" : \"Failed to get state for " + merchantId + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	res := customers.Customer{}
+	res_Merchant := merchants.Merchant{}
+	res_trans := transactions.Transaction{}
+ 	transactionId := args[3]
+	json.Unmarshal(customerAsBytes, &res)
+	json.Unmarshal(merchantAsBytes, &res_Merchant)
+	_, err = points.MigrateCustomer(stub, &res)								//a Customer still on the legacy merchant lists is migrated first
+	if err != nil {
+		return nil, err
+	}
+	category := ""
+	if len(args) == 6 {
+		category = args[5]
+	}
+	purchaseAmount, err := strconv.ParseFloat(args[2], 64)
+	var pointsEarned float64
+	var ruleId string
+	var balance points.Balance
+	var lot points.PointsLot
+	var errBalance error
+	idProblem, errIds := transactions.IdProblem(stub, transactionId)
+	if errIds != nil {
+		return nil, errIds
+	}
+	transactionTime, timeProblem := transactions.Stamp(args[4])
+	problem := ""
+	if res.CustomerID != customerId {
+		problem = customerId + " Not Found."
+	} else if res_Merchant.MerchantID != merchantId {
+		problem = merchantId + " Not Found."
+	} else if err != nil || purchaseAmount <= 0 {
+		problem = "Purchase amount must be a positive number"
+	} else if idProblem != "" {
+		problem = idProblem
+	} else if timeProblem != "" {
+		problem = timeProblem
+	} else {
+		pointsEarned, ruleId, errBalance = points.EarnPoints(stub, res_Merchant, customerId, purchaseAmount, purchaseAmount, args[4], category)
+		if errBalance == nil {
+			lot, problem = points.EarnedLot(res_Merchant, pointsEarned, args[4])
+		}
+		if problem == "" && errBalance == nil {
+			balance, _, problem, errBalance = points.AdjustPoints(stub, &res, res_Merchant, 0, []points.PointsLot{lot})
+			balance.Purchases++
+		}
+		if problem == "" && errBalance == nil {
+			_, errBalance = points.ReviewTier(stub, &balance, res_Merchant, lot.EarnedAt, lot.Points)
+		}
+	}
+	if errBalance != nil {
+		return nil, errBalance
+	}
+	if problem != "" {
+		errMsg := "{ \"customerID\" : \""+customerId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	fmt.Println("Customer found with customerId : " + customerId)
+	fmt.Println(res);
+	res_trans.TransactionID = transactionId
+ 	res_trans.TransactionDateTime = args[4]
+ 	res_trans.TransactionTime = transactionTime
+ 	res_trans.TransactionType = "Accumulation"
+ 	res_trans.TransactionFrom = res_Merchant.MerchantName
+ 	res_trans.TransactionTo = res.UserName
+ 	res_trans.Credit = strconv.FormatFloat(pointsEarned, 'f', 2, 64)
+ 	res_trans.Debit = "0"
+ 	res_trans.CustomerID = customerId
+ 	res_trans.AppliedRuleID = ruleId
+	
+	err = points.PutBalance(stub, balance)
+	if err != nil {
+		return nil, err
+	}
+	customerAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(customerId, customerAsBytes)							//store Customer with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	// build the Transaction json string manually
+	transaction_json := `{`+
+		`"transactionId": "` + transactionId + `" , `+
+		`"transactionDateTime": "` + res_trans.TransactionDateTime + `" , `+
+		`"transactionTime": ` + strconv.FormatInt(res_trans.TransactionTime, 10) + ` , `+
+		`"transactionType": "` + res_trans.TransactionType + `" , `+
+		`"transactionFrom": "` + res_trans.TransactionFrom + `" , `+ 
+		`"transactionTo": "` + res_trans.TransactionTo + `" , `+ 
+		`"credit": "` + res_trans.Credit + `" , `+ 
+		`"debit": "` + res_trans.Debit + `" , `+ 
+		`"customerId": "` +  res_trans.CustomerID + `" , `+ 
+		`"merchantId": "` +  merchantId + `" , `+ 
+		`"appliedRuleId": "` +  res_trans.AppliedRuleID + `" `+ 
+	`}`
+	err = stub.PutState(transactionId, []byte(transaction_json))									//store Transaction with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	//get the Transaction index
+	transactionAsBytes, err := stub.GetState(transactions.TransactionIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Transaction index")
+	}
+	var transactionIndex []string	
+	json.Unmarshal(transactionAsBytes, &transactionIndex)							//un stringify it aka JSON.parse()
+	
+	//append
+	transactionIndex = append(transactionIndex, transactionId)									//add Transaction transactionId to index list
+	
+	jsonAsBytes, _ := json.Marshal(transactionIndex)
+	fmt.Print("update customer jsonAsBytes: ")
+	fmt.Println(jsonAsBytes)
+	err = stub.PutState(transactions.TransactionIndexStr, jsonAsBytes)						//store name of Transaction
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"customerID\" : \""+customerId+"\", \"message\" : \"Customer details updated succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("Customer details updated succcessfully")
+	return nil, nil
+}
+// ============================================================================================================================
+// Write - update customer during redemption into chaincode state, points redeemed pay part of the purchase and the rest earns points
+// ============================================================================================================================
+func (t *ManageLPM) updateCustomerPurchase(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("Updating Customer - purchase")
+	// updateCustomerPurchase("customerId", "merchantId", "purchaseAmount", "pointsRedeemed", "transactionId1", "transactionId2", "transactionDateTime", optional "category")
+	if len(args) != 7 && len(args) != 8 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 7 or 8\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set customerId
+	customerId := args[0]
+	merchantId := args[1]
+	archivedId := common.ArchivedRecord(stub, customerId, merchantId)
+	if archivedId != "" {
+		errMsg := "{ \"message\" : \"" + archivedId + " is archived and can't be updated.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	customerAsBytes, err := stub.GetState(customerId)					//get the Customer for the specified customerId from chaincode state
+	if err != nil {
+		errMsg := "{ \"message\" : \"Failed to get state for " + customerId + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	merchantAsBytes, err := stub.GetState(merchantId)					//get the Merchant whose rates price the points
+	if err != nil {
+		errMsg := "{ \"message\" : \"Failed to get state for " + merchantId + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	res := customers.Customer{}
+	res_trans1 := transactions.Transaction{}
+ 	res_trans2 := transactions.Transaction{}
+ 	res_Merchant := merchants.Merchant{}
+
+  	transactionId1 := args[4]
+ 	transactionId2 := args[5]
+	json.Unmarshal(customerAsBytes, &res)
+	json.Unmarshal(merchantAsBytes, &res_Merchant)
+	_, err = points.MigrateCustomer(stub, &res)								//a Customer still on the legacy merchant lists is migrated first
+	if err != nil {
+		return nil, err
+	}
+	purchaseAmount, err := strconv.ParseFloat(args[2], 64)
+	pointsRedeemed, errPoints := strconv.ParseFloat(args[3], 64)
+	exchangeRate, _ := strconv.ParseFloat(res_Merchant.ExchangeRate, 64)
+	redeemedWorth := pointsRedeemed * exchangeRate
+	category := ""
+	if len(args) == 8 {
+		category = args[7]
+	}
+	var pointsEarned float64
+	var ruleId string
+	var balance points.Balance
+	var lot points.PointsLot
+	var errBalance error
+	idProblem, errIds := transactions.IdProblem(stub, transactionId1, transactionId2)
+	if errIds != nil {
+		return nil, errIds
+	}
+	transactionTime, timeProblem := transactions.Stamp(args[6])
+	problem := ""
+	if res.CustomerID != customerId {
+		problem = customerId + " Not Found."
+	} else if res_Merchant.MerchantID != merchantId {
+		problem = merchantId + " Not Found."
+	} else if err != nil || purchaseAmount <= 0 {
+		problem = "Purchase amount must be a positive number"
+	} else if errPoints != nil || pointsRedeemed < 0 {
+		problem = "Points redeemed must be zero or a positive number"
+	} else if redeemedWorth > purchaseAmount {
+		problem = "Points redeemed are worth more than the purchase"
+	} else if idProblem != "" {
+		problem = idProblem
+	} else if timeProblem != "" {
+		problem = timeProblem
+	} else {
+		//the part paid in cash earns points, the basket is the whole purchase
+		pointsEarned, ruleId, errBalance = points.EarnPoints(stub, res_Merchant, customerId, purchaseAmount - redeemedWorth, purchaseAmount, args[6], category)
+		if errBalance == nil {
+			lot, problem = points.EarnedLot(res_Merchant, pointsEarned, args[6])
+		}
+		//the redeemed points are taken from the oldest lots, the points earned are added in the same Balance update
+		if problem == "" && errBalance == nil {
+			balance, _, problem, errBalance = points.AdjustPoints(stub, &res, res_Merchant, pointsRedeemed, []points.PointsLot{lot})
+			balance.Purchases++
+		}
+		if problem == "" && errBalance == nil {
+			_, errBalance = points.ReviewTier(stub, &balance, res_Merchant, lot.EarnedAt, lot.Points)
+		}
+	}
+	if errBalance != nil {
+		return nil, errBalance
+	}
+	if problem != "" {
+		errMsg := "{ \"customerID\" : \""+customerId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	fmt.Println("Customer found with customerId : " + customerId)
+	fmt.Println(res);
+	res_trans1.TransactionID = transactionId1
+ 	res_trans1.TransactionDateTime = args[6]
+ 	res_trans1.TransactionTime = transactionTime
+ 	res_trans1.TransactionType = "Purchase"
+ 	res_trans1.TransactionFrom = res.UserName
+ 	res_trans1.TransactionTo = res_Merchant.MerchantName
+ 	res_trans1.Credit = "0"
+ 	res_trans1.Debit = strconv.FormatFloat(pointsRedeemed, 'f', 2, 64)
+ 	res_trans1.CustomerID = customerId
+ 	res_trans2.TransactionID = transactionId2
+ 	res_trans2.TransactionDateTime = args[6]
+ 	res_trans2.TransactionTime = transactionTime
+ 	res_trans2.TransactionType = "Purchase"
+ 	res_trans2.TransactionFrom = res_Merchant.MerchantName
+ 	res_trans2.TransactionTo = res.UserName
+ 	res_trans2.Credit = strconv.FormatFloat(pointsEarned, 'f', 2, 64)
+ 	res_trans2.Debit = "0"
+ 	res_trans2.CustomerID = customerId
+ 	res_trans2.AppliedRuleID = ruleId
+ 	res_Merchant.PurchaseBalance = strconv.FormatFloat(redeemedWorth, 'f', 2, 64)
+ 	res_Merchant.MerchantCU_date = args[6]
+	
+	err = points.PutBalance(stub, balance)
+	if err != nil {
+		return nil, err
+	}
+	customerAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(customerId, customerAsBytes)							//store Customer with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	// build the Transaction1 json string manually
+ 	transaction_json1 := `{`+
+ 		`"transactionId": "` + transactionId1 + `" , `+
+ 		`"transactionDateTime": "` + res_trans1.TransactionDateTime + `" , `+
+ 		`"transactionTime": ` + strconv.FormatInt(res_trans1.TransactionTime, 10) + ` , `+
+ 		`"transactionType": "` + res_trans1.TransactionType + `" , `+
+ 		`"transactionFrom": "` + res_trans1.TransactionFrom + `" , `+ 
+ 		`"transactionTo": "` + res_trans1.TransactionTo + `" , `+ 
+ 		`"credit": "` + res_trans1.Credit + `" , `+ 
+ 		`"debit": "` + res_trans1.Debit + `" , `+ 
+ 		`"customerId": "` +  res_trans1.CustomerID + `" , `+ 
+ 		`"merchantId": "` +  merchantId + `" `+ 
+    `}`
+	err = stub.PutState(transactionId1, []byte(transaction_json1))					//store Transaction with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	//get the Transaction index
+	transactionAsBytes1, err := stub.GetState(transactions.TransactionIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Transaction index")
+	}
+	var transactionIndex1 []string	
+	json.Unmarshal(transactionAsBytes1, &transactionIndex1)							//un stringify it aka JSON.parse()
+	
+	//append
+	transactionIndex1 = append(transactionIndex1, transactionId1)					//add Transaction transactionId to index list
+	
+	jsonAsBytes1, _ := json.Marshal(transactionIndex1)
+	fmt.Print("update customer jsonAsBytes1: ")
+	fmt.Println(jsonAsBytes1)
+	err = stub.PutState(transactions.TransactionIndexStr, jsonAsBytes1)							//store name of Transaction
+	if err != nil {
+		return nil, err
+	}
+
+	// build the Transaction2 json string manually
+ 	transaction_json2 := `{`+
+ 		`"transactionId": "` + transactionId2 + `" , `+
+ 		`"transactionDateTime": "` + res_trans2.TransactionDateTime + `" , `+
+ 		`"transactionTime": ` + strconv.FormatInt(res_trans2.TransactionTime, 10) + ` , `+
+ 		`"transactionType": "` + res_trans2.TransactionType + `" , `+
+ 		`"transactionFrom": "` + res_trans2.TransactionFrom + `" , `+ 
+ 		`"transactionTo": "` + res_trans2.TransactionTo + `" , `+ 
+ 		`"credit": "` + res_trans2.Credit + `" , `+ 
+ 		`"debit": "` + res_trans2.Debit + `" , `+ 
+ 		`"customerId": "` +  res_trans2.CustomerID + `" , `+ 
+ 		`"merchantId": "` +  merchantId + `" , `+ 
+ 		`"appliedRuleId": "` +  res_trans2.AppliedRuleID + `" `+ 
+ 	`}`
+ 	err = stub.PutState(transactionId2, []byte(transaction_json2))					//store Transaction with id as key
+ 	if err != nil {
+ 		return nil, err
+ 	}
+ 
+ 	//get the Transaction index
+ 	transactionAsBytes2, err := stub.GetState(transactions.TransactionIndexStr)
+ 	if err != nil {
+ 		return nil, errors.New("Failed to get Transaction index")
+ 	}
+ 	var transactionIndex2 []string	
+ 	json.Unmarshal(transactionAsBytes2, &transactionIndex2)							//un stringify it aka JSON.parse()
+ 	
+ 	//append
+ 	transactionIndex2 = append(transactionIndex2, transactionId2)					//add Transaction transactionId to index list
+ 	jsonAsBytes2, _ := json.Marshal(transactionIndex2)
+ 	fmt.Println(jsonAsBytes2)
+ 	err = stub.PutState(transactions.TransactionIndexStr, jsonAsBytes2)							//store name of Transaction
+  	if err != nil {
+  		return nil, err
+  	}
+
+	// update the Merchant START
+	merchant_args := []string{res_Merchant.MerchantID, res_Merchant.PurchaseBalance, res_Merchant.MerchantCU_date}
+	_, err = t.updateMerchantsPurchaseBal(stub, merchant_args)
+	if err != nil {
+		return nil, err
+	}
+ 	// update the Merchant END
+
+	tosend := "{ \"customerID\" : \""+customerId+"\", \"message\" : \"Customer details updated succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("Customer details updated succcessfully")
+	return nil, nil
+}
+// ============================================================================================================================
+// Write - update customer during transfer into chaincode state, the points of one merchant move between two customers
+// ============================================================================================================================
+func (t *ManageLPM) updateCustomerTransfer(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("Updating Customer - transfer")
+	// updateCustomerTransfer("fromCustomerId", "toCustomerId", "merchantId", "points", "transactionId1", "transactionId2", "transactionDateTime")
+	if len(args) != 7 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 7\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set customerIds
+	customerId1 := args[0]
+	customerId2 := args[1]
+	merchantId := args[2]
+	archivedId := common.ArchivedRecord(stub, customerId1, customerId2, merchantId)
+	if archivedId != "" {
+		errMsg := "{ \"message\" : \"" + archivedId + " is archived and can't be updated.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	res1 := customers.Customer{}
+	res2 := customers.Customer{}
+	res_Merchant := merchants.Merchant{}
+	res_trans1 := transactions.Transaction{}
+ 	res_trans2 := transactions.Transaction{}
+  	transactionId1 := args[4]
+ 	transactionId2 := args[5]
+	
+	customer1AsBytes, err := stub.GetState(customerId1)					//get the Customer for the specified customerId from chaincode state
+	if err != nil {
+		errMsg := "{ \"message\" : \"Failed to get state for " + customerId1 + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	customer2AsBytes, err := stub.GetState(customerId2)					//get the Customer for the specified customerId from chaincode state
+	if err != nil {
+		errMsg := "{ \"message\" : \"Failed to get state for " + customerId2 + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	merchantAsBytes, err := stub.GetState(merchantId)					//get the Merchant whose points are transferred
+	if err != nil {
+		errMsg := "{ \"message\" : \"Failed to get state for " + merchantId + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	json.Unmarshal(customer1AsBytes, &res1)
+	json.Unmarshal(customer2AsBytes, &res2)
+	json.Unmarshal(merchantAsBytes, &res_Merchant)
+	_, err = points.MigrateCustomer(stub, &res1)								//Customers still on the legacy merchant lists are migrated first
+	if err != nil {
+		return nil, err
+	}
+	_, err = points.MigrateCustomer(stub, &res2)
+	if err != nil {
+		return nil, err
+	}
+	pointsTransferred, err := strconv.ParseFloat(args[3], 64)
+	var balance1, balance2 points.Balance
+	var lots []points.PointsLot
+	var errBalance error
+	idProblem, errIds := transactions.IdProblem(stub, transactionId1, transactionId2)
+	if errIds != nil {
+		return nil, errIds
+	}
+	transactionTime, timeProblem := transactions.Stamp(args[6])
+	problem := ""
+	if res1.CustomerID != customerId1 {
+		problem = customerId1 + " Not Found."
+	} else if res2.CustomerID != customerId2 {
+		problem = customerId2 + " Not Found."
+	} else if customerId1 == customerId2 {
+		problem = "Points can't be transferred to the same customer"
+	} else if res_Merchant.MerchantID != merchantId {
+		problem = merchantId + " Not Found."
+	} else if err != nil || pointsTransferred <= 0 {
+		problem = "Points must be a positive number"
+	} else if idProblem != "" {
+		problem = idProblem
+	} else if timeProblem != "" {
+		problem = timeProblem
+	} else {
+		//the lots taken keep their earn and expiry dates with the receiving Customer
+		balance1, lots, problem, errBalance = points.AdjustPoints(stub, &res1, res_Merchant, pointsTransferred, nil)
+		if problem == "" && errBalance == nil {
+			balance2, _, problem, errBalance = points.AdjustPoints(stub, &res2, res_Merchant, 0, lots)
+		}
+	}
+	if errBalance != nil {
+		return nil, errBalance
+	}
+	if problem != "" {
+		errMsg := "{ \"customerID\" : \""+customerId1+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	fmt.Println("Customers found with customerId1 : " + customerId1 + " and customerId2 : " + customerId2)
+	
+	res_trans1.TransactionID = transactionId1
+	res_trans1.TransactionDateTime = args[6]
+	res_trans1.TransactionTime = transactionTime
+	res_trans1.TransactionType = "Transfer"
+	res_trans1.TransactionFrom = res1.UserName
+	res_trans1.TransactionTo = res2.UserName
+	res_trans1.Credit = "0"
+	res_trans1.Debit = strconv.FormatFloat(pointsTransferred, 'f', 2, 64)
+	res_trans1.CustomerID = customerId1
+	res_trans2.TransactionID = transactionId2
+	res_trans2.TransactionDateTime = args[6]
+	res_trans2.TransactionTime = transactionTime
+	res_trans2.TransactionType = "Transfer"
+	res_trans2.TransactionFrom = res1.UserName
+	res_trans2.TransactionTo = res2.UserName
+	res_trans2.Credit = strconv.FormatFloat(pointsTransferred, 'f', 2, 64)
+	res_trans2.Debit = "0"
+	res_trans2.CustomerID = customerId2
+
+	err = points.PutBalance(stub, balance1)
+	if err != nil {
+		return nil, err
+	}
+	customer1AsBytes, _ = json.Marshal(res1)
+	err = stub.PutState(customerId1, customer1AsBytes)							//store Customer with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	err = points.PutBalance(stub, balance2)
+	if err != nil {
+		return nil, err
+	}
+	customer2AsBytes, _ = json.Marshal(res2)
+	err = stub.PutState(customerId2, customer2AsBytes)							//store Customer with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	// build the Transaction1 json string manually
+ 	transaction_json1 := `{`+
+ 		`"transactionId": "` + transactionId1 + `" , `+
+ 		`"transactionDateTime": "` + res_trans1.TransactionDateTime + `" , `+
+ 		`"transactionTime": ` + strconv.FormatInt(res_trans1.TransactionTime, 10) + ` , `+
+ 		`"transactionType": "` + res_trans1.TransactionType + `" , `+
+ 		`"transactionFrom": "` + res_trans1.TransactionFrom + `" , `+ 
+ 		`"transactionTo": "` + res_trans1.TransactionTo + `" , `+ 
+ 		`"credit": "` + res_trans1.Credit + `" , `+ 
+ 		`"debit": "` + res_trans1.Debit + `" , `+ 
+ 		`"customerId": "` +  res_trans1.CustomerID + `" , `+ 
+ 		`"merchantId": "` +  merchantId + `" `+ 
+    `}`
+	err = stub.PutState(transactionId1, []byte(transaction_json1))					//store Transaction with id as key
+	if err != nil {
+		return nil, err
+	}
+
+	//get the Transaction index
+	transactionAsBytes1, err := stub.GetState(transactions.TransactionIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Transaction index")
+	}
+	var transactionIndex1 []string	
+	json.Unmarshal(transactionAsBytes1, &transactionIndex1)							//un stringify it aka JSON.parse()
+	
+	//append
+	transactionIndex1 = append(transactionIndex1, transactionId1)					//add Transaction transactionId to index list
+	
+	jsonAsBytes1, _ := json.Marshal(transactionIndex1)
+	fmt.Print("update customer jsonAsBytes1: ")
+	fmt.Println(jsonAsBytes1)
+	err = stub.PutState(transactions.TransactionIndexStr, jsonAsBytes1)							//store name of Transaction
+	if err != nil {
+		return nil, err
+	}
+
+	// build the Transaction2 json string manually
+ 	transaction_json2 := `{`+
+ 		`"transactionId": "` + transactionId2 + `" , `+
+ 		`"transactionDateTime": "` + res_trans2.TransactionDateTime + `" , `+
+ 		`"transactionTime": ` + strconv.FormatInt(res_trans2.TransactionTime, 10) + ` , `+
+ 		`"transactionType": "` + res_trans2.TransactionType + `" , `+
+ 		`"transactionFrom": "` + res_trans2.TransactionFrom + `" , `+ 
+ 		`"transactionTo": "` + res_trans2.TransactionTo + `" , `+ 
+ 		`"credit": "` + res_trans2.Credit + `" , `+ 
+ 		`"debit": "` + res_trans2.Debit + `" , `+ 
+ 		`"customerId": "` +  res_trans2.CustomerID + `" , `+ 
+ 		`"merchantId": "` +  merchantId + `" `+ 
+ 	`}`
+ 	err = stub.PutState(transactionId2, []byte(transaction_json2))					//store Transaction with id as key
+ 	if err != nil {
+ 		return nil, err
+ 	}
+ 
+ 	//get the Transaction index
+ 	transactionAsBytes2, err := stub.GetState(transactions.TransactionIndexStr)
+ 	if err != nil {
+ 		return nil, errors.New("Failed to get Transaction index")
+ 	}
+ 	var transactionIndex2 []string	
+ 	json.Unmarshal(transactionAsBytes2, &transactionIndex2)							//un stringify it aka JSON.parse()
+ 	
+ 	//append
+ 	transactionIndex2 = append(transactionIndex2, transactionId2)					//add Transaction transactionId to index list
+ 	jsonAsBytes2, _ := json.Marshal(transactionIndex2)
+ 	fmt.Println(jsonAsBytes2)
+ 	err = stub.PutState(transactions.TransactionIndexStr, jsonAsBytes2)							//store name of Transaction
+  	if err != nil {
+  		return nil, err
+  	}
+
+	tosend := "{ \"customerID\" : \""+customerId1+"\", \"message\" : \"Customer details updated succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("Customer details updated succcessfully for transfer")
+	return nil, nil
+}
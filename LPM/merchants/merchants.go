@@ -0,0 +1,134 @@
+/*/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Package merchants holds the Merchant record with its expiry policy, tiers and earn rules
+package merchants
+
+import (
+"errors"
+"encoding/json"
+
+"github.com/hyperledger/fabric/core/chaincode/shim"	
+
+"Blockchain/LPM/common"
+)
+
+var MerchantIndexStr = "_Merchantindex"				//name for the key/value that will store a list of all known Merchant
+var ExpiryMonthsPolicy = "months"				//a Merchant's points expire expiryMonths after they were earned
+var ExpiryYearEndPolicy = "yearEnd"			//a Merchant's points expire at the end of the year they were earned in
+var EarnRulePrefix = "_EarnRule_"				//prefix of the composite key merchantId, ruleId an EarnRule is stored under
+var EarnRuleDraft = "Draft"						//status of an EarnRule until the Merchant activates it
+var EarnRuleActive = "Active"
+var EarnRuleExpired = "Expired"
+var EarnRuleTypes = []string{"weekend", "basket", "category", "firstPurchase"}	//the promotions an EarnRule can run
+
+type Merchant struct{							// Attributes of a Merchant
+	MerchantID string `json:"merchantId"`					
+	MerchantUserName string `json:"merchantUserName"`
+	MerchantName string `json:"merchantName"`
+	MerchantIndustry string `json:"merchantIndustry"`
+	IndustryColor string `json:"industryColor"`					
+	PointsPerDollarSpent string `json:"pointsPerDollarSpent"`
+	ExchangeRate string `json:"exchangeRate"`
+	PurchaseBalance string `json:"purchaseBalance"`
+	MerchantCurrency string `json:"merchantCurrency"`
+	MerchantCU_date string `json:"merchantCU_date"`
+	MerchantInitialBalance string `json:"merchantInitialBalance,omitempty"`	// kept from the records of the Orig chaincode
+	ExpiryPolicy string `json:"expiryPolicy,omitempty"`			// never when empty, months or yearEnd
+	ExpiryMonths string `json:"expiryMonths,omitempty"`
+	TierWindowDays string `json:"tierWindowDays,omitempty"`		// days of Transaction history a Customer's tier is qualified on
+	Tiers []Tier `json:"tiers,omitempty"`						// lowest threshold first, no tiers when empty
+	Archive *common.Archive `json:"archive,omitempty"`
+}
+
+type Tier struct{								// A Merchant's status level, reached by earning Threshold points within the tier window
+	TierName string `json:"tierName"`
+	Threshold float64 `json:"threshold"`
+	Multiplier float64 `json:"multiplier"`					// multiplies the points per dollar spent of the Customers in the tier
+}
+
+type EarnRule struct{							// A Merchant's promotion, a purchase it applies to earns PointsPerDollarSpent * Multiplier + BonusPoints
+	RuleID string `json:"ruleId"`
+	MerchantID string `json:"merchantId"`
+	RuleName string `json:"ruleName"`
+	RuleType string `json:"ruleType"`						// weekend, basket (from MinimumBasket), category or firstPurchase
+	Multiplier float64 `json:"multiplier"`
+	BonusPoints float64 `json:"bonusPoints"`
+	MinimumBasket float64 `json:"minimumBasket,omitempty"`
+	Category string `json:"category,omitempty"`
+	ValidFrom string `json:"validFrom,omitempty"`				// YYYY-MM-DD, both days included, open ended when empty
+	ValidTo string `json:"validTo,omitempty"`
+	Status string `json:"status"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// ============================================================================================================================
+// GetEarnRule - a Merchant's EarnRule, an empty EarnRule when there's none with the id
+// ============================================================================================================================
+func GetEarnRule(stub shim.ChaincodeStubInterface, merchantId string, ruleId string) (EarnRule, error) {
+	rule := EarnRule{}
+	ruleAsBytes, err := stub.GetState(common.CompositeKey(EarnRulePrefix, merchantId, ruleId))
+	if err != nil {
+		return rule, errors.New("Failed to get Rule " + ruleId + " of " + merchantId)
+	}
+	json.Unmarshal(ruleAsBytes, &rule)
+	return rule, nil
+}
+// ============================================================================================================================
+// EarnRules - all EarnRules of a Merchant, in rule id order
+// ============================================================================================================================
+func EarnRules(stub shim.ChaincodeStubInterface, merchantId string) ([]EarnRule, error) {
+	var rules []EarnRule
+	keysIter, err := stub.RangeQueryState(EarnRulePrefix + merchantId + common.KeySeparator, EarnRulePrefix + merchantId + "\x01")
+	if err != nil {
+		return nil, errors.New("Failed to get Rules of " + merchantId)
+	}
+	defer keysIter.Close()
+	for keysIter.HasNext() {
+		_, ruleAsBytes, err := keysIter.Next()
+		if err != nil {
+			return nil, errors.New("Failed to get Rules of " + merchantId)
+		}
+		rule := EarnRule{}
+		json.Unmarshal(ruleAsBytes, &rule)
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+// ============================================================================================================================
+// TierFor - the Merchant's Tier with the name a Balance holds, multiplying by 1 when it's none or the Merchant dropped it
+// ============================================================================================================================
+func TierFor(merchant Merchant, tierName string) Tier {
+	for _, tier := range merchant.Tiers {
+		if tierName != "" && tier.TierName == tierName {
+			return tier
+		}
+	}
+	return Tier{Multiplier: 1}
+}
+// ============================================================================================================================
+// QualifiedTier - the highest of a Merchant's tiers the qualifying points reach, an empty Tier below the lowest threshold
+// ============================================================================================================================
+func QualifiedTier(merchant Merchant, points float64) Tier {
+	qualified := Tier{Multiplier: 1}
+	for _, tier := range merchant.Tiers {
+		if points + 0.005 >= tier.Threshold {
+			qualified = tier
+		}
+	}
+	return qualified
+}
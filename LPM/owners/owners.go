@@ -0,0 +1,78 @@
+/*/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Package owners holds the Owner record and the exchange spread the Owner sets
+package owners
+
+import (
+"errors"
+"encoding/json"
+
+"github.com/hyperledger/fabric/core/chaincode/shim"	
+)
+
+var OwnerIndexStr = "_Ownerindex"				//name for the key/value that will store a list of all known Owner
+var ExchangeSpreadStr = "_ExchangeSpread"		//name for the key/value that will store the ExchangeSpread set by the Owner
+
+type Owner struct{							// Attributes of a Owner
+	OwnerID string `json:"ownerId"`					
+	OwnerUserName string `json:"ownerUserName"`
+	OwnerName string `json:"ownerName"`
+}
+
+type ExchangeSpread struct{						// Share of the value an Owner keeps when points of one Merchant are exchanged for another's
+	Spread string `json:"spread"`
+	SetBy string `json:"setBy"`
+	SetAt string `json:"setAt"`
+	SpreadEarned string `json:"spreadEarned"`					// dollar value kept from all exchanges so far
+}
+
+// ============================================================================================================================
+// GetExchangeSpread - the ExchangeSpread set by the Owner, no spread until the Owner sets one
+// ============================================================================================================================
+func GetExchangeSpread(stub shim.ChaincodeStubInterface) (ExchangeSpread, error) {
+	spread := ExchangeSpread{Spread: "0", SpreadEarned: "0"}
+	spreadAsBytes, err := stub.GetState(ExchangeSpreadStr)
+	if err != nil {
+		return spread, errors.New("Failed to get the exchange spread")
+	}
+	json.Unmarshal(spreadAsBytes, &spread)
+	return spread, nil
+}
+// ============================================================================================================================
+// IsOwner - whether userName is the OwnerUserName of one of the Owners
+// ============================================================================================================================
+func IsOwner(stub shim.ChaincodeStubInterface, userName string) (bool, error) {
+	var ownerIndex []string
+	ownerIndexAsBytes, err := stub.GetState(OwnerIndexStr)
+	if err != nil {
+		return false, errors.New("Failed to get Owner index")
+	}
+	json.Unmarshal(ownerIndexAsBytes, &ownerIndex)
+	for _, ownerId := range ownerIndex {
+		ownerAsBytes, err := stub.GetState(ownerId)
+		if err != nil {
+			return false, errors.New("Failed to get state for " + ownerId)
+		}
+		owner := Owner{}
+		json.Unmarshal(ownerAsBytes, &owner)
+		if userName != "" && owner.OwnerUserName == userName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,487 @@
+/*/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Package points holds the Balances Customers have with Merchants, their lots, earning, tiers and exchange pricing
+package points
+
+import (
+"errors"
+"strconv"
+"encoding/json"
+"strings"
+"time"
+
+"github.com/hyperledger/fabric/core/chaincode/shim"	
+
+"Blockchain/LPM/common"
+"Blockchain/LPM/customers"
+"Blockchain/LPM/merchants"
+"Blockchain/LPM/owners"
+"Blockchain/LPM/transactions"
+)
+
+var BalancePrefix = "_Balance_"					//prefix of the composite key customerId, merchantId a Balance is stored under
+var MerchantBalancePrefix = "_MerchantBalance_"	//prefix of the composite key merchantId, customerId the same Balance is stored under
+
+type Balance struct{							// Points a Customer holds with one Merchant
+	CustomerID string `json:"customerId"`
+	MerchantID string `json:"merchantId"`
+	MerchantName string `json:"merchantName"`
+	MerchantColor string `json:"merchantColor"`
+	MerchantCurrency string `json:"merchantCurrency"`
+	PointsCount float64 `json:"pointsCount"`
+	PointsWorth float64 `json:"pointsWorth"`
+	Lots []PointsLot `json:"lots,omitempty"`					// oldest first, points held from before lots were tracked aren't in a lot
+	Purchases int `json:"purchases,omitempty"`					// purchases earning points since earn rules were added
+	Tier string `json:"tier,omitempty"`						// the Merchant's tier the Customer qualified for, none when empty
+	TierSince string `json:"tierSince,omitempty"`
+	QualifyingPoints float64 `json:"qualifyingPoints,omitempty"`		// points earned within the tier window when the tier was last reviewed
+}
+
+type PointsLot struct{							// Points earned together, they expire together
+	Points float64 `json:"points"`
+	EarnedAt string `json:"earnedAt"`
+	ExpiresAt string `json:"expiresAt,omitempty"`				// the last day the points can be used, never when empty
+}
+
+// ============================================================================================================================
+// GetBalance - the Balance a Customer holds with a Merchant, an empty Balance when the Customer holds none
+// ============================================================================================================================
+func GetBalance(stub shim.ChaincodeStubInterface, customerId string, merchantId string) (Balance, error) {
+	balance := Balance{}
+	balanceAsBytes, err := stub.GetState(common.CompositeKey(BalancePrefix, customerId, merchantId))
+	if err != nil {
+		return balance, errors.New("Failed to get Balance of " + customerId + " with " + merchantId)
+	}
+	json.Unmarshal(balanceAsBytes, &balance)
+	return balance, nil
+}
+// ============================================================================================================================
+// PutBalance - store a Balance under both the Customer first and the Merchant first key
+// ============================================================================================================================
+func PutBalance(stub shim.ChaincodeStubInterface, balance Balance) error {
+	balanceAsBytes, _ := json.Marshal(balance)
+	err := stub.PutState(common.CompositeKey(BalancePrefix, balance.CustomerID, balance.MerchantID), balanceAsBytes)
+	if err != nil {
+		return err
+	}
+	return stub.PutState(common.CompositeKey(MerchantBalancePrefix, balance.MerchantID, balance.CustomerID), balanceAsBytes)
+}
+// ============================================================================================================================
+// CustomerBalances - all Balances a Customer holds, one per Merchant
+// ============================================================================================================================
+func CustomerBalances(stub shim.ChaincodeStubInterface, customerId string) ([]Balance, error) {
+	return rangeBalances(stub, BalancePrefix, customerId)
+}
+// ============================================================================================================================
+// MerchantBalances - all Balances held with a Merchant, one per Customer
+// ============================================================================================================================
+func MerchantBalances(stub shim.ChaincodeStubInterface, merchantId string) ([]Balance, error) {
+	return rangeBalances(stub, MerchantBalancePrefix, merchantId)
+}
+// ============================================================================================================================
+// rangeBalances - scan the Balances stored under prefix + id + KeySeparator, in key order
+// ============================================================================================================================
+func rangeBalances(stub shim.ChaincodeStubInterface, prefix string, id string) ([]Balance, error) {
+	var balances []Balance
+	keysIter, err := stub.RangeQueryState(prefix + id + common.KeySeparator, prefix + id + "\x01")
+	if err != nil {
+		return nil, errors.New("Failed to get Balances of " + id)
+	}
+	defer keysIter.Close()
+	for keysIter.HasNext() {
+		_, balanceAsBytes, err := keysIter.Next()
+		if err != nil {
+			return nil, errors.New("Failed to get Balances of " + id)
+		}
+		balance := Balance{}
+		json.Unmarshal(balanceAsBytes, &balance)
+		balances = append(balances, balance)
+	}
+	return balances, nil
+}
+// ============================================================================================================================
+// AdjustPoints - the Customer's Balance with a Merchant after taking points from its oldest lots first and adding the given
+// lots, revalued at the merchant's exchange rate, and the Customer's wallet worth with it. Nothing is stored, the caller puts the
+// Balance and the Customer once all checks passed. Returns the lots taken, and the problem when the balance would go negative
+// ============================================================================================================================
+func AdjustPoints(stub shim.ChaincodeStubInterface, customer *customers.Customer, merchant merchants.Merchant, taken float64, added []PointsLot) (Balance, []PointsLot, string, error) {
+	balance, err := GetBalance(stub, customer.CustomerID, merchant.MerchantID)
+	if err != nil {
+		return balance, nil, "", err
+	}
+	if balance.MerchantID == "" {
+		if taken > 0 {
+			return balance, nil, "Customer holds no points of Merchant " + merchant.MerchantID, nil
+		}
+		balance.CustomerID = customer.CustomerID
+		balance.MerchantID = merchant.MerchantID
+	}
+	if balance.PointsCount - taken < -0.005 {
+		return balance, nil, "Customer doesn't hold enough points of Merchant " + merchant.MerchantID, nil
+	}
+	takenLots := takeLots(&balance, taken)
+	for _, lot := range added {
+		balance.PointsCount = balance.PointsCount + lot.Points
+		balance.Lots = InsertLot(balance.Lots, lot)
+	}
+	balance.MerchantName = merchant.MerchantName
+	balance.MerchantColor = merchant.IndustryColor
+	balance.MerchantCurrency = merchant.MerchantCurrency
+	Revalue(&balance, merchant)
+	customer.WalletWorth, err = WalletWorth(stub, balance)
+	return balance, takenLots, "", err
+}
+// ============================================================================================================================
+// takeLots - take points from a Balance oldest first and return what was taken. Points held from before lots were tracked
+// are the oldest and never expire
+// ============================================================================================================================
+func takeLots(balance *Balance, points float64) []PointsLot {
+	var taken []PointsLot
+	if points <= 0 {
+		return taken
+	}
+	untracked := balance.PointsCount
+	for _, lot := range balance.Lots {
+		untracked = untracked - lot.Points
+	}
+	remaining := points
+	if untracked > 0.005 {
+		part := untracked
+		if part > remaining {
+			part = remaining
+		}
+		taken = append(taken, PointsLot{Points: common.RoundPoints(part)})
+		remaining = remaining - part
+	}
+	for len(balance.Lots) > 0 && remaining > 0.005 {
+		lot := balance.Lots[0]
+		if lot.Points <= remaining + 0.005 {
+			taken = append(taken, lot)
+			remaining = remaining - lot.Points
+			balance.Lots = balance.Lots[1:]
+		} else {
+			balance.Lots[0].Points = common.RoundPoints(lot.Points - remaining)
+			lot.Points = common.RoundPoints(remaining)
+			taken = append(taken, lot)
+			remaining = 0
+		}
+	}
+	balance.PointsCount = balance.PointsCount - points
+	return taken
+}
+// ============================================================================================================================
+// InsertLot - add a lot to lots kept oldest first, lots without an earn date count as the oldest
+// ============================================================================================================================
+func InsertLot(lots []PointsLot, lot PointsLot) []PointsLot {
+	if lot.Points <= 0 {
+		return lots
+	}
+	position := len(lots)
+	for position > 0 && lots[position-1].EarnedAt > lot.EarnedAt {
+		position--
+	}
+	lots = append(lots, PointsLot{})
+	copy(lots[position+1:], lots[position:])
+	lots[position] = lot
+	return lots
+}
+// ============================================================================================================================
+// EarnedLot - a lot of points earned on the date transactionDateTime starts with, expiring as the merchant's policy says
+// ============================================================================================================================
+func EarnedLot(merchant merchants.Merchant, points float64, transactionDateTime string) (PointsLot, string) {
+	if len(transactionDateTime) < len(common.DateLayout) {
+		return PointsLot{}, "transactionDateTime must start with a YYYY-MM-DD date"
+	}
+	earnedAt, err := time.Parse(common.DateLayout, transactionDateTime[:len(common.DateLayout)])
+	if err != nil {
+		return PointsLot{}, "transactionDateTime must start with a YYYY-MM-DD date"
+	}
+	lot := PointsLot{common.RoundPoints(points), earnedAt.Format(common.DateLayout), ""}
+	if merchant.ExpiryPolicy == merchants.ExpiryMonthsPolicy {
+		months, _ := strconv.Atoi(merchant.ExpiryMonths)
+		lot.ExpiresAt = earnedAt.AddDate(0, months, 0).Format(common.DateLayout)
+	} else if merchant.ExpiryPolicy == merchants.ExpiryYearEndPolicy {
+		lot.ExpiresAt = strconv.Itoa(earnedAt.Year()) + "-12-31"
+	}
+	return lot, ""
+}
+// ============================================================================================================================
+// Revalue - round a Balance's points and price them at the merchant's exchange rate
+// ============================================================================================================================
+func Revalue(balance *Balance, merchant merchants.Merchant) {
+	exchangeRate, _ := strconv.ParseFloat(merchant.ExchangeRate, 64)
+	balance.PointsCount = common.RoundPoints(balance.PointsCount)
+	balance.PointsWorth = common.RoundPoints(balance.PointsCount * exchangeRate)
+}
+// ============================================================================================================================
+// WalletWorth - worth of all a Customer's Balances with the given ones in place of the stored ones
+// ============================================================================================================================
+func WalletWorth(stub shim.ChaincodeStubInterface, changed ...Balance) (string, error) {
+	balances, err := CustomerBalances(stub, changed[0].CustomerID)
+	if err != nil {
+		return "", err
+	}
+	worth := float64(0)
+	for _, balance := range changed {
+		worth = worth + balance.PointsWorth
+	}
+	for _, held := range balances {
+		stored := true
+		for _, balance := range changed {
+			if held.MerchantID == balance.MerchantID {
+				stored = false
+			}
+		}
+		if stored {
+			worth = worth + held.PointsWorth
+		}
+	}
+	return strconv.FormatFloat(worth, 'f', 2, 64), nil
+}
+// ============================================================================================================================
+// ExchangeQuote - points of toMerchant given for points of fromMerchant: their value at fromMerchant's exchange rate less
+// the spread, priced at toMerchant's exchange rate. Returns the points, the value given up and the value received
+// ============================================================================================================================
+func ExchangeQuote(fromMerchant merchants.Merchant, toMerchant merchants.Merchant, points float64, spread owners.ExchangeSpread) (float64, float64, float64, string) {
+	fromRate, errFrom := strconv.ParseFloat(fromMerchant.ExchangeRate, 64)
+	toRate, errTo := strconv.ParseFloat(toMerchant.ExchangeRate, 64)
+	spreadRate, _ := strconv.ParseFloat(spread.Spread, 64)
+	if errFrom != nil || fromRate <= 0 {
+		return 0, 0, 0, "Merchant " + fromMerchant.MerchantID + " has no exchange rate"
+	}
+	if errTo != nil || toRate <= 0 {
+		return 0, 0, 0, "Merchant " + toMerchant.MerchantID + " has no exchange rate"
+	}
+	valueOut := common.RoundPoints(points * fromRate)
+	valueIn := common.RoundPoints(valueOut * (1 - spreadRate))
+	return common.RoundPoints(valueIn / toRate), valueOut, valueIn, ""
+}
+// ============================================================================================================================
+// EarnPoints - points earned on the amount spent at the merchant's points per dollar times the Customer's tier multiplier,
+// priced by the active EarnRule that gives the most. Rules are tried in rule id order and the first one wins a tie, so every peer picks the same rule. spent
+// is the part paid in cash, basket the whole purchase. Returns the points and the rule applied, "" for the flat rate
+// ============================================================================================================================
+func EarnPoints(stub shim.ChaincodeStubInterface, merchant merchants.Merchant, customerId string, spent float64, basket float64, transactionDateTime string, category string) (float64, string, error) {
+	balance, err := GetBalance(stub, customerId, merchant.MerchantID)
+	if err != nil {
+		return 0, "", err
+	}
+	pointsPerDollarSpent, _ := strconv.ParseFloat(merchant.PointsPerDollarSpent, 64)
+	base := spent * pointsPerDollarSpent * merchants.TierFor(merchant, balance.Tier).Multiplier
+	if len(transactionDateTime) < len(common.DateLayout) {
+		return base, "", nil
+	}
+	day, err := time.Parse(common.DateLayout, transactionDateTime[:len(common.DateLayout)])
+	if err != nil {
+		return base, "", nil					//earnedLot rejects the date
+	}
+	today := day.Format(common.DateLayout)
+	rules, err := merchants.EarnRules(stub, merchant.MerchantID)
+	if err != nil {
+		return base, "", err
+	}
+	points := base
+	ruleId := ""
+	for _, rule := range rules {
+		if rule.Status != merchants.EarnRuleActive || (rule.ValidFrom != "" && today < rule.ValidFrom) || (rule.ValidTo != "" && today > rule.ValidTo) {
+			continue
+		}
+		applies := false
+		if rule.RuleType == "weekend" {
+			applies = day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+		} else if rule.RuleType == "basket" {
+			applies = basket >= rule.MinimumBasket
+		} else if rule.RuleType == "category" {
+			applies = category != "" && strings.EqualFold(category, rule.Category)
+		} else if rule.RuleType == "firstPurchase" {
+			applies = balance.Purchases == 0 && balance.PointsCount == 0 && len(balance.Lots) == 0
+		}
+		ruled := base * rule.Multiplier + rule.BonusPoints
+		if applies && ruled > points {
+			points = ruled
+			ruleId = rule.RuleID
+		}
+	}
+	return points, ruleId, nil
+}
+// ============================================================================================================================
+// QualifyingPoints - points earned with a Merchant on Accumulation and Purchase transactions in the tier window ending on
+// asOfDate, by Customer. customerId "" counts every Customer
+// ============================================================================================================================
+func QualifyingPoints(stub shim.ChaincodeStubInterface, merchant merchants.Merchant, customerId string, asOfDate string) (map[string]float64, error) {
+	points := map[string]float64{}
+	windowDays, _ := strconv.Atoi(merchant.TierWindowDays)
+	asOf, err := time.Parse(common.DateLayout, asOfDate)
+	if err != nil {
+		return points, errors.New("asOfDate must be a YYYY-MM-DD date")
+	}
+	windowStart := asOf.AddDate(0, 0, 1 - windowDays).Format(common.DateLayout)
+	transactionAsBytes, err := stub.GetState(transactions.TransactionIndexStr)
+	if err != nil {
+		return points, errors.New("Failed to get Transaction index")
+	}
+	var transactionIndex []string
+	json.Unmarshal(transactionAsBytes, &transactionIndex)							//un stringify it aka JSON.parse()
+	for _, val := range transactionIndex {
+		valueAsBytes, err := stub.GetState(val)
+		if err != nil {
+			return points, errors.New("Failed to get state for " + val)
+		}
+		transaction := transactions.Transaction{}
+		json.Unmarshal(valueAsBytes, &transaction)
+		if (customerId != "" && transaction.CustomerID != customerId) || (transaction.TransactionType != "Accumulation" && transaction.TransactionType != "Purchase") {
+			continue
+		}
+		if len(transaction.TransactionDateTime) < len(common.DateLayout) || transactions.MerchantOf(transaction, []merchants.Merchant{merchant}) != merchant.MerchantID {
+			continue
+		}
+		day := transaction.TransactionDateTime[:len(common.DateLayout)]
+		if !common.ValidDay(day) || day < windowStart || day > asOfDate {
+			continue
+		}
+		credit, _ := strconv.ParseFloat(transaction.Credit, 64)
+		points[transaction.CustomerID] = points[transaction.CustomerID] + credit
+	}
+	return points, nil
+}
+// ============================================================================================================================
+// ReviewTier - promote or demote a Balance to the tier its qualifying points reach as of a day, pending being points earned
+// that aren't stored as a Transaction yet. Nothing is stored, true when the tier changed
+// ============================================================================================================================
+func ReviewTier(stub shim.ChaincodeStubInterface, balance *Balance, merchant merchants.Merchant, asOfDate string, pending float64) (bool, error) {
+	if len(merchant.Tiers) == 0 && balance.Tier == "" {
+		return false, nil
+	}
+	points, err := QualifyingPoints(stub, merchant, balance.CustomerID, asOfDate)
+	if err != nil {
+		return false, err
+	}
+	return AssignTier(balance, merchant, common.RoundPoints(points[balance.CustomerID] + pending), asOfDate), nil
+}
+// ============================================================================================================================
+// AssignTier - set a Balance's qualifying points and the tier they reach, true when the tier changed
+// ============================================================================================================================
+func AssignTier(balance *Balance, merchant merchants.Merchant, points float64, asOfDate string) bool {
+	tier := merchants.QualifiedTier(merchant, points)
+	balance.QualifyingPoints = points
+	if tier.TierName == balance.Tier {
+		return false
+	}
+	balance.Tier = tier.TierName
+	balance.TierSince = ""
+	if tier.TierName != "" {
+		balance.TierSince = asOfDate
+	}
+	return true
+}
+// ============================================================================================================================
+// ExpiredLots - points of the lots that expired before asOfDate and the lots kept
+// ============================================================================================================================
+func ExpiredLots(lots []PointsLot, asOfDate string) (float64, []PointsLot) {
+	expired := float64(0)
+	var kept []PointsLot
+	for _, lot := range lots {
+		if lot.ExpiresAt != "" && lot.ExpiresAt < asOfDate {
+			expired = expired + lot.Points
+		} else {
+			kept = append(kept, lot)
+		}
+	}
+	return expired, kept
+}
+// ============================================================================================================================
+// SplitBalances - the Balances held in a Customer's legacy comma separated merchant lists, aligned by position. A Merchant
+// listed twice gets one Balance with the points of both positions
+// ============================================================================================================================
+func SplitBalances(customer customers.Customer) []Balance {
+	var balances []Balance
+	merchantNames := strings.Split(customer.MerchantNames, ",")
+	merchantColors := strings.Split(customer.MerchantColors, ",")
+	merchantCurrencies := strings.Split(customer.MerchantCurrencies, ",")
+	pointsCount := strings.Split(customer.MerchantsPointsCount, ",")
+	pointsWorth := strings.Split(customer.MerchantsPointsWorth, ",")
+	listItem := func(list []string, i int) string {
+		if i < len(list) {
+			return strings.TrimSpace(list[i])
+		}
+		return ""
+	}
+	for i, merchantId := range strings.Split(customer.MerchantIDs, ",") {
+		merchantId = strings.TrimSpace(merchantId)
+		if merchantId == "" {
+			continue
+		}
+		count, _ := strconv.ParseFloat(listItem(pointsCount, i), 64)
+		worth, _ := strconv.ParseFloat(listItem(pointsWorth, i), 64)
+		merged := false
+		for j := range balances {
+			if balances[j].MerchantID == merchantId {
+				balances[j].PointsCount = balances[j].PointsCount + count
+				balances[j].PointsWorth = balances[j].PointsWorth + worth
+				merged = true
+			}
+		}
+		if !merged {
+			balances = append(balances, Balance{CustomerID: customer.CustomerID, MerchantID: merchantId, MerchantName: listItem(merchantNames, i), MerchantColor: listItem(merchantColors, i), MerchantCurrency: listItem(merchantCurrencies, i), PointsCount: count, PointsWorth: worth})
+		}
+	}
+	return balances
+}
+// ============================================================================================================================
+// MigrateCustomer - move a Customer's legacy comma separated merchant lists into Balance records and store the Customer
+// without them, false when there was nothing to migrate
+// ============================================================================================================================
+func MigrateCustomer(stub shim.ChaincodeStubInterface, customer *customers.Customer) (bool, error) {
+	if customer.MerchantIDs == "" && customer.MerchantsPointsCount == "" && customer.MerchantsPointsWorth == "" {
+		return false, nil
+	}
+	walletWorth := float64(0)
+	for _, balance := range SplitBalances(*customer) {
+		err := PutBalance(stub, balance)
+		if err != nil {
+			return false, err
+		}
+		walletWorth = walletWorth + balance.PointsWorth
+	}
+	customer.WalletWorth = strconv.FormatFloat(walletWorth, 'f', 2, 64)
+	customer.MerchantIDs = ""
+	customer.MerchantNames = ""
+	customer.MerchantColors = ""
+	customer.MerchantCurrencies = ""
+	customer.MerchantsPointsCount = ""
+	customer.MerchantsPointsWorth = ""
+	customerAsBytes, _ := json.Marshal(customer)
+	return true, stub.PutState(customer.CustomerID, customerAsBytes)
+}
+// ============================================================================================================================
+// CustomerDetails - a Customer as JSON together with the Customer's Balances
+// ============================================================================================================================
+func CustomerDetails(stub shim.ChaincodeStubInterface, customer customers.Customer) ([]byte, error) {
+	balances, err := CustomerBalances(stub, customer.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	if balances == nil {
+		balances = []Balance{}
+	}
+	details := struct {
+		customers.Customer
+		Balances []Balance `json:"balances"`
+	}{customer, balances}
+	return json.Marshal(details)
+}
@@ -0,0 +1,305 @@
+/*/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Package transactions holds the Transaction record and the settlement Statements built from them
+package transactions
+
+import (
+"bytes"
+"encoding/csv"
+"errors"
+"strconv"
+"encoding/json"
+"sort"
+"strings"
+"time"
+
+"github.com/hyperledger/fabric/core/chaincode/shim"	
+
+"Blockchain/LPM/common"
+"Blockchain/LPM/merchants"
+)
+
+var TransactionIndexStr = "_Transactionindex"		// name for the key/value that will store a list of all known Transaction
+var StatementIndexStr = "_Statementindex"		//name for the key/value that will store a list of all known settlement Statement
+var StatementOpen = "Open"						//status of a settlement Statement until the Owner marks it paid
+var StatementPaid = "Paid"
+var DateTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04", common.DateLayout}	//what a transactionDateTime can look like, UTC without a zone
+var ActivityPageSize = 50						//lines on a page of an activity statement when the query doesn't ask for a size
+var DateTimeProblem = "transactionDateTime must be a YYYY-MM-DD date, optionally followed by a time"
+
+type Transaction struct{							// Attributes of a Transaction 
+	TransactionID string `json:"transactionId"`					
+	TransactionDateTime string `json:"transactionDateTime"`
+	TransactionType string `json:"transactionType"`				// Values are Purchase, Transfer, Accumulation (Add Points), CustomerOnBoarding, Expiry, Exchange
+	TransactionFrom string `json:"transactionFrom"`
+	TransactionTo string `json:"transactionTo"`
+	Credit string `json:"credit"`
+	Debit string `json:"debit"`
+	CustomerID string `json:"customerId"`
+	MerchantID string `json:"merchantId,omitempty"`				// the Merchant whose points moved, settlement statements are built from it
+	AppliedRuleID string `json:"appliedRuleId,omitempty"`			// the EarnRule that priced the points earned, none for the flat rate
+	LinkedTransactionID string `json:"linkedTransactionId,omitempty"`	// the other leg of an Exchange
+	TransactionTime int64 `json:"transactionTime,omitempty"`			// TransactionDateTime parsed, Unix seconds
+}
+
+type ActivityLine struct{						// A Transaction on an activity statement with the points balance after it
+	Transaction
+	Balance float64 `json:"balance"`
+}
+
+type ActivityStatement struct{					// A Customer's or Merchant's Transactions over a date range, the balances are the points held before and after it
+	CustomerID string `json:"customerId,omitempty"`
+	MerchantID string `json:"merchantId,omitempty"`
+	FromDate string `json:"fromDate,omitempty"`				// both included, open ended when empty
+	ToDate string `json:"toDate,omitempty"`
+	TransactionType string `json:"transactionType,omitempty"`	// only lists Transactions of this type, the balances count every type
+	OpeningBalance float64 `json:"openingBalance"`
+	ClosingBalance float64 `json:"closingBalance"`
+	TotalCredit float64 `json:"totalCredit"`
+	TotalDebit float64 `json:"totalDebit"`
+	Page int `json:"page"`
+	PageSize int `json:"pageSize"`
+	TotalLines int `json:"totalLines"`
+	Lines []ActivityLine `json:"lines"`
+}
+
+type Statement struct{							// Settlement of one Merchant's points liability over a period, stored for the Owner
+	StatementID string `json:"statementId"`
+	MerchantID string `json:"merchantId"`
+	PeriodFrom string `json:"periodFrom"`					// YYYY-MM-DD, both days included
+	PeriodTo string `json:"periodTo"`
+	PointsIssued float64 `json:"pointsIssued"`
+	PointsRedeemed float64 `json:"pointsRedeemed"`
+	PointsTransferredIn float64 `json:"pointsTransferredIn"`		// exchanged in from other Merchants
+	PointsTransferredOut float64 `json:"pointsTransferredOut"`	// exchanged out to other Merchants
+	PointsExpired float64 `json:"pointsExpired"`
+	NetPoints float64 `json:"netPoints"`
+	ExchangeRate string `json:"exchangeRate"`
+	NetLiability float64 `json:"netLiability"`				// NetPoints at ExchangeRate
+	Status string `json:"status"`
+	CreatedBy string `json:"createdBy"`
+	CreatedAt string `json:"createdAt"`
+	PaidAt string `json:"paidAt,omitempty"`
+	PaymentReference string `json:"paymentReference,omitempty"`
+}
+
+// ============================================================================================================================
+// IdProblem - why Transactions can't be stored under the given ids: one is missing, given twice or already the
+// key of a Transaction or any other record. "" when they're all free, a retried invoke is rejected this way
+// ============================================================================================================================
+func IdProblem(stub shim.ChaincodeStubInterface, ids ...string) (string, error) {
+	for i, id := range ids {
+		if id == "" {
+			return "Expecting a transactionId", nil
+		}
+		for _, other := range ids[:i] {
+			if other == id {
+				return "Every transaction needs its own transactionId", nil
+			}
+		}
+		valueAsBytes, err := stub.GetState(id)
+		if err != nil {
+			return "", errors.New("Failed to get state for " + id)
+		}
+		if len(valueAsBytes) > 0 {
+			return "Transaction id " + id + " is already used", nil
+		}
+	}
+	return "", nil
+}
+// ============================================================================================================================
+// MerchantOf - ID of the Merchant whose points a Transaction moved, transactions from before merchantId was kept
+// are matched on the merchant's name
+// ============================================================================================================================
+func MerchantOf(transaction Transaction, known []merchants.Merchant) string {
+	if transaction.MerchantID != "" {
+		return transaction.MerchantID
+	}
+	for _, merchant := range known {
+		if merchant.MerchantName != "" && (transaction.TransactionFrom == merchant.MerchantName || transaction.TransactionTo == merchant.MerchantName) {
+			return merchant.MerchantID
+		}
+	}
+	return ""
+}
+// ============================================================================================================================
+// Settle - add the points a Transaction moved to its Merchant's Statement. Transfers between Customers leave the
+// Merchant's liability as it is
+// ============================================================================================================================
+func Settle(statement *Statement, transaction Transaction) {
+	credit, _ := strconv.ParseFloat(transaction.Credit, 64)
+	debit, _ := strconv.ParseFloat(transaction.Debit, 64)
+	switch transaction.TransactionType {
+	case "Transfer":
+	case "Exchange":
+		statement.PointsTransferredIn = common.RoundPoints(statement.PointsTransferredIn + credit)
+		statement.PointsTransferredOut = common.RoundPoints(statement.PointsTransferredOut + debit)
+	case "Expiry":
+		statement.PointsExpired = common.RoundPoints(statement.PointsExpired + debit)
+	default:
+		statement.PointsIssued = common.RoundPoints(statement.PointsIssued + credit)
+		statement.PointsRedeemed = common.RoundPoints(statement.PointsRedeemed + debit)
+	}
+}
+// ============================================================================================================================
+// Stamp - Unix time of a transactionDateTime, with DateTimeProblem when it isn't one
+// ============================================================================================================================
+func Stamp(dateTime string) (int64, string) {
+	parsed, err := ParseDateTime(dateTime)
+	if err != nil {
+		return 0, DateTimeProblem
+	}
+	return parsed.Unix(), ""
+}
+// ============================================================================================================================
+// ParseDateTime - the time a transactionDateTime stands for, in the first of the DateTimeLayouts it matches
+// ============================================================================================================================
+func ParseDateTime(dateTime string) (time.Time, error) {
+	for _, layout := range DateTimeLayouts {
+		parsed, err := time.Parse(layout, dateTime)
+		if err == nil {
+			return parsed.UTC(), nil
+		}
+	}
+	return time.Time{}, errors.New(DateTimeProblem)
+}
+// ============================================================================================================================
+// TimeOf - the time of a Transaction, Transactions stored before TransactionTime was kept are parsed again and the
+// ones that can't be are taken as older than any other
+// ============================================================================================================================
+func TimeOf(transaction Transaction) time.Time {
+	if transaction.TransactionTime != 0 {
+		return time.Unix(transaction.TransactionTime, 0).UTC()
+	}
+	parsed, err := ParseDateTime(transaction.TransactionDateTime)
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed
+}
+// ============================================================================================================================
+// Amounts - points a Transaction credited and debited with a Merchant, all of them when merchantId is "". An on boarding
+// Transaction of a Customer with several Merchants lists their ids and points comma separated
+// ============================================================================================================================
+func Amounts(transaction Transaction, merchantId string) (float64, float64) {
+	merchantIds := strings.Split(transaction.MerchantID, ",")
+	credits := strings.Split(transaction.Credit, ",")
+	debits := strings.Split(transaction.Debit, ",")
+	var credit, debit float64
+	for i := range merchantIds {
+		if merchantId != "" && len(merchantIds) > 1 && merchantIds[i] != merchantId {
+			continue
+		}
+		if i < len(credits) {
+			value, _ := strconv.ParseFloat(strings.TrimSpace(credits[i]), 64)
+			credit += value
+		}
+		if i < len(debits) {
+			value, _ := strconv.ParseFloat(strings.TrimSpace(debits[i]), 64)
+			debit += value
+		}
+	}
+	return common.RoundPoints(credit), common.RoundPoints(debit)
+}
+
+// ============================================================================================================================
+// movedPointsOf - whether a Transaction moved points of the Merchant, one on boarding a Customer with several Merchants
+// lists their ids comma separated
+// ============================================================================================================================
+func movedPointsOf(transaction Transaction, merchant merchants.Merchant) bool {
+	if strings.Contains(transaction.MerchantID, ",") {
+		return strings.Contains(","+transaction.MerchantID+",", ","+merchant.MerchantID+",")
+	}
+	return MerchantOf(transaction, []merchants.Merchant{merchant}) == merchant.MerchantID
+}
+
+type byTime []ActivityLine
+
+func (lines byTime) Len() int { return len(lines) }
+func (lines byTime) Swap(i, j int) { lines[i], lines[j] = lines[j], lines[i] }
+func (lines byTime) Less(i, j int) bool { return TimeOf(lines[i].Transaction).Before(TimeOf(lines[j].Transaction)) }
+
+// ============================================================================================================================
+// Activity - the statement of a Customer's points, with one Merchant when merchant has an ID, or of every Customer of a
+// Merchant when customerId is "". from and to are the first and last instant included, zero when open ended. Lines are
+// in time order, stored order for the same time, and every page of a statement carries the same balances
+// ============================================================================================================================
+func Activity(stub shim.ChaincodeStubInterface, customerId string, merchant merchants.Merchant, from time.Time, to time.Time, transactionType string) (ActivityStatement, error) {
+	statement := ActivityStatement{CustomerID: customerId, MerchantID: merchant.MerchantID, TransactionType: transactionType, Lines: []ActivityLine{}}
+	var transactionIndex []string
+	transactionAsBytes, err := stub.GetState(TransactionIndexStr)
+	if err != nil {
+		return statement, errors.New("Failed to get Transaction index string")
+	}
+	json.Unmarshal(transactionAsBytes, &transactionIndex)
+	var lines []ActivityLine
+	for _, val := range transactionIndex {
+		valueAsBytes, err := stub.GetState(val)
+		if err != nil {
+			return statement, errors.New("Failed to get state for " + val)
+		}
+		transaction := Transaction{}
+		json.Unmarshal(valueAsBytes, &transaction)
+		if customerId != "" && transaction.CustomerID != customerId {
+			continue
+		}
+		if merchant.MerchantID != "" && !movedPointsOf(transaction, merchant) {
+			continue
+		}
+		lines = append(lines, ActivityLine{Transaction: transaction})
+	}
+	sort.Stable(byTime(lines))
+	for _, line := range lines {
+		credit, debit := Amounts(line.Transaction, merchant.MerchantID)
+		when := TimeOf(line.Transaction)
+		if !from.IsZero() && when.Before(from) {
+			statement.OpeningBalance = common.RoundPoints(statement.OpeningBalance + credit - debit)
+			continue
+		}
+		if !to.IsZero() && when.After(to) {
+			break
+		}
+		statement.TotalCredit = common.RoundPoints(statement.TotalCredit + credit)
+		statement.TotalDebit = common.RoundPoints(statement.TotalDebit + debit)
+		line.Balance = common.RoundPoints(statement.OpeningBalance + statement.TotalCredit - statement.TotalDebit)
+		if transactionType == "" || line.TransactionType == transactionType {
+			statement.Lines = append(statement.Lines, line)
+		}
+	}
+	statement.ClosingBalance = common.RoundPoints(statement.OpeningBalance + statement.TotalCredit - statement.TotalDebit)
+	statement.TotalLines = len(statement.Lines)
+	return statement, nil
+}
+// ============================================================================================================================
+// ActivityCSV - an activity statement as CSV, opening and closing balance rows around its lines
+// ============================================================================================================================
+func ActivityCSV(statement ActivityStatement) []byte {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	writer.Write([]string{"transactionId", "transactionDateTime", "transactionType", "transactionFrom", "transactionTo", "customerId", "merchantId", "credit", "debit", "balance"})
+	writer.Write([]string{"", statement.FromDate, "Opening balance", "", "", statement.CustomerID, statement.MerchantID, "", "", strconv.FormatFloat(statement.OpeningBalance, 'f', 2, 64)})
+	for _, line := range statement.Lines {
+		credit, debit := Amounts(line.Transaction, statement.MerchantID)
+		writer.Write([]string{line.TransactionID, line.TransactionDateTime, line.TransactionType, line.TransactionFrom, line.TransactionTo, line.CustomerID, line.MerchantID,
+			strconv.FormatFloat(credit, 'f', 2, 64), strconv.FormatFloat(debit, 'f', 2, 64), strconv.FormatFloat(line.Balance, 'f', 2, 64)})
+	}
+	writer.Write([]string{"", statement.ToDate, "Closing balance", "", "", statement.CustomerID, statement.MerchantID,
+		strconv.FormatFloat(statement.TotalCredit, 'f', 2, 64), strconv.FormatFloat(statement.TotalDebit, 'f', 2, 64), strconv.FormatFloat(statement.ClosingBalance, 'f', 2, 64)})
+	writer.Flush()
+	return buffer.Bytes()
+}
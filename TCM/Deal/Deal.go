@@ -32,6 +32,10 @@ var DealIndexStr = "_Dealindex" //name for the key/value that will store a list
 
 var transactionIndexStr = "_transactionIndex" //name for the key/value that will store a list of all known transactionIds
 
+var IncludeArchived = "include_archived" //optional list query argument that also returns archived Deals and Transactions
+
+var OpenAllocationStatuses = map[string]bool{"Ready for Allocation": true, "Pending due to insufficient collateral": true} //a Deal with Transactions in these statuses can't be archived
+
 type Transactions struct {
     TransactionId string `json:"transactionId"`
     TransactionDate string `json:"transactionDate"`
@@ -45,6 +49,7 @@ type Transactions struct {
     AllocationStatus string `json:"allocationStatus"`
     TransactionStatus string `json:"transactionStatus"`
     ComplianceStatus string `json:"complianceStatus"`
+    Archive *Archive `json:"archive,omitempty"`
 }
 
 type Deals struct { // Attributes of a Deal
@@ -57,6 +62,13 @@ type Deals struct { // Attributes of a Deal
     IssueDate string `json:"issueDate"`
     LastSuccessfulAllocationDate string `json:"lastSuccessfulAllocationDate"`
     Transactions string `json:"transactions"`
+    Archive *Archive `json:"archive,omitempty"`
+}
+
+type Archive struct { // Reason, caller and date a Deal or Transaction was archived with instead of being deleted
+    Reason string `json:"reason"`
+    ArchivedBy string `json:"archivedBy"`
+    ArchivedAt string `json:"archivedAt"`
 }
 
 /*type Pledger struct{
@@ -252,8 +264,8 @@ func(t * ManageDeals) getDeal_byPledger(stub shim.ChaincodeStubInterface, args[]
     var valIndex Deals
     fmt.Println("start getDeal_byPledger")
     var err error
-    if len(args) != 1 {
-        errMsg:= "{ \"message\" : \"Incorrect number of arguments. Expecting 'pledgerName' as an argument\", \"code\" : \"503\"}"
+    if len(args) < 1 || len(args) > 2 {
+        errMsg:= "{ \"message\" : \"Incorrect number of arguments. Expecting 'pledgerName' and optionally 'include_archived' as arguments\", \"code\" : \"503\"}"
         err = stub.SetEvent("errEvent", [] byte(errMsg))
         if err != nil {
             return nil, err
@@ -284,7 +296,11 @@ func(t * ManageDeals) getDeal_byPledger(stub shim.ChaincodeStubInterface, args[]
         }
         //fmt.Print("valueAsBytes : ")
         //fmt.Println(valueAsBytes)
+        valIndex = Deals{}
         json.Unmarshal(valueAsBytes, &valIndex)
+        if valIndex.Archive != nil && !includeArchived(args, 1) {
+            continue
+        }
         fmt.Print("valIndex: ")
         fmt.Print(valIndex)
         if valIndex.Pledger == pledgerName {
@@ -298,6 +314,7 @@ func(t * ManageDeals) getDeal_byPledger(stub shim.ChaincodeStubInterface, args[]
         } 
     }
     jsonResp = jsonResp + "}"
+    jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
     fmt.Println("jsonResp : " + jsonResp)
     if jsonResp == "{}" {
         fmt.Println("Pledger not found.")
@@ -325,8 +342,8 @@ func(t * ManageDeals) getDeal_byPledgee(stub shim.ChaincodeStubInterface, args[]
     var valIndex Deals
     fmt.Println("start getDeal_byPledgee")
     var err error
-    if len(args) != 1 {
-        errMsg:= "{ \"message\" : \"Incorrect number of arguments. Expecting 'pledgeeName' as an argument\", \"code\" : \"503\"}"
+    if len(args) < 1 || len(args) > 2 {
+        errMsg:= "{ \"message\" : \"Incorrect number of arguments. Expecting 'pledgeeName' and optionally 'include_archived' as arguments\", \"code\" : \"503\"}"
         err = stub.SetEvent("errEvent", [] byte(errMsg))
         if err != nil {
             return nil, err
@@ -357,7 +374,11 @@ func(t * ManageDeals) getDeal_byPledgee(stub shim.ChaincodeStubInterface, args[]
         }
         //fmt.Print("valueAsBytes : ")
         //fmt.Println(valueAsBytes)
+        valIndex = Deals{}
         json.Unmarshal(valueAsBytes, &valIndex)
+        if valIndex.Archive != nil && !includeArchived(args, 1) {
+            continue
+        }
         fmt.Print("valIndex: ")
         fmt.Print(valIndex)
         if valIndex.Pledgee == pledgeeName {
@@ -371,6 +392,7 @@ func(t * ManageDeals) getDeal_byPledgee(stub shim.ChaincodeStubInterface, args[]
         }
     }
     jsonResp = jsonResp + "}"
+    jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
     fmt.Println("jsonResp : " + jsonResp)
     if jsonResp == "{}" {
         fmt.Println("Pledgee not found.")
@@ -395,10 +417,11 @@ func(t * ManageDeals) getDeal_byPledgee(stub shim.ChaincodeStubInterface, args[]
 func(t * ManageDeals) get_AllDeal(stub shim.ChaincodeStubInterface, args[] string)([] byte, error) {
     var jsonResp, errResp string
     var dealIndex[] string
+    var valIndex Deals
     fmt.Println("start get_AllDeal")
     var err error
     if len(args) != 1 {
-        errMsg:= "{ \"message\" : \"Incorrect number of arguments. Expecting \" \" as an argument\", \"code\" : \"503\"}"
+        errMsg:= "{ \"message\" : \"Incorrect number of arguments. Expecting \" \" or 'include_archived' as an argument\", \"code\" : \"503\"}"
         err = stub.SetEvent("errEvent", [] byte(errMsg))
         if err != nil {
             return nil, err
@@ -424,6 +447,11 @@ func(t * ManageDeals) get_AllDeal(stub shim.ChaincodeStubInterface, args[] strin
         }
         //fmt.Print("valueAsBytes : ")
         //fmt.Println(valueAsBytes)
+        valIndex = Deals{}
+        json.Unmarshal(valueAsBytes, &valIndex)
+        if valIndex.Archive != nil && !includeArchived(args, 0) {
+            continue
+        }
         jsonResp = jsonResp + "\"" + val + "\":" + string(valueAsBytes[: ])
         if i < len(dealIndex) - 1 {
             jsonResp = jsonResp + ","
@@ -432,6 +460,7 @@ func(t * ManageDeals) get_AllDeal(stub shim.ChaincodeStubInterface, args[] strin
     //fmt.Println("len(dealIndex) : ")
     //fmt.Println(len(dealIndex))
     jsonResp = jsonResp + "}"
+    jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
     //fmt.Println("jsonResp : " + jsonResp)
     //fmt.Print("jsonResp in bytes : ")
     //fmt.Println([]byte(jsonResp))
@@ -445,10 +474,11 @@ func(t * ManageDeals) get_AllDeal(stub shim.ChaincodeStubInterface, args[] strin
 func(t * ManageDeals) get_AllTransactions(stub shim.ChaincodeStubInterface, args[] string)([] byte, error) {
     var jsonResp, errResp string
     var transactionIndex[] string
+    var valIndex Transactions
     fmt.Println("start get_AllTransactions")
     var err error
     if len(args) != 1 {
-        errMsg:= "{ \"message\" : \"Incorrect number of arguments. Expecting \" \" as an argument\", \"code\" : \"503\"}"
+        errMsg:= "{ \"message\" : \"Incorrect number of arguments. Expecting \" \" or 'include_archived' as an argument\", \"code\" : \"503\"}"
         err = stub.SetEvent("errEvent", [] byte(errMsg))
         if err != nil {
             return nil, err
@@ -474,6 +504,11 @@ func(t * ManageDeals) get_AllTransactions(stub shim.ChaincodeStubInterface, args
         }
         //fmt.Print("valueAsBytes : ")
         //fmt.Println(valueAsBytes)
+        valIndex = Transactions{}
+        json.Unmarshal(valueAsBytes, &valIndex)
+        if valIndex.Archive != nil && !includeArchived(args, 0) {
+            continue
+        }
         jsonResp = jsonResp + "\"" + val + "\":" + string(valueAsBytes[: ])
         if i < len(transactionIndex) - 1 {
             jsonResp = jsonResp + ","
@@ -482,6 +517,7 @@ func(t * ManageDeals) get_AllTransactions(stub shim.ChaincodeStubInterface, args
     //fmt.Println("len(transactionIndex) : ")
     //fmt.Println(len(transactionIndex))
     jsonResp = jsonResp + "}"
+    jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
     //fmt.Println("jsonResp : " + jsonResp)
     //fmt.Print("jsonResp in bytes : ")
     //fmt.Println([]byte(jsonResp))
@@ -517,6 +553,14 @@ func(t * ManageDeals) update_deal(stub shim.ChaincodeStubInterface, args[] strin
     res:= Deals {}
     json.Unmarshal(dealAsBytes, &res)
     fmt.Println(res);
+    if res.DealID == dealId && res.Archive != nil {
+        errMsg:= "{ \"dealId\" : \"" + dealId + "\", \"message\" : \"Archived Deals can't be updated\", \"code\" : \"503\"}"
+        err = stub.SetEvent("errEvent", [] byte(errMsg))
+        if err != nil {
+            return nil, err
+        }
+        return nil,nil
+    }
     if res.DealID == dealId {
         fmt.Println("Deal found with dealId : " + dealId)
         //build the Deal json string manually
@@ -641,8 +685,8 @@ func(t * ManageDeals) getTransactions_byDealID(stub shim.ChaincodeStubInterface,
     var err error
     var _tempJson Transactions
     fmt.Println("start getTransactions_byDealID")
-    if len(args) != 1 {
-        errMsg:= "{ \"message\" : \"Incorrect number of arguments. Expecting 'dealId' as an argument\", \"code\" : \"503\"}"
+    if len(args) < 1 || len(args) > 2 {
+        errMsg:= "{ \"message\" : \"Incorrect number of arguments. Expecting 'dealId' and optionally 'include_archived' as arguments\", \"code\" : \"503\"}"
         err = stub.SetEvent("errEvent", [] byte(errMsg))
         if err != nil {
             return nil, err
@@ -677,7 +721,11 @@ func(t * ManageDeals) getTransactions_byDealID(stub shim.ChaincodeStubInterface,
             errResp := "{\"Error\":\"Failed to get state for " + _transactionSplit[i] + "\"}"
             return nil, errors.New(errResp)
         }
+        _tempJson = Transactions{}
         json.Unmarshal(valueAsBytes, &_tempJson)
+        if _tempJson.Archive != nil && !includeArchived(args, 1) {
+            continue
+        }
         fmt.Print("valueAsBytes : ")
         fmt.Println(valueAsBytes)
         jsonResp = jsonResp + string(valueAsBytes[: ])
@@ -686,6 +734,7 @@ func(t * ManageDeals) getTransactions_byDealID(stub shim.ChaincodeStubInterface,
         }
     }
     jsonResp = jsonResp + "]"
+    jsonResp = strings.Replace(jsonResp, ",]", "]", -1)
     if jsonResp == "[]" {
         fmt.Println("Transactions not found.")
         jsonResp =  "{ \"message\" : \" No transactions found.\", \"code\" : \"503\"}"
@@ -710,8 +759,8 @@ func(t * ManageDeals) getTransactions_byUser(stub shim.ChaincodeStubInterface, a
     var _tempJson Transactions
     fmt.Println("start getTransactions_byUser")
     var err error
-    if len(args) != 2 {
-        errMsg:= "{ \"message\" : \"Incorrect number of arguments. Expecting 'user' and 'role' as an argument\", \"code\" : \"503\"}"
+    if len(args) < 2 || len(args) > 3 {
+        errMsg:= "{ \"message\" : \"Incorrect number of arguments. Expecting 'user', 'role' and optionally 'include_archived' as arguments\", \"code\" : \"503\"}"
         err = stub.SetEvent("errEvent", [] byte(errMsg))
         if err != nil {
             return nil, err
@@ -740,7 +789,11 @@ func(t * ManageDeals) getTransactions_byUser(stub shim.ChaincodeStubInterface, a
         }
         //fmt.Print("dealAsBytes : ")
         //fmt.Println(dealAsBytes)
+        valIndex = Deals{}
         json.Unmarshal(dealAsBytes, &valIndex)
+        if valIndex.Archive != nil && !includeArchived(args, 2) {
+            continue
+        }
         fmt.Print("valIndex: ")
         fmt.Print(valIndex)
         if valIndex.Transactions == "" || valIndex.Transactions == " "{
@@ -760,7 +813,11 @@ func(t * ManageDeals) getTransactions_byUser(stub shim.ChaincodeStubInterface, a
                     errResp := "{\"Error\":\"Failed to get state for " + _transactionSplit[i] + "\"}"
                     return nil, errors.New(errResp)
                 }
+                _tempJson = Transactions{}
                 json.Unmarshal(valueAsBytes, &_tempJson)
+                if _tempJson.Archive != nil && !includeArchived(args, 2) {
+                    continue
+                }
                 fmt.Print("_tempJson : ")
                 fmt.Println(_tempJson)
                 if _role == "Pledger" {
@@ -788,6 +845,7 @@ func(t * ManageDeals) getTransactions_byUser(stub shim.ChaincodeStubInterface, a
         }
     }
     jsonResp = jsonResp + "]"
+    jsonResp = strings.Replace(jsonResp, ",]", "]", -1)
     fmt.Println("jsonResp : " + jsonResp)
     if jsonResp == "[]" {
         fmt.Println("User not found.")
@@ -848,6 +906,14 @@ func(t * ManageDeals) addTransaction_inDeal(stub shim.ChaincodeStubInterface, ar
     //_tempJson := Transactions{}
     json.Unmarshal(dealAsBytes, &res)
     fmt.Println(res);
+    if res.DealID == dealId && res.Archive != nil {
+        errMsg:= "{ \"dealId\" : \"" + dealId + "\",\"message\" : \"Transactions can't be added to an archived Deal\", \"code\" : \"503\"}"
+        err = stub.SetEvent("errEvent", [] byte(errMsg))
+        if err != nil {
+            return nil, err
+        }
+        return nil,nil
+    }
     if res.DealID == dealId {
         fmt.Println("Deal found with dealId : " + dealId)
         _transactionSplit:= strings.Split(res.Transactions, ",")
@@ -906,136 +972,139 @@ func(t * ManageDeals) addTransaction_inDeal(stub shim.ChaincodeStubInterface, ar
     return nil, nil
 }
 // ============================================================================================================================
-// Delete - remove deal from chain
+// Delete - archive a Deal and its Transactions with a reason once none of its Transactions is waiting for allocation
 // ============================================================================================================================
-func (t *ManageDeals) deleteDeal(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	if len(args) != 1 {
-		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'dealId' as an argument\", \"code\" : \"503\"}"
-		err := stub.SetEvent("errEvent", []byte(errMsg))
-		if err != nil {
-			return nil, err
-		} 
-		return nil, nil
-	}
-	// set dealId
-	dealId := args[0]
-	err := stub.DelState(dealId)						//remove the Deal from chaincode
-	if err != nil {
-		errMsg := "{ \"message\" : \"Failed to delete state\", \"code\" : \"503\"}"
-		err = stub.SetEvent("errEvent", []byte(errMsg))
-		if err != nil {
-			return nil, err
-		} 
-		return nil, nil
-	}
-
-	//get the Deal index
-	dealAsBytes, err := stub.GetState(DealIndexStr)
-	if err != nil {
-		errMsg := "{ \"message\" : \"Failed to get Deal index\", \"code\" : \"503\"}"
-		err = stub.SetEvent("errEvent", []byte(errMsg))
-		if err != nil {
-			return nil, err
-		} 
-		return nil, nil
-	}
-	res := Deals{}
-	json.Unmarshal(dealAsBytes, &res)								//un stringify it aka JSON.parse()
-	_TransactionsSplit := strings.Split(res.Transactions, ",")
-	fmt.Print("_TransactionsSplit: " )
-	fmt.Println(_TransactionsSplit)
-	for i:=0;i<len(_TransactionsSplit);{
-		fmt.Println("_TransactionsSplit[i]: " + _TransactionsSplit[i])
-		err := stub.DelState(_TransactionsSplit[i])													//remove the key from chaincode state
-		if err != nil {
-			errMsg := "{ \"transactions\" : \"" + _TransactionsSplit[i] + "\", \"message\" : \"Failed to delete state\", \"code\" : \"503\"}"
-			err = stub.SetEvent("errEvent", []byte(errMsg))
-			if err != nil {
-				return nil, err
-			} 
-			return nil, nil
-		}
-		_TransactionsSplit = append(_TransactionsSplit[:i], _TransactionsSplit[i+1:]...)			//remove it
-		fmt.Println(_TransactionsSplit)
-		for x:= range _TransactionsSplit{											//debug prints...
-			fmt.Println(string(x) + " - " + _TransactionsSplit[x])
-		}
-	}
-
-	tosend := "{ \"dealID\" : \""+dealId+"\", \"message\" : \"Deal and its Transactions deleted succcessfully\", \"code\" : \"200\"}"
-	err = stub.SetEvent("evtsender", []byte(tosend))
-	if err != nil {
-		return nil, err
-	} 
-
-	fmt.Println("Deal and its Transactions deleted succcessfully")
-	return nil, nil
+func(t * ManageDeals) deleteDeal(stub shim.ChaincodeStubInterface, args[] string)([] byte, error) {
+    // deleteDeal("dealId", "reason", "archivedAt")
+    if len(args) != 3 {
+        errMsg:= "{ \"message\" : \"Incorrect number of arguments. Expecting 'dealId', 'reason' and 'archivedAt' as arguments\", \"code\" : \"503\"}"
+        err:= stub.SetEvent("errEvent", [] byte(errMsg))
+        if err != nil {
+            return nil, err
+        }
+        return nil, nil
+    }
+    return archiveDeal(stub, args[0], args[1], args[2], true)
 }
 // ============================================================================================================================
-// Delete - remove transactions from chain
+// Delete - archive the Transactions of a Deal with a reason once none of them is waiting for allocation, the Deal stays
 // ============================================================================================================================
-func (t *ManageDeals) deleteTransactions(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	if len(args) != 1 {
-		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'dealId' as an argument\", \"code\" : \"503\"}"
-		err := stub.SetEvent("errEvent", []byte(errMsg))
-		if err != nil {
-			return nil, err
-		} 
-		return nil, nil
-	}
-	// set dealId
-	dealId := args[0]
-	err := stub.DelState(dealId)						//remove the Deal from chaincode
-	if err != nil {
-		errMsg := "{ \"message\" : \"Failed to delete state\", \"code\" : \"503\"}"
-		err = stub.SetEvent("errEvent", []byte(errMsg))
-		if err != nil {
-			return nil, err
-		} 
-		return nil, nil
-	}
-
-	//get the Deal index
-	dealAsBytes, err := stub.GetState(DealIndexStr)
-	if err != nil {
-		errMsg := "{ \"message\" : \"Failed to get Deal index\", \"code\" : \"503\"}"
-		err = stub.SetEvent("errEvent", []byte(errMsg))
-		if err != nil {
-			return nil, err
-		} 
-		return nil, nil
-	}
-	res := Deals{}
-	json.Unmarshal(dealAsBytes, &res)								//un stringify it aka JSON.parse()
-	_TransactionsSplit := strings.Split(res.Transactions, ",")
-	fmt.Print("_TransactionsSplit: " )
-	fmt.Println(_TransactionsSplit)
-	for i:=0;i<len(_TransactionsSplit);{
-		fmt.Println("_TransactionsSplit[i]: " + _TransactionsSplit[i])
-		err := stub.DelState(_TransactionsSplit[i])													//remove the key from chaincode state
-		if err != nil {
-			errMsg := "{ \"transactions\" : \"" + _TransactionsSplit[i] + "\", \"message\" : \"Failed to delete state\", \"code\" : \"503\"}"
-			err = stub.SetEvent("errEvent", []byte(errMsg))
-			if err != nil {
-				return nil, err
-			} 
-			return nil, nil
-		}
-		_TransactionsSplit = append(_TransactionsSplit[:i], _TransactionsSplit[i+1:]...)			//remove it
-		fmt.Println(_TransactionsSplit)
-		for x:= range _TransactionsSplit{											//debug prints...
-			fmt.Println(string(x) + " - " + _TransactionsSplit[x])
-		}
-	}
-
-	tosend := "{ \"dealID\" : \""+dealId+"\", \"message\" : \"Deal and its Transactions deleted succcessfully\", \"code\" : \"200\"}"
-	err = stub.SetEvent("evtsender", []byte(tosend))
-	if err != nil {
-		return nil, err
-	} 
-
-	fmt.Println("Deal and its Transactions deleted succcessfully")
-	return nil, nil
+func(t * ManageDeals) deleteTransactions(stub shim.ChaincodeStubInterface, args[] string)([] byte, error) {
+    // deleteTransactions("dealId", "reason", "archivedAt")
+    if len(args) != 3 {
+        errMsg:= "{ \"message\" : \"Incorrect number of arguments. Expecting 'dealId', 'reason' and 'archivedAt' as arguments\", \"code\" : \"503\"}"
+        err:= stub.SetEvent("errEvent", [] byte(errMsg))
+        if err != nil {
+            return nil, err
+        }
+        return nil, nil
+    }
+    return archiveDeal(stub, args[0], args[1], args[2], false)
+}
+// ============================================================================================================================
+// archiveDeal - archive the Transactions of a Deal, and the Deal itself when withDeal is set
+// ============================================================================================================================
+func archiveDeal(stub shim.ChaincodeStubInterface, dealId string, reason string, archivedAt string, withDeal bool)([] byte, error) {
+    var err error
+    fmt.Println("start archiveDeal")
+    dealAsBytes, err:= stub.GetState(dealId)
+    if err != nil {
+        return nil, errors.New("Failed to get Deal dealId")
+    }
+    deal := Deals{}
+    json.Unmarshal(dealAsBytes, &deal)
+    problem := ""
+    if deal.DealID != dealId {
+        problem = dealId + " Not Found."
+    } else if deal.Archive != nil {
+        problem = "Deal is already archived"
+    } else if reason == "" || archivedAt == "" {
+        problem = "Expecting a reason and the archive date"
+    }
+    var transactions[] Transactions
+    if problem == "" {
+        transactions, err = dealTransactions(stub, deal)
+        if err != nil {
+            return nil, err
+        }
+    }
+    for _, transaction := range transactions {
+        if problem == "" && OpenAllocationStatuses[transaction.AllocationStatus] {
+            problem = "Transaction " + transaction.TransactionId + " is still open (" + transaction.AllocationStatus + ")"
+        }
+    }
+    if problem != "" {
+        errMsg:= "{ \"dealId\" : \"" + dealId + "\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+        err = stub.SetEvent("errEvent", [] byte(errMsg))
+        if err != nil {
+            return nil, err
+        }
+        return nil, nil
+    }
+    archive := &Archive{reason, callerName(stub), archivedAt}
+    for _, transaction := range transactions {
+        transaction.Archive = archive
+        transactionAsBytes, _ := json.Marshal(transaction)
+        err = stub.PutState(transaction.TransactionId, transactionAsBytes)
+        if err != nil {
+            return nil, err
+        }
+    }
+    message := "Transactions of the Deal archived succcessfully"
+    if withDeal {
+        deal.Archive = archive
+        dealAsBytes, _ = json.Marshal(deal)
+        err = stub.PutState(dealId, dealAsBytes)
+        if err != nil {
+            return nil, err
+        }
+        message = "Deal and its Transactions archived succcessfully"
+    }
+    tosend := "{ \"dealID\" : \"" + dealId + "\", \"message\" : \"" + message + "\", \"code\" : \"200\"}"
+    err = stub.SetEvent("evtsender", [] byte(tosend))
+    if err != nil {
+        return nil, err
+    }
+    fmt.Println(message)
+    return nil, nil
+}
+// ============================================================================================================================
+// dealTransactions - the Transactions of a Deal that are not archived yet
+// ============================================================================================================================
+func dealTransactions(stub shim.ChaincodeStubInterface, deal Deals)([] Transactions, error) {
+    var transactions[] Transactions
+    for _, transactionId := range strings.Split(deal.Transactions, ",") {
+        transactionId = strings.TrimSpace(transactionId)
+        if transactionId == "" {
+            continue
+        }
+        transactionAsBytes, err:= stub.GetState(transactionId)
+        if err != nil {
+            return nil, errors.New("Failed to get state for " + transactionId)
+        }
+        transaction := Transactions{}
+        json.Unmarshal(transactionAsBytes, &transaction)
+        if transaction.TransactionId == transactionId && transaction.Archive == nil {
+            transactions = append(transactions, transaction)
+        }
+    }
+    return transactions, nil
+}
+// ============================================================================================================================
+// callerName - username attribute of the caller's certificate, "" when it has none
+// ============================================================================================================================
+func callerName(stub shim.ChaincodeStubInterface) string {
+    username, err:= stub.ReadCertAttribute("username")
+    if err != nil {
+        return ""
+    }
+    return string(username)
+}
+// ============================================================================================================================
+// includeArchived - whether the argument at position n asks a list query for archived records too
+// ============================================================================================================================
+func includeArchived(args[] string, n int) bool {
+    return len(args) > n && args[n] == IncludeArchived
 }
 // ============================================================================================================================
 // update_transaction - update Transaction into chaincode state
@@ -1066,6 +1135,14 @@ func(t * ManageDeals) update_transaction(stub shim.ChaincodeStubInterface, args[
     res := Transactions {}
     res_Deal := Deals {}
     json.Unmarshal(transAsBytes, &res)
+    if res.TransactionId == _transactionId && res.Archive != nil {
+        errMsg:= "{ \"transactionId\" : \"" + _transactionId + "\", \"message\" : \"Archived Transactions can't be updated\", \"code\" : \"503\"}"
+        err = stub.SetEvent("errEvent", [] byte(errMsg))
+        if err != nil {
+            return nil, err
+        }
+        return nil,nil
+    }
     if res.TransactionId == _transactionId {
         fmt.Println("Transaction found with _transactionId : " + _transactionId)
         //fmt.Println(res);
@@ -1180,6 +1257,14 @@ func(t * ManageDeals) update_transaction_AllocationStatus(stub shim.ChaincodeStu
     res:= Transactions {}
     json.Unmarshal(transAsBytes, &res)
     fmt.Println(res);
+    if res.TransactionId == _transactionId && res.Archive != nil {
+        errMsg:= "{ \"transactionId\" : \"" + _transactionId + "\", \"message\" : \"Archived Transactions can't be updated\", \"code\" : \"503\"}"
+        err = stub.SetEvent("errEvent", [] byte(errMsg))
+        if err != nil {
+            return nil, err
+        }
+        return nil,nil
+    }
     if res.TransactionId == _transactionId {
         fmt.Println("Transaction found with _transactionId : " + _transactionId)
         //fmt.Println(res);
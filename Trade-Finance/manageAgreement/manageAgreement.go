@@ -23,9 +23,18 @@ import (
 "errors"
 "fmt"
 "strconv"
+"strings"
 "encoding/json"
+"encoding/hex"
+"encoding/csv"
+"crypto/sha256"
+"math"
+"sort"
+"time"
+"unicode"
 
 "github.com/hyperledger/fabric/core/chaincode/shim"
+"github.com/hyperledger/fabric/core/util"
 )
 
 // ManageAgreement example simple Chaincode implementation
@@ -34,6 +43,53 @@ type ManageAgreement struct {
 
 var AgreementIndexStr = "_Agreementindex"				//name for the key/value that will store a list of all known Agreement
 var FraudListIndexStr = "_FraudListIndexStr"
+var FraudListDateStr = "_FraudListDate"					//date the fraud list is screened as of, only moved forward by compliance
+var PaymentChaincodeStr = "_PaymentChaincode"				//name of the Payment chaincode whose settlements and refunds are recorded, set by an admin
+
+// Order in which the parties sign an Agreement, and the status it moves to once each one has signed
+var SigningOrder = []string{"seller", "buyerBank", "sellerBank", "buyer"}
+// Status of an Agreement until its first party signs
+var AgreementCreatedStatus = "Created"
+// Status of a record whose parties matched the fraud list, until compliance clears or confirms the hit
+var HeldScreeningStatus = "Held – Screening"
+// Status of an Agreement once its payments add up to Total_Value + ExtraCharges + Shipper_fees
+var PaidStatus = "Paid"
+// Statuses of the Payment chaincode a Payment is recorded and reversed in
+var PaymentSettledStatus = "Settled"
+var PaymentRefundedStatus = "Refunded"
+var ConfirmedFraudStatus = "Rejected – Fraud"
+// Minimum similarity (0 to 1) between two normalised names to report a fuzzy match
+var FuzzyMatchThreshold = 0.85
+// Words dropped from names before matching
+var NameNoiseWords = map[string]bool{"ltd": true, "limited": true, "inc": true, "incorporated": true, "llc": true, "plc": true,
+	"corp": true, "corporation": true, "co": true, "company": true, "gmbh": true, "sa": true, "ag": true, "pvt": true, "the": true}
+
+// Categories a fraud list entry can be listed under
+var FraudCategories = map[string]bool{"sanctions": true, "fraud": true, "money_laundering": true, "counterfeit": true, "other": true}
+var FraudActiveStatus = "Active"
+var FraudRemovedStatus = "Removed"
+var DateLayout = "2006-01-02"
+
+// Shipment milestones in the order they happen, and the party allowed to post each one
+var ShipmentMilestones = []string{"picked_up", "departed_port", "arrived_port", "customs_cleared", "delivered"}
+var MilestoneRoles = map[string]string{"picked_up": "shipper", "departed_port": "portAuthority", "arrived_port": "portAuthority",
+	"customs_cleared": "portAuthority", "delivered": "shipper"}
+
+// Statuses of the Payment chaincode in which a Payment still depends on its Agreement
+var OpenPaymentStatuses = map[string]bool{"Initiated": true, "Approved by buyer bank": true, "Overdue": true,
+	"Held – Screening": true, "Held – Late Delivery": true}
+var OpenDisputeStatus = "Open"
+var IncludeArchived = "include_archived"		//optional last argument of the list queries to return archived records too
+
+// Documents that can be attached to an Agreement
+var DocumentTypes = map[string]bool{"bill_of_lading": true, "invoice": true, "certificate_of_origin": true}
+
+var SignedStatus = map[string]string{
+	"seller": "Approved By Seller",
+	"buyerBank": "Approved By Buyer Bank",
+	"sellerBank": "Approved By Seller Bank",
+	"buyer": "Fully Signed",
+}
 
 type Agreement struct{							// Attributes of a Agreement 
 	AgreementID string `json:"agreementId"`	
@@ -50,22 +106,162 @@ type Agreement struct{							// Attributes of a Agreement
 	Item_name string `json:"item_name"`
 	Item_quantity string `json:"item_quantity"`
 	Total_Value string `json:"total_value"`
+	Currency string `json:"currency"`				// ISO code of every amount, copied from the PO
 	Delivery_date string `json:"delivery_date"`
 	ExtraCharges string `json:"extraCharges"`
 	Shipper_fees string `json:"shipper_fees"`
 	DocumentName string `json:"document_name"`
 	DocumentURL string `json:"document_url"`
-	TC_Text string `json:"tc_text "`
+	TC_Text string `json:"tc_text"`
 	Buyer_sign string `json:"buyer_sign"`
 	BuyerBank_sign string `json:"buyerBank_sign"`
 	Seller_sign string `json:"seller_sign"`
-	SellerBank_sign string `json:"sellerBank_sign "`
-	Industry string `json:"industry "`
-	GoodsPrice string `json:"goodsPrice "`
+	SellerBank_sign string `json:"sellerBank_sign"`
+	Industry string `json:"industry"`
+	GoodsPrice string `json:"goodsPrice"`
+	Line_items []LineItem `json:"line_items"`				// copied from the PO identified by TransID
+	Documents []Document `json:"documents"`
+	Signatures []Signature `json:"signatures"`
+	Screening *Screening `json:"screening,omitempty"`
+	Shipment *Shipment `json:"shipment,omitempty"`
+	Instalments []Instalment `json:"instalments"`
+	Payments []AgreementPayment `json:"payments"`			// settled Payments recorded by the Payment chaincode
+	Archive *Archive `json:"archive,omitempty"`			// set instead of deleting the Agreement
+}
+type Instalment struct{						// A line of the payment schedule, e.g. 30% on shipment
+	LineNo string `json:"lineNo"`
+	Description string `json:"description"`
+	Percent string `json:"percent"`				// of Total_Value + ExtraCharges + Shipper_fees
+	Trigger string `json:"trigger"`				// signing or a shipment milestone
+}
+type Archive struct{							// Why and when a record was archived
+	Reason string `json:"reason"`
+	ArchivedBy string `json:"archivedBy"`
+	ArchivedAt string `json:"archivedAt"`
+}
+type AgreementPayment struct{
+	PaymentID string `json:"paymentId"`
+	LineNo string `json:"lineNo"`				// "" when the Agreement has no schedule
+	Amount string `json:"amount"`
+	PaidAt string `json:"paidAt"`
+	Reversed bool `json:"reversed"`				// set when the Payment is refunded
+	ReversedAt string `json:"reversedAt"`
+	Refunded string `json:"refunded"`				// partial refunds of disputes, in Currency
+}
+type Payment struct{							// Subset of the Payment returned by the Payment chaincode's getPaymentByID
+	PaymentID string `json:"paymentId"`
+	AgreementID string `json:"agreementId"`
+	PaymentStatus string `json:"paymentStatus"`
+	AmountTransferred string `json:"amountTransferred"`
+	InstalmentNo string `json:"instalmentNo"`
+	RefundedAmount string `json:"refundedAmount"`
+}
+type AgreementBalance struct{
+	AgreementID string `json:"agreementId"`
+	Agreement_status string `json:"agreement_status"`
+	Currency string `json:"currency"`
+	Total string `json:"total"`
+	Paid string `json:"paid"`
+	Outstanding string `json:"outstanding"`
+	Instalments []InstalmentBalance `json:"instalments"`
+}
+type InstalmentBalance struct{
+	LineNo string `json:"lineNo"`
+	Description string `json:"description"`
+	Percent string `json:"percent"`
+	Trigger string `json:"trigger"`
+	Due bool `json:"due"`
+	Amount string `json:"amount"`
+	Paid string `json:"paid"`
+	Outstanding string `json:"outstanding"`
+}
+type Shipment struct{						// Progress of the goods from the shipper to the buyer
+	Status string `json:"status"`				// last milestone posted
+	Milestones []Milestone `json:"milestones"`
+	Late bool `json:"late"`					// set once the goods are not delivered by Delivery_date
+	LateSince string `json:"lateSince"`
+}
+type Milestone struct{
+	Milestone string `json:"milestone"`
+	Role string `json:"role"`					// shipper or portAuthority
+	PostedBy string `json:"postedBy"`
+	At string `json:"at"`
+	Location string `json:"location"`
+	Remarks string `json:"remarks"`
+}
+type Screening struct{						// Outcome of screening the parties against the fraud list
+	Status string `json:"status"`				// Held, Cleared or Confirmed
+	Hits []ScreeningHit `json:"hits"`
+	PreviousStatus string `json:"previousStatus"`		// status restored when the hit is cleared
+	Reason string `json:"reason"`
+	ReviewedBy string `json:"reviewedBy"`
+	ReviewedAt string `json:"reviewedAt"`
+}
+type ScreeningHit struct{
+	Role string `json:"role"`
+	PartyName string `json:"partyName"`
+	FraudID string `json:"fraudId"`
+	FraudName string `json:"fraudName"`
+	MatchType string `json:"matchType"`			// exact, token or fuzzy
+	Score string `json:"score"`
+}
+type Document struct{						// A document attached to an Agreement, the file itself lives at URL
+	DocType string `json:"docType"`				// bill_of_lading, invoice or certificate_of_origin
+	Name string `json:"name"`
+	URL string `json:"url"`
+	Hash string `json:"hash"`					// hex SHA-256 of the file
+	AttachedBy string `json:"attachedBy"`
+}
+type Signature struct{						// A party's signature on an Agreement
+	Role string `json:"role"`					// seller, buyerBank, sellerBank or buyer
+	Party string `json:"party"`
+	SignedAt string `json:"signedAt"`
+	DocumentsHash string `json:"documentsHash"`		// documentsDigest of the Agreement at signing time
+}
+type LineItem struct{						// Attributes of a single PO line, as stored by the PO chaincode
+	LineNo string `json:"line_no"`
+	ItemId string `json:"item_id"`
+	Item_name string `json:"item_name"`
+	Quantity string `json:"quantity"`
+	Unit string `json:"unit"`
+	Unit_price string `json:"unit_price"`
+	Currency string `json:"currency"`
+	Tax_rate string `json:"tax_rate"`
+	Tax_amount string `json:"tax_amount"`
+	Line_total string `json:"line_total"`
+}
+type PO struct{							// Subset of the PO returned by the PO chaincode's getPO_byID
+	TransID string `json:"transId"`
+	SellerName string `json:"sellerName"`
+	BuyerName string `json:"buyerName"`
+	PO_status string `json:"po_status"`
+	Line_items []LineItem `json:"line_items"`
+	Currency string `json:"currency"`
+	Total_value string `json:"total_value"`
+	Archive *Archive `json:"archive,omitempty"`
 }
 type Fraud_list struct{
 	FraudID string `json:"fraudId"`	
 	FraudName string `json:"fraudName"`
+	Category string `json:"category"`
+	Reason string `json:"reason"`
+	ReportedBy string `json:"reportedBy"`
+	ListedDate string `json:"listedDate"`			// YYYY-MM-DD
+	ExpiryDate string `json:"expiryDate"`			// optional, YYYY-MM-DD, the entry is not screened after this date
+	Status string `json:"status"`				// Active or Removed, entries written before statuses existed count as Active
+	History []FraudAudit `json:"history"`
+}
+type FraudAudit struct{						// One change to a fraud list entry
+	Action string `json:"action"`				// listed, relisted, updated or removed
+	By string `json:"by"`
+	At string `json:"at"`
+	Reason string `json:"reason"`
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+type FieldChange struct{
+	Field string `json:"field"`
+	From string `json:"from"`
+	To string `json:"to"`
 }
 // ============================================================================================================================
 // Main - start the chaincode for Agreement management
@@ -143,8 +339,34 @@ func (t *ManageAgreement) Invoke(stub shim.ChaincodeStubInterface, function stri
 		return t.delete_agreement(stub, args)
 	}else if function == "update_agreement" {									//update an Agreement
 		return t.update_agreement(stub, args)
-	}else if function == "update_fraud_list" {									//update an Agreement
+	}else if function == "update_fraud_list" {									//add an entry to the fraud list
 		return t.update_fraud_list(stub, args)
+	}else if function == "update_fraud_entry" {									//change an entry of the fraud list
+		return t.update_fraud_entry(stub, args)
+	}else if function == "remove_from_fraud_list" {									//remove an entry from the fraud list
+		return t.remove_from_fraud_list(stub, args)
+	}else if function == "import_fraud_list" {									//add many entries given as CSV rows
+		return t.import_fraud_list(stub, args)
+	}else if function == "set_fraud_list_date" {									//move the fraud list screening date forward
+		return t.set_fraud_list_date(stub, args)
+	}else if function == "sign_agreement" {									//sign an Agreement as one of its parties
+		return t.sign_agreement(stub, args)
+	}else if function == "attach_document" {									//attach a document hash to an Agreement
+		return t.attach_document(stub, args)
+	}else if function == "review_screening" {									//compliance clears or confirms a fraud list hit
+		return t.review_screening(stub, args)
+	}else if function == "post_milestone" {									//shipper or port authority posts a shipment milestone
+		return t.post_milestone(stub, args)
+	}else if function == "check_delivery" {									//flag a late delivery
+		return t.check_delivery(stub, args)
+	}else if function == "set_instalments" {									//set the instalment schedule
+		return t.set_instalments(stub, args)
+	}else if function == "record_instalment_payment" {									//record a settled payment, called by the Payment chaincode
+		return t.record_instalment_payment(stub, args)
+	}else if function == "reverse_instalment_payment" {									//reverse a refunded payment, called by the Payment chaincode
+		return t.reverse_instalment_payment(stub, args)
+	}else if function == "set_payment_chaincode" {									//admin registers the Payment chaincode
+		return t.set_payment_chaincode(stub, args)
 	}
 	fmt.Println("invoke did not find func: " + function)					//error
 	errMsg := "{ \"message\" : \"Received unknown function invocation\", \"code\" : \"503\"}"
@@ -183,6 +405,16 @@ func (t *ManageAgreement) Query(stub shim.ChaincodeStubInterface, function strin
 		return t.getApprovalStatus(stub, args)
 	}else if function == "get_fraud_details" {													//Read a Agreement by Port Authority
 		return t.get_fraud_details(stub, args[0])
+	}else if function == "getAgreement_byTransID" {													//Read the Agreements raised against a PO
+		return t.getAgreement_byTransID(stub, args)
+	}else if function == "verify_document" {													//Check a document hash against an Agreement
+		return t.verify_document(stub, args)
+	}else if function == "screen_parties" {													//Screen party names against the fraud list
+		return t.screen_parties(stub, args)
+	}else if function == "get_shipment" {													//Read the shipment milestones of an Agreement
+		return t.get_shipment(stub, args)
+	}else if function == "getAgreementBalance" {													//Read the amounts paid and outstanding
+		return t.getAgreementBalance(stub, args)
 	}
 
 	fmt.Println("query did not find func: " + function)						//error
@@ -233,7 +465,7 @@ func (t *ManageAgreement) getAgreement_byBuyer(stub shim.ChaincodeStubInterface,
 	var valIndex Agreement
 	fmt.Println("start getAgreement_byBuyer")
 	var err error
-	if len(args) != 1 {
+	if len(args) != 1 && len(args) != 2 {
 		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"Buyer_Name\" as an argument\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
@@ -265,7 +497,11 @@ func (t *ManageAgreement) getAgreement_byBuyer(stub shim.ChaincodeStubInterface,
 		}
 		fmt.Print("valueAsBytes : ")
 		fmt.Println(valueAsBytes)
+		valIndex = Agreement{}
 		json.Unmarshal(valueAsBytes, &valIndex)
+		if valIndex.Archive != nil && !includeArchived(args, 1) {
+			continue
+		}
 		fmt.Print("valIndex: ")
 		fmt.Print(valIndex)
 		if valIndex.BuyerName == buyer_name{
@@ -289,10 +525,52 @@ func (t *ManageAgreement) getAgreement_byBuyer(stub shim.ChaincodeStubInterface,
 	fmt.Println("jsonResp : " + jsonResp)
 	fmt.Print("jsonResp in bytes : ")
 	fmt.Println([]byte(jsonResp))
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
 	fmt.Println("end getAgreement_byBuyer")
 	return []byte(jsonResp), nil											//send it onward
 }
 
+// ============================================================================================================================
+//  getAgreement_byTransID - get the Agreements raised against a specific PO (trade) from chaincode state
+// ============================================================================================================================
+func (t *ManageAgreement) getAgreement_byTransID(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var transId string
+	var agreementIndex []string
+	var valIndex Agreement
+	fmt.Println("start getAgreement_byTransID")
+	var err error
+	if len(args) != 1 && len(args) != 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"TransID\" and optionally \"include_archived\" arguments\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	transId = args[0]
+	agreementAsBytes, err := stub.GetState(AgreementIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Agreement index")
+	}
+	json.Unmarshal(agreementAsBytes, &agreementIndex)								//un stringify it aka JSON.parse()
+	agreements := make(map[string]json.RawMessage)
+	for i,val := range agreementIndex{
+		fmt.Println(strconv.Itoa(i) + " - looking at " + val + " for getAgreement_byTransID")
+		valueAsBytes, err := stub.GetState(val)
+		if err != nil {
+			return nil, errors.New("{\"Error\":\"Failed to get state for " + val + "\"}")
+		}
+		valIndex = Agreement{}
+		json.Unmarshal(valueAsBytes, &valIndex)
+		if valIndex.TransID == transId && (valIndex.Archive == nil || includeArchived(args, 1)) {
+			agreements[val] = json.RawMessage(valueAsBytes)
+		}
+	}
+	jsonResp, _ := json.Marshal(agreements)
+	fmt.Println("end getAgreement_byTransID")
+	return jsonResp, nil											//send it onward
+}
+
 // ============================================================================================================================
 //  getApprovalStatus - get approval details of an Agreement for a specific user from chaincode state
 // ============================================================================================================================
@@ -328,9 +606,6 @@ func (t *ManageAgreement) getApprovalStatus(stub shim.ChaincodeStubInterface, ar
 		fmt.Println("Seller found")
 		result = "{" + "\""+ "agreementId" + "\": \"" + agreementId + "\", \""+ "Seller_sign" + "\":\"" + string(agreementIndex.Seller_sign) + "\"}"
 		fmt.Println("result: "+ result)
-		if string(agreementIndex.Seller_sign) == "false"{
-			// call update agreement and set seller_sign "true"
-		}
 	}else if agreementIndex.BuyerName == user{
 		fmt.Println("Buyer found")
 		fmt.Print(string(agreementIndex.Agreement_status));
@@ -340,9 +615,6 @@ func (t *ManageAgreement) getApprovalStatus(stub shim.ChaincodeStubInterface, ar
 		fmt.Println("Buyer Bank found")
 		result = "{" + "\""+ "agreementId" + "\": \"" + agreementId + "\", \""+ "BuyerBank_sign" + "\":\"" + string(agreementIndex.BuyerBank_sign) + "\"}"
 		fmt.Println("result: "+ result)
-		if string(agreementIndex.Seller_sign) == "true"{
-			// check for conditions and call update agreement and set buyer bank sign "true"
-		}
 	}else if agreementIndex.SB_name == user{
 		fmt.Println("Seller Bank found")
 		result = "{" + "\""+ "agreementId" + "\": \"" + agreementId + "\", \""+ "SellerBank_sign" + "\":\"" + string(agreementIndex.SellerBank_sign) + "\"}"
@@ -369,7 +641,7 @@ func (t *ManageAgreement) getAgreement_bySeller(stub shim.ChaincodeStubInterface
 	var valIndex Agreement
 	fmt.Println("start getAgreement_bySeller")
 	var err error
-	if len(args) != 1 {
+	if len(args) != 1 && len(args) != 2 {
 		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"Seller_Name\" as an argument\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
@@ -401,7 +673,11 @@ func (t *ManageAgreement) getAgreement_bySeller(stub shim.ChaincodeStubInterface
 		}
 		fmt.Print("valueAsBytes : ")
 		fmt.Println(valueAsBytes)
+		valIndex = Agreement{}
 		json.Unmarshal(valueAsBytes, &valIndex)
+		if valIndex.Archive != nil && !includeArchived(args, 1) {
+			continue
+		}
 		fmt.Print("valIndex: ")
 		fmt.Print(valIndex)
 		if valIndex.SellerName == seller_name{
@@ -427,6 +703,7 @@ func (t *ManageAgreement) getAgreement_bySeller(stub shim.ChaincodeStubInterface
 	fmt.Println("jsonResp : " + jsonResp)
 	fmt.Print("jsonResp in bytes : ")
 	fmt.Println([]byte(jsonResp))
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
 	fmt.Println("end getAgreement_bySeller")
 	return []byte(jsonResp), nil											//send it onward
 }
@@ -463,6 +740,11 @@ func (t *ManageAgreement) get_AllAgreement(stub shim.ChaincodeStubInterface, arg
 			errResp = "{\"Error\":\"Failed to get state for " + val + "\"}"
 			return nil, errors.New(errResp)
 		}
+		valIndex := Agreement{}
+		json.Unmarshal(valueAsBytes, &valIndex)
+		if valIndex.Archive != nil && !includeArchived(args, 0) {
+			continue
+		}
 		fmt.Print("valueAsBytes : ")
 		fmt.Println(valueAsBytes)
 		jsonResp = jsonResp + "\""+ val + "\":" + string(valueAsBytes[:])
@@ -476,6 +758,7 @@ func (t *ManageAgreement) get_AllAgreement(stub shim.ChaincodeStubInterface, arg
 	fmt.Println("jsonResp : " + jsonResp)
 	fmt.Print("jsonResp in bytes : ")
 	fmt.Println([]byte(jsonResp))
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
 	fmt.Println("end get_AllAgreement")
 	return []byte(jsonResp), nil
 											//send it onward
@@ -490,7 +773,7 @@ func (t *ManageAgreement) getAgreement_byShipper(stub shim.ChaincodeStubInterfac
 	var valIndex Agreement
 	fmt.Println("start getAgreement_byShipper")
 	var err error
-	if len(args) != 1 {
+	if len(args) != 1 && len(args) != 2 {
 		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"Shipper_Name\" as an argument\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
@@ -522,7 +805,11 @@ func (t *ManageAgreement) getAgreement_byShipper(stub shim.ChaincodeStubInterfac
 		}
 		fmt.Print("valueAsBytes : ")
 		fmt.Println(valueAsBytes)
+		valIndex = Agreement{}
 		json.Unmarshal(valueAsBytes, &valIndex)
+		if valIndex.Archive != nil && !includeArchived(args, 1) {
+			continue
+		}
 		fmt.Print("valIndex: ")
 		fmt.Print(valIndex)
 		if valIndex.ShipperName == shipper_name{
@@ -548,6 +835,7 @@ func (t *ManageAgreement) getAgreement_byShipper(stub shim.ChaincodeStubInterfac
 	fmt.Println("jsonResp : " + jsonResp)
 	fmt.Print("jsonResp in bytes : ")
 	fmt.Println([]byte(jsonResp))
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
 	fmt.Println("end getAgreement_byShipper")
 	return []byte(jsonResp), nil											//send it onward
 }
@@ -561,7 +849,7 @@ func (t *ManageAgreement) getAgreement_byBuyerBank(stub shim.ChaincodeStubInterf
 	var valIndex Agreement
 	fmt.Println("start getAgreement_byBuyerBank")
 	var err error
-	if len(args) != 1 {
+	if len(args) != 1 && len(args) != 2 {
 		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"Buyer_Bank_Name\" as an argument\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
@@ -593,7 +881,11 @@ func (t *ManageAgreement) getAgreement_byBuyerBank(stub shim.ChaincodeStubInterf
 		}
 		fmt.Print("valueAsBytes : ")
 		fmt.Println(valueAsBytes)
+		valIndex = Agreement{}
 		json.Unmarshal(valueAsBytes, &valIndex)
+		if valIndex.Archive != nil && !includeArchived(args, 1) {
+			continue
+		}
 		fmt.Print("valIndex: ")
 		fmt.Print(valIndex)
 		if valIndex.BB_name == bb_name{
@@ -619,6 +911,7 @@ func (t *ManageAgreement) getAgreement_byBuyerBank(stub shim.ChaincodeStubInterf
 	fmt.Println("jsonResp : " + jsonResp)
 	fmt.Print("jsonResp in bytes : ")
 	fmt.Println([]byte(jsonResp))
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
 	fmt.Println("end getAgreement_byBuyerBank")
 	return []byte(jsonResp), nil											//send it onward
 }
@@ -632,7 +925,7 @@ func (t *ManageAgreement) getAgreement_bySellerBank(stub shim.ChaincodeStubInter
 	var valIndex Agreement
 	fmt.Println("start getAgreement_bySellerBank")
 	var err error
-	if len(args) != 1 {
+	if len(args) != 1 && len(args) != 2 {
 		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"Seller_Bank_Name\" as an argument\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
@@ -664,7 +957,11 @@ func (t *ManageAgreement) getAgreement_bySellerBank(stub shim.ChaincodeStubInter
 		}
 		fmt.Print("valueAsBytes : ")
 		fmt.Println(valueAsBytes)
+		valIndex = Agreement{}
 		json.Unmarshal(valueAsBytes, &valIndex)
+		if valIndex.Archive != nil && !includeArchived(args, 1) {
+			continue
+		}
 		fmt.Print("valIndex: ")
 		fmt.Print(valIndex)
 		if valIndex.SB_name == sb_name{
@@ -690,6 +987,7 @@ func (t *ManageAgreement) getAgreement_bySellerBank(stub shim.ChaincodeStubInter
 	fmt.Println("jsonResp : " + jsonResp)
 	fmt.Print("jsonResp in bytes : ")
 	fmt.Println([]byte(jsonResp))
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
 	fmt.Println("end getAgreement_bySellerBank")
 	return []byte(jsonResp), nil											//send it onward
 }
@@ -703,7 +1001,7 @@ func (t *ManageAgreement) getAgreement_byPortAuthority(stub shim.ChaincodeStubIn
 	var valIndex Agreement
 	fmt.Println("start getAgreement_byPortAuthority")
 	var err error
-	if len(args) != 1 {
+	if len(args) != 1 && len(args) != 2 {
 		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"Port_Authority_Name\" as an argument\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
@@ -735,7 +1033,11 @@ func (t *ManageAgreement) getAgreement_byPortAuthority(stub shim.ChaincodeStubIn
 		}
 		fmt.Print("valueAsBytes : ")
 		fmt.Println(valueAsBytes)
+		valIndex = Agreement{}
 		json.Unmarshal(valueAsBytes, &valIndex)
+		if valIndex.Archive != nil && !includeArchived(args, 1) {
+			continue
+		}
 		fmt.Print("valIndex: ")
 		fmt.Print(valIndex)
 		if valIndex.PortAuthName == agreementPortAuth_name{
@@ -761,6 +1063,7 @@ func (t *ManageAgreement) getAgreement_byPortAuthority(stub shim.ChaincodeStubIn
 	fmt.Println("jsonResp : " + jsonResp)
 	fmt.Print("jsonResp in bytes : ")
 	fmt.Println([]byte(jsonResp))
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
 	fmt.Println("end getAgreement_byPortAuthority")
 	return []byte(jsonResp), nil											//send it onward
 }
@@ -880,12 +1183,14 @@ func (t *ManageAgreement) get_fraud_list(stub shim.ChaincodeStubInterface, args
 	return []byte(jsonResp), nil
 }
 // ============================================================================================================================
-// Delete - remove a Agreement from chain
+// Delete - archive an Agreement with a reason once no open Payment or dispute depends on it, the Agreement stays on the ledger
 // ============================================================================================================================
 func (t *ManageAgreement) delete_agreement(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	if len(args) != 1 {
-		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"agreementID\" as an argument.\", \"code\" : \"503\"}"
-		err := stub.SetEvent("errEvent", []byte(errMsg))
+	// delete_agreement("agreementId", "reason", "archivedAt", "paymentChaincode")
+	var err error
+	if len(args) != 4 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"agreementID\", \"reason\", \"archivedAt\" and \"paymentChaincode\" arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
 		} 
@@ -893,42 +1198,83 @@ func (t *ManageAgreement) delete_agreement(stub shim.ChaincodeStubInterface, arg
 	}
 	// set agreementId
 	agreementId := args[0]
-	err := stub.DelState(agreementId)													//remove the Agreement from chaincode
+	reason := args[1]
+	archivedAt := args[2]
+	paymentChaincode := args[3]
+	agreementAsBytes, err := stub.GetState(agreementId)
 	if err != nil {
-		errMsg := "{ \"message\" : \"Failed to delete state\", \"code\" : \"503\"}"
+		return nil, errors.New("{\"Error\":\"Failed to get state for " + agreementId + "\"}")
+	}
+	res := Agreement{}
+	json.Unmarshal(agreementAsBytes, &res)
+	problem := ""
+	if res.AgreementID != agreementId {
+		problem = agreementId + " Not Found."
+	} else if res.Archive != nil {
+		problem = "Agreement is already archived"
+	} else if reason == "" || !isDate(archivedAt) {
+		problem = "Expecting a reason and a YYYY-MM-DD date"
+	}
+	if problem == "" {
+		f := "getPaymentByAgreement"
+		queryArgs := util.ToChaincodeArgs(f, agreementId)
+		paymentsAsBytes, err := stub.QueryChaincode(paymentChaincode, queryArgs)
+		if err != nil {
+			errStr := fmt.Sprintf("Failed to query Payment chaincode. Got error: %s", err.Error())
+			fmt.Println(errStr)
+			return nil, errors.New(errStr)
+		}
+		payments := make(map[string]struct{
+			PaymentStatus string `json:"paymentStatus"`
+			OpenDispute string `json:"openDispute"`
+		})
+		json.Unmarshal(paymentsAsBytes, &payments)
+		var paymentIds []string
+		for paymentId := range payments {
+			paymentIds = append(paymentIds, paymentId)
+		}
+		sort.Strings(paymentIds)							// report the same Payment on every peer
+		for _, paymentId := range paymentIds {
+			if problem == "" && (OpenPaymentStatuses[payments[paymentId].PaymentStatus] || payments[paymentId].OpenDispute != "") {
+				problem = "Payment " + paymentId + " of this Agreement is still open"
+			}
+		}
+	}
+	if problem == "" {
+		f := "getDisputesByAgreement"
+		queryArgs := util.ToChaincodeArgs(f, agreementId)
+		disputesAsBytes, err := stub.QueryChaincode(paymentChaincode, queryArgs)
+		if err != nil {
+			errStr := fmt.Sprintf("Failed to query Payment chaincode. Got error: %s", err.Error())
+			fmt.Println(errStr)
+			return nil, errors.New(errStr)
+		}
+		var disputes []struct{
+			DisputeID string `json:"disputeId"`
+			Dispute_status string `json:"dispute_status"`
+		}
+		json.Unmarshal(disputesAsBytes, &disputes)
+		for _, dispute := range disputes {
+			if problem == "" && dispute.Dispute_status == OpenDisputeStatus {
+				problem = "Dispute " + dispute.DisputeID + " of this Agreement is still open"
+			}
+		}
+	}
+	if problem != "" {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
-		} 
+		}
 		return nil, nil
 	}
-
-	//get the Agreement index
-	agreementAsBytes, err := stub.GetState(AgreementIndexStr)
+	res.Archive = &Archive{reason, callerName(stub), archivedAt}
+	agreementAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(agreementId, agreementAsBytes)
 	if err != nil {
-		return nil, errors.New("Failed to get Agreement index")
-	}
-	fmt.Println("agreementAsBytes in delete agreement")
-	fmt.Println(agreementAsBytes);
-	var agreementIndex []string
-	json.Unmarshal(agreementAsBytes, &agreementIndex)								//un stringify it aka JSON.parse()
-	fmt.Println("agreementIndex in delete agreement")
-	fmt.Println(agreementIndex);
-	//remove agreement from index
-	for i,val := range agreementIndex{
-		fmt.Println(strconv.Itoa(i) + " - looking at " + val + " for " + agreementId)
-		if val == agreementId{															//find the correct Agreement
-			fmt.Println("found Agreement with matching agreementId")
-			agreementIndex = append(agreementIndex[:i], agreementIndex[i+1:]...)			//remove it
-			for x:= range agreementIndex{											//debug prints...
-				fmt.Println(string(x) + " - " + agreementIndex[x])
-			}
-			break
-		}
+		return nil, err
 	}
-	jsonAsBytes, _ := json.Marshal(agreementIndex)									//save new index
-	err = stub.PutState(AgreementIndexStr, jsonAsBytes)
-	tosend := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Agreement deleted succcessfully\", \"code\" : \"200\"}"
+	tosend := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Agreement archived succcessfully\", \"code\" : \"200\"}"
 	err = stub.SetEvent("evtsender", []byte(tosend))
 	if err != nil {
 		return nil, err
@@ -936,14 +1282,15 @@ func (t *ManageAgreement) delete_agreement(stub shim.ChaincodeStubInterface, arg
 	return nil, nil
 }
 // ============================================================================================================================
-// Write - update Agreement into chaincode state
+// Write - update Agreement into chaincode state, only before anyone has signed it. The status and signatures only change
+// through sign_agreement
 // ============================================================================================================================
 func (t *ManageAgreement) update_agreement(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	var jsonResp string
 	var err error
 	fmt.Println("start update_agreement")
-	if len(args) != 26{
-		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 26 arguments.\", \"code\" : \"503\"}"
+	if len(args) != 25{
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 25 arguments.\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
@@ -962,64 +1309,69 @@ func (t *ManageAgreement) update_agreement(stub shim.ChaincodeStubInterface, arg
 	res := Agreement{}
 	json.Unmarshal(agreementAsBytes, &res)
 
+	if res.AgreementID == agreementId && (res.Agreement_status == HeldScreeningStatus || res.Agreement_status == ConfirmedFraudStatus || len(res.Payments) > 0 || res.Archive != nil) {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Agreement cannot be updated, status is " + res.Agreement_status + " with " + strconv.Itoa(len(res.Payments)) + " payments recorded\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	signed := len(res.Signatures) > 0
+	for _, role := range SigningOrder {
+		signed = signed || *signFlag(&res, role) == "true"
+	}
+	if res.AgreementID == agreementId && signed {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Agreement is already signed and can't be updated\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if res.AgreementID == agreementId && (args[1] != res.TransID || args[2] != res.BuyerName || args[3] != res.SellerName) {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"The PO, buyer and seller of an Agreement can't be changed\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	var parties []string
 	if res.AgreementID == agreementId{
 		fmt.Println("Agreement found with agreementId : " + agreementId)
 		fmt.Println(res);
 		
-		res.TransID = args[1]
-		res.Agreement_status = args[2]
-		res.BuyerName = args[3]
-		res.SellerName = args[4]
-		res.ShipperName = args[5]
-		res.BB_name = args[6]
-		res.SB_name	= args[7]
-		res.PortAuthName = args[8]
-		res.AgreementCU_date = args[9]
-		res.ItemId = args[10]
-		res.Item_name = args[11]
-		res.Item_quantity = args[12]
-		res.Total_Value = args[13]
-		res.Delivery_date = args[14]
-		res.ExtraCharges = args[15]
-		res.Shipper_fees = args[16]
-		res.DocumentName = args[17]
-		res.DocumentURL = args[18]
-		res.TC_Text = args[19]
-		res.Buyer_sign = args[20]
-		res.BuyerBank_sign = args[21]
-		res.Seller_sign = args[22]
-		res.SellerBank_sign = args[23]
-		res.Industry = args[24]
-		res.GoodsPrice = args[25]
-		
-		totalValue,err := strconv.Atoi(res.Total_Value)
-		if err != nil {
-			return nil, errors.New("Error while converting string 'total_value' to int ")
+		// parties whose name changes are screened again
+		renamed := map[string][2]string{
+			"shipper": {res.ShipperName, args[4]},
+			"buyerBank": {res.BB_name, args[5]},
+			"sellerBank": {res.SB_name, args[6]},
+			"portAuthority": {res.PortAuthName, args[7]},
 		}
-
-		// Auto Approval
-		/*if (totalValue <= 10000 || res.Industry == "Books" || res.Industry == "Mobiles & Tablets"){
-			res.BuyerBank_sign = "true";
-			if (res.Industry == "Books" || res.Industry == "Mobiles & Tablets"){
-				res.SellerBank_sign = "true";
+		for _, role := range []string{"shipper", "buyerBank", "sellerBank", "portAuthority"} {
+			if renamed[role][0] != renamed[role][1] {
+				parties = append(parties, role, renamed[role][1])
 			}
-		}*/
-		if (totalValue <= 10000 && (res.Industry == "Books" || res.Industry == "Mobiles & Tablets")){
-			res.BuyerBank_sign = "true";
-		}
-		if (res.Industry == "Books" || res.Industry == "Mobiles & Tablets"){
-			res.SellerBank_sign = "true";
 		}
-		if(res.BuyerBank_sign == "true" && res.Seller_sign == "false" && res.SellerBank_sign == "false"){
-			res.Agreement_status = "Approved By Buyer Bank"
-		}
-		if(res.BuyerBank_sign == "true" && res.Seller_sign == "true" && res.SellerBank_sign == "false"){
-			res.Agreement_status = "Approved By Seller"
-		}
-		if(res.BuyerBank_sign == "true" && res.Seller_sign == "true" && res.SellerBank_sign == "true"){
-			res.Agreement_status = "Approved By Seller Bank"
-		}
-		
+		res.ShipperName = args[4]
+		res.BB_name = args[5]
+		res.SB_name	= args[6]
+		res.PortAuthName = args[7]
+		res.AgreementCU_date = args[8]
+		res.ItemId = args[9]
+		res.Item_name = args[10]
+		res.Item_quantity = args[11]
+		res.Total_Value = args[12]
+		res.Delivery_date = args[13]
+		res.ExtraCharges = args[14]
+		res.Shipper_fees = args[15]
+		res.DocumentName = args[16]
+		res.DocumentURL = args[17]
+		res.TC_Text = args[18]
+		// args[19] to args[22] (signatures) are ignored, signatures only change through sign_agreement
+		res.Industry = args[23]
+		res.GoodsPrice = args[24]
 	}else{
 		errMsg := "{ \"message\" : \""+ agreementId+ " Not Found.\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
@@ -1029,40 +1381,35 @@ func (t *ManageAgreement) update_agreement(stub shim.ChaincodeStubInterface, arg
 		return nil, nil
 	}
 
-	//build the Agreement json string manually
-	input := 	`{`+
-		`"agreementId": "` + res.AgreementID + `" , `+
-		`"transId": "` + res.TransID + `" , `+ 
-		`"agreement_status": "` + res.Agreement_status + `" , `+ 
-		`"buyer_name": "` + res.BuyerName + `" , `+
-		`"seller_name": "` + res.SellerName + `" , `+
-		`"shipper_name": "` + res.ShipperName + `" , `+ 
-		`"bb_name": "` + res.BB_name + `" , `+ 
-		`"sb_name": "` + res.SB_name + `" , `+ 
-		`"agreementPortAuth_name": "` + res.PortAuthName + `" , `+ 
-		`"agreementCU_date": "` + res.AgreementCU_date + `" , `+ 
-		`"item_id": "` + res.ItemId + `" , `+ 
-		`"item_name": "` + res.Item_name + `" , `+ 
-		`"item_quantity": "` + res.Item_quantity + `" , `+ 
-		`"total_value": "` + res.Total_Value + `" , `+ 
-		`"delivery_date": "` + res.Delivery_date + `" , `+ 
-		`"extraCharges": "` + res.ExtraCharges + `" , `+ 
-		`"shipper_fees" : "` + res.Shipper_fees + `", `+ 
-		`"document_name": "` + res.DocumentName + `" , `+ 
-		`"document_url": "` + res.DocumentURL + `" , `+ 
-		`"tc_text" : "` + res.TC_Text + `" , `+ 
-		`"buyer_sign": "` + res.Buyer_sign + `" , `+ 
-		`"buyerBank_sign": "` + res.BuyerBank_sign + `" , `+ 
-		`"seller_sign": "` + res.Seller_sign + `" , `+ 
-		`"sellerBank_sign" : "` + res.SellerBank_sign + `" , `+ 
-		`"industry" : "` + res.Industry + `" , `+
-		`"goodsPrice" : "` + res.GoodsPrice + `" `+ 
-		`}`
-	err = stub.PutState(agreementId, []byte(input))									//store Agreement with id as key
+	err = checkAgreementTotal(res)
+	if err != nil {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"" + err.Error() + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if len(parties) > 0 {
+		hits, err := screenParties(stub, parties)
+		if err != nil {
+			return nil, err
+		}
+		if len(hits) > 0 {
+			res.Screening = &Screening{Status: "Held", Hits: hits, PreviousStatus: res.Agreement_status}
+			res.Agreement_status = HeldScreeningStatus
+		}
+	}
+
+	input, _ := json.Marshal(res)
+	err = stub.PutState(agreementId, input)									//store Agreement with id as key
 	if err != nil {
 		return nil, err
 	}
 	tosend := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Agreement updated succcessfully\", \"code\" : \"200\"}"
+	if res.Agreement_status == HeldScreeningStatus {
+		tosend = "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Agreement updated and held for fraud screening\", \"code\" : \"200\"}"
+	}
 	err = stub.SetEvent("evtsender", []byte(tosend))
 	if err != nil {
 		return nil, err
@@ -1075,8 +1422,8 @@ func (t *ManageAgreement) update_agreement(stub shim.ChaincodeStubInterface, arg
 // ============================================================================================================================
 func (t *ManageAgreement) create_agreement(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	var err error
-	if len(args) != 26 {
-		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 26 arguments.\", \"code\" : \"503\"}"
+	if len(args) != 27 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 27 arguments.\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
@@ -1087,7 +1434,7 @@ func (t *ManageAgreement) create_agreement(stub shim.ChaincodeStubInterface, arg
 	
 		agreementId := args[0]
 		transId := args[1]
-		agreement_status := args[2]
+		agreement_status := AgreementCreatedStatus			// args[2] is kept for compatibility, an Agreement always starts Created
 		buyer_name := args[3]
 		seller_name := args[4]
 		shipper_name := args[5]
@@ -1105,49 +1452,11 @@ func (t *ManageAgreement) create_agreement(stub shim.ChaincodeStubInterface, arg
 		document_name := args[17]
 		document_url := args[18]
 		tc_text := args[19]
-		buyer_sign := args[20]
-		buyerBank_sign := args[21]
-		seller_sign := args[22]
-		sellerBank_sign := args[23]
+		// args[20] to args[23] (signatures) are ignored, signatures are collected through sign_agreement
 		industry := args[24]
 		goodsPrice := args[25]
+		poChaincode := args[26]						// name of the PO chaincode holding transId
 		
-		fmt.Println("Checking fraud list...");
-
-		buyer, err:= t.get_fraud_details(stub, buyer_name)
-		if buyer != nil{
-			errMsg := "{ \"Agreement ID\" : \""+agreementId+"\", \"message\" : \"Buyer name exists in Fraud list. So, Agreement auto-rejected by System. \", \"code\" : \"503\"}"
-			err = stub.SetEvent("errEvent", []byte(errMsg))
-			if err != nil {
-				return nil, err
-			} 
-			return nil, nil
-		} else if err != nil{
-			errMsg := "{ \"message\" : \"Error while checking for Buyer in Fraud list. \", \"code\" : \"503\"}"
-			err = stub.SetEvent("errEvent", []byte(errMsg))
-			if err != nil {
-				return nil, err
-			} 
-			return nil, nil
-		}
-		seller, err := t.get_fraud_details(stub, seller_name)
-		if seller != nil{
-			errMsg := "{ \"Agreement ID\" : \""+agreementId+"\", \"message\" : \"Seller name exists in Fraud list. So, Agreement auto-rejected by System. \", \"code\" : \"503\"}"
-			err = stub.SetEvent("errEvent", []byte(errMsg))
-			if err != nil {
-				return nil, err
-			} 
-			return nil, nil
-		}else if err != nil{
-			errMsg := "{ \"message\" : \"Error while checking for Seller in Fraud list. \", \"code\" : \"503\"}"
-			err = stub.SetEvent("errEvent", []byte(errMsg))
-			if err != nil {
-				return nil, err
-			} 
-			return nil, nil
-		}
-		fmt.Println("Checked fraud list successfully.");
-
 		agreementAsBytes, err := stub.GetState(agreementId)
 		if err != nil {
 			return nil, errors.New("Failed to get Agreement ID")
@@ -1168,39 +1477,107 @@ func (t *ManageAgreement) create_agreement(stub shim.ChaincodeStubInterface, arg
 		return nil, nil				//all stop a Agreement by this name exists
 	}
 	
-	//build the Agreement json string manually
-	input := 	`{`+
-		`"agreementId": "` + agreementId + `" , `+
-		`"transId": "` + transId + `" , `+ 
-		`"agreement_status": "` + agreement_status + `" , `+ 
-		`"buyer_name": "` + buyer_name + `" , `+
-		`"seller_name": "` + seller_name + `" , `+
-		`"shipper_name": "` + shipper_name + `" , `+ 
-		`"bb_name": "` + bb_name + `" , `+ 
-		`"sb_name": "` + sb_name + `" , `+ 	
-		`"agreementPortAuth_name": "` + agreementPortAuth_name + `" , `+ 
-		`"agreementCU_date": "` + agreementCU_date + `" , `+ 
-		`"item_id": "` + item_id + `" , `+ 
-		`"item_name": "` + item_name + `" , `+ 
-		`"item_quantity": "` + item_quantity + `" , `+ 
-		`"total_value": "` + total_value + `" , `+ 
-		`"delivery_date": "` + delivery_date + `" , `+ 
-		`"extraCharges": "` + extraCharges + `" , `+ 
-		`"shipper_fees": "` + shipper_fees + `", `+ 
-		`"document_name": "` + document_name + `" , `+ 
-		`"document_url": "` + document_url + `" , `+ 
-		`"tc_text": "` + tc_text + `" , `+ 
-		`"buyer_sign": "` + buyer_sign + `" , `+ 
-		`"buyerBank_sign": "` + buyerBank_sign + `" , `+ 
-		`"seller_sign": "` + seller_sign + `" , `+ 
-		`"sellerBank_sign": "` + sellerBank_sign + `", `+ 
-		`"industry": "` + industry + `" , `+
-		`"goodsPrice": "` + goodsPrice + `" `+
-		`}`
-		fmt.Println("input: " + input)
-		fmt.Print("input in bytes array: ")
-		fmt.Println([]byte(input))
-	err = stub.PutState(agreementId, []byte(input))									//store Agreement with agreementId as key
+	// Fetch the PO this agreement is raised against and copy its line items
+	f := "getPO_byID"
+	queryArgs := util.ToChaincodeArgs(f, transId)
+	poAsBytes, err := stub.QueryChaincode(poChaincode, queryArgs)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to query PO chaincode. Got error: %s", err.Error())
+		fmt.Println(errStr)
+		return nil, errors.New(errStr)
+	}
+	po := PO{}
+	json.Unmarshal(poAsBytes, &po)
+	if po.TransID != transId {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"PO " + transId + " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if po.PO_status != "Accepted" || po.Archive != nil {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"PO " + transId + " is not Accepted (status: " + po.PO_status + ") or is archived.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if po.BuyerName != buyer_name || po.SellerName != seller_name {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Buyer and seller must be the buyer and seller of PO " + transId + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	agreement := Agreement{
+		AgreementID: agreementId,
+		TransID: transId,
+		Currency: po.Currency,
+		Agreement_status: agreement_status,
+		BuyerName: buyer_name,
+		SellerName: seller_name,
+		ShipperName: shipper_name,
+		BB_name: bb_name,
+		SB_name: sb_name,
+		PortAuthName: agreementPortAuth_name,
+		AgreementCU_date: agreementCU_date,
+		ItemId: item_id,
+		Item_name: item_name,
+		Item_quantity: item_quantity,
+		Total_Value: total_value,
+		Delivery_date: delivery_date,
+		ExtraCharges: extraCharges,
+		Shipper_fees: shipper_fees,
+		DocumentName: document_name,
+		DocumentURL: document_url,
+		TC_Text: tc_text,
+		Buyer_sign: "false",					// signatures are only collected through sign_agreement
+		BuyerBank_sign: "false",
+		Seller_sign: "false",
+		SellerBank_sign: "false",
+		Industry: industry,
+		GoodsPrice: goodsPrice,
+		Line_items: po.Line_items,
+	}
+	err = checkAgreementTotal(agreement)
+	if err != nil {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"" + err.Error() + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	fmt.Println("Checking fraud list...");
+	hits, err := screenParties(stub, []string{
+		"buyer", buyer_name,
+		"seller", seller_name,
+		"shipper", shipper_name,
+		"buyerBank", bb_name,
+		"sellerBank", sb_name,
+		"portAuthority", agreementPortAuth_name,
+	})
+	if err != nil {
+		errMsg := "{ \"message\" : \"Error while checking the Fraud list. \", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if len(hits) > 0 {
+		agreement.Screening = &Screening{Status: "Held", Hits: hits, PreviousStatus: agreement.Agreement_status}
+		agreement.Agreement_status = HeldScreeningStatus
+	}
+	fmt.Println("Checked fraud list successfully.");
+
+	input, _ := json.Marshal(agreement)
+	fmt.Println("input: " + string(input))
+	err = stub.PutState(agreementId, input)									//store Agreement with agreementId as key
 	if err != nil {
 		return nil, err
 	}
@@ -1228,6 +1605,9 @@ func (t *ManageAgreement) create_agreement(stub shim.ChaincodeStubInterface, arg
 	}
 
 	tosend := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Agreement created succcessfully\", \"code\" : \"200\"}"
+	if agreement.Screening != nil {
+		tosend = "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Agreement created and held for fraud screening\", \"code\" : \"200\"}"
+	}
 	err = stub.SetEvent("evtsender", []byte(tosend))
 	if err != nil {
 		return nil, err
@@ -1240,172 +1620,1881 @@ func (t *ManageAgreement) create_agreement(stub shim.ChaincodeStubInterface, arg
 // create Fraud_list - add an entry in the farus list, store into chaincode state
 // ============================================================================================================================
 func (t *ManageAgreement) update_fraud_list(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	// update_fraud_list("fraudID","fraudName")
+	// update_fraud_list("fraudID","fraudName","category","reason","reportedBy","listedDate","expiryDate")
 	var err error
-	if len(args) != 2 {
-		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 2 arguments.\", \"code\" : \"503\"}"
+	if len(args) != 7 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 7 arguments.\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
-		} 
+		}
 		return nil, nil
 	}
 	fmt.Println("Updating Fraud list.")
-	
-	fraudId := args[0]
-	fraudName := args[1]
 
-	fraudListAsBytes, err := stub.GetState(fraudId)
-	if err != nil {
-		return nil, errors.New("Failed to get fraudID")
-	}
-	fmt.Print("fraudListAsBytes: ")
-	fmt.Println(fraudListAsBytes)
-	res := Fraud_list{}
-	json.Unmarshal(fraudListAsBytes, &res)
-	fmt.Print("res: ")
-	fmt.Println(res)
-	if res.FraudID == fraudId{
-		fmt.Println("This Fraud Name already exists: " + fraudId)
-		errMsg := "{ \"message\" : \"This Fraud Name already exists.\", \"code\" : \"503\"}"
-		err := stub.SetEvent("errEvent", []byte(errMsg))
+	fraud := fraudEntryFromRow(args)
+	user, ok := complianceUser(stub)
+	if !ok {
+		errMsg := "{ \"Fraud ID\" : \""+fraud.FraudID+"\", \"message\" : \"Only a compliance user can change the fraud list\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
-		} 
-		return nil, nil				//all stop a Fraud List by this name exists
+		}
+		return nil, nil
 	}
-	
-	//build the fraud json string manually
-	input := 	`{`+
-		`"fraudId": "` + fraudId + `" , `+
-		`"fraudName": "` + fraudName  + `" `+ 
-		`}`
-		fmt.Println("input: " + input)
-		fmt.Print("input in bytes array: ")
-		fmt.Println([]byte(input))
-	err = stub.PutState(fraudId, []byte(input))									//store Fraud with fraudId as key
+	existing, problem, err := checkFraudListing(stub, fraud)
 	if err != nil {
 		return nil, err
 	}
-	//get the Fraud List index
-	fraudListIndexAsBytes, err := stub.GetState(FraudListIndexStr)
-	if err != nil {
-		return nil, errors.New("Failed to get Fraud List index")
+	if problem != "" {
+		fmt.Println(problem)
+		errMsg := "{ \"Fraud ID\" : \""+fraud.FraudID+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
 	}
-	var fraudListIndex []string
-	fmt.Print("fraudListIndexAsBytes: ")
-	fmt.Println(fraudListIndexAsBytes)
-	
-	json.Unmarshal(fraudListIndexAsBytes, &fraudListIndex)							//un stringify it aka JSON.parse()
-	//append
-	fraudListIndex = append(fraudListIndex, fraudId)									//add fraudId to index list
-	fmt.Println("! fraud List index after appending fraudId: ", fraudListIndex)
-	jsonAsBytes, _ := json.Marshal(fraudListIndex)
-	fmt.Print("jsonAsBytes: ")
-	fmt.Println(jsonAsBytes)
-	err = stub.PutState(FraudListIndexStr, jsonAsBytes)						//store name of Agreement
+	isNew, err := writeFraudListing(stub, fraud, existing, user)
 	if err != nil {
 		return nil, err
 	}
-	tosend := "{ \"Fraud ID\" : \""+fraudId+"\", \"message\" : \"Fraud ID added succcessfully\", \"code\" : \"200\"}"
+	if isNew {
+		err = appendFraudListIndex(stub, []string{fraud.FraudID})
+		if err != nil {
+			return nil, err
+		}
+	}
+	tosend := "{ \"Fraud ID\" : \""+fraud.FraudID+"\", \"message\" : \"Fraud ID added succcessfully\", \"code\" : \"200\"}"
 	err = stub.SetEvent("evtsender", []byte(tosend))
 	if err != nil {
 		return nil, err
-	} 
+	}
 
 	fmt.Println("Fraud list updated successfully.")
 	return nil, nil
 }
-/*func (t *ManageAgreement) approve_agreement(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	/*var jsonResp , str string
+// ============================================================================================================================
+// update_fraud_entry - change the details of a listed entry, the previous values are kept in its history
+// ============================================================================================================================
+func (t *ManageAgreement) update_fraud_entry(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// update_fraud_entry("fraudID","fraudName","category","reason","reportedBy","expiryDate","updatedAt")
 	var err error
-	fmt.Println("start approve_agreement")
-	if len(args) != 3{
+	if len(args) != 7 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 7 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	fmt.Println("start update_fraud_entry")
+	fraudId := args[0]
+	updatedAt := args[6]
+	user, ok := complianceUser(stub)
+	if !ok {
+		errMsg := "{ \"Fraud ID\" : \""+fraudId+"\", \"message\" : \"Only a compliance user can change the fraud list\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	fraudAsBytes, err := stub.GetState(fraudId)
+	if err != nil {
+		return nil, errors.New("Failed to get fraudID")
+	}
+	res := Fraud_list{}
+	json.Unmarshal(fraudAsBytes, &res)
+	if res.FraudID != fraudId || res.Status == FraudRemovedStatus {
+		errMsg := "{ \"message\" : \""+ fraudId+ " is not on the fraud list.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	updated := res
+	updated.FraudName = args[1]
+	updated.Category = args[2]
+	updated.Reason = args[3]
+	updated.ReportedBy = args[4]
+	updated.ExpiryDate = args[5]
+	problem := validateFraudEntry(updated, res.ListedDate == "")
+	if problem == "" && !isDate(updatedAt) {
+		problem = "updatedAt must be a YYYY-MM-DD date"
+	}
+	if problem != "" {
+		errMsg := "{ \"Fraud ID\" : \""+fraudId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	var changes []FieldChange
+	if res.FraudName != updated.FraudName {
+		changes = append(changes, FieldChange{"fraudName", res.FraudName, updated.FraudName})
+	}
+	if res.Category != updated.Category {
+		changes = append(changes, FieldChange{"category", res.Category, updated.Category})
+	}
+	if res.Reason != updated.Reason {
+		changes = append(changes, FieldChange{"reason", res.Reason, updated.Reason})
+	}
+	if res.ReportedBy != updated.ReportedBy {
+		changes = append(changes, FieldChange{"reportedBy", res.ReportedBy, updated.ReportedBy})
+	}
+	if res.ExpiryDate != updated.ExpiryDate {
+		changes = append(changes, FieldChange{"expiryDate", res.ExpiryDate, updated.ExpiryDate})
+	}
+	if len(changes) == 0 {
+		errMsg := "{ \"Fraud ID\" : \""+fraudId+"\", \"message\" : \"Nothing to update\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	updated.Status = FraudActiveStatus
+	updated.History = append(updated.History, FraudAudit{Action: "updated", By: user, At: updatedAt, Reason: updated.Reason, Changes: changes})
+	fraudAsBytes, _ = json.Marshal(updated)
+	err = stub.PutState(fraudId, fraudAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	err = advanceFraudListDate(stub, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"Fraud ID\" : \""+fraudId+"\", \"message\" : \"Fraud entry updated succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end update_fraud_entry")
+	return nil, nil
+}
+// ============================================================================================================================
+// remove_from_fraud_list - take an entry off the fraud list, the entry stays on the ledger with its history
+// ============================================================================================================================
+func (t *ManageAgreement) remove_from_fraud_list(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// remove_from_fraud_list("fraudID","reason","removedAt")
+	var err error
+	if len(args) != 3 {
 		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 3 arguments.\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
-		} 
+		}
 		return nil, nil
 	}
-	// set agreementId
-	agreementId := args[0]
-	bb_name := args[1]
-	sb_name := args[2]
-	//sign := args[2]
-	agreementAsBytes, err := stub.GetState(agreementId)									//get the Agreement for the specified agreementId from chaincode state
+	fmt.Println("start remove_from_fraud_list")
+	fraudId := args[0]
+	reason := args[1]
+	removedAt := args[2]
+	user, ok := complianceUser(stub)
+	if !ok {
+		errMsg := "{ \"Fraud ID\" : \""+fraudId+"\", \"message\" : \"Only a compliance user can change the fraud list\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if reason == "" || !isDate(removedAt) {
+		errMsg := "{ \"Fraud ID\" : \""+fraudId+"\", \"message\" : \"Expecting a reason and a YYYY-MM-DD removal date\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	fraudAsBytes, err := stub.GetState(fraudId)
 	if err != nil {
-		jsonResp = "{\"Error\":\"Failed to get state for " + agreementId + "\"}"
-		return nil, errors.New(jsonResp)
+		return nil, errors.New("Failed to get fraudID")
 	}
-	res := Agreement{}
-	json.Unmarshal(agreementAsBytes, &res)
-	if res.AgreementID == agreementId{
-		fmt.Println("Agreement found with agreementId : " + agreementId)
-		fmt.Println("res: "+res);
-		if res.BB_name == bb_name {
-			totalValue,err := strconv.Atoi(res.Total_Value)
-			if err != nil {
-				return nil, errors.New("Error while converting string 'Total_Value' to int ")
+	res := Fraud_list{}
+	json.Unmarshal(fraudAsBytes, &res)
+	if res.FraudID != fraudId || res.Status == FraudRemovedStatus {
+		errMsg := "{ \"message\" : \""+ fraudId+ " is not on the fraud list.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	res.Status = FraudRemovedStatus
+	res.History = append(res.History, FraudAudit{Action: "removed", By: user, At: removedAt, Reason: reason})
+	fraudAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(fraudId, fraudAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	err = advanceFraudListDate(stub, removedAt)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"Fraud ID\" : \""+fraudId+"\", \"message\" : \"Fraud ID removed succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end remove_from_fraud_list")
+	return nil, nil
+}
+// ============================================================================================================================
+// import_fraud_list - add many entries at once, each argument is a CSV row in the update_fraud_list argument order.
+// A header row starting with fraudId is skipped. Nothing is stored unless every row is valid.
+// ============================================================================================================================
+func (t *ManageAgreement) import_fraud_list(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start import_fraud_list")
+	if len(args) == 0 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting at least one CSV row.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	by, ok := complianceUser(stub)
+	if !ok {
+		errMsg := "{ \"message\" : \"Only a compliance user can change the fraud list\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	var frauds []Fraud_list
+	var existing []Fraud_list
+	seen := map[string]bool{}
+	for i, row := range args {
+		reader := csv.NewReader(strings.NewReader(row))
+		reader.TrimLeadingSpace = true
+		fields, err := reader.Read()
+		problem := ""
+		if err != nil {
+			problem = "cannot parse CSV"
+		} else if i == 0 && fields[0] == "fraudId" {
+			continue
+		} else if len(fields) != 7 {
+			problem = "expecting 7 fields"
+		}
+		var fraud, previous Fraud_list
+		if problem == "" {
+			fraud = fraudEntryFromRow(fields)
+			if seen[fraud.FraudID] {
+				problem = "duplicate fraudId " + fraud.FraudID
 			}
-			if (totalValue <= 10000 && res.Industry == "Books" || res.Industry == "Mobiles & Tablets"){
-					res.BuyerBank_sign = "true";
-					str = "Buyer Bank Signature"
+		}
+		if problem == "" {
+			previous, problem, err = checkFraudListing(stub, fraud)
+			if err != nil {
+				return nil, err
 			}
-		} else if res.SB_name == sb_name {
-			if (res.Industry == "Books" || res.Industry == "Mobiles & Tablets"){
-					res.SellerBank_sign = "true";
-					str = "Seller Bank Signature"
+		}
+		if problem != "" {
+			errMsg := "{ \"message\" : \"Row " + strconv.Itoa(i+1) + ": " + problem + "\", \"code\" : \"503\"}"
+			err = stub.SetEvent("errEvent", []byte(errMsg))
+			if err != nil {
+				return nil, err
 			}
+			return nil, nil
 		}
-	}else{
-		errMsg := "{ \"message\" : \""+ agreementId+ " Not Found.\", \"code\" : \"503\"}"
-		err = stub.SetEvent("errEvent", []byte(errMsg))
+		seen[fraud.FraudID] = true
+		frauds = append(frauds, fraud)
+		existing = append(existing, previous)
+	}
+	var newIds []string
+	for i, fraud := range frauds {
+		isNew, err := writeFraudListing(stub, fraud, existing[i], by)
 		if err != nil {
 			return nil, err
-		} 
-		return nil, nil
-	}*/
-	
-	//build the Agreement json string manually
-	/*input := 	`{`+
-		`"agreementId": "` + res.AgreementID + `" , `+
-		`"transId": "` + res.TransID + `" , `+ 
-		`"agreement_status": "` + res.Agreement_status + `" , `+ 
-		`"buyer_name": "` + res.BuyerName + `" , `+
-		`"seller_name": "` + res.SellerName + `" , `+
-		`"shipper_name": "` + res.ShipperName + `" , `+ 
-		`"bb_name": "` + res.BB_name + `" , `+ 
-		`"sb_name": "` + res.SB_name + `" , `+ 
-		`"agreementPortAuth_name": "` + res.PortAuthName + `" , `+ 
-		`"agreementCU_date": "` + res.AgreementCU_date + `" , `+ 
-		`"item_id": "` + res.ItemId + `" , `+ 
-		`"item_name": "` + res.Item_name + `" , `+ 
-		`"item_quantity": "` + res.Item_quantity + `" , `+ 
-		`"total_value": "` + res.Total_Value + `" , `+ 
-		`"delivery_date": "` + res.Delivery_date + `" , `+ 
-		`"extraCharges": "` + res.ExtraCharges + `" , `+ 
-		`"shipper_fees": "` + res.Shipper_fees + `" , `+ 
-		`"document_name": "` + res.DocumentName + `" , `+ 
-		`"document_url": "` + res.DocumentURL + `" , `+ 
-		`"tc_text" : "` + res.TC_Text + `" , `+ 
-		`"buyer_sign": "` + res.Buyer_sign + `" , `+ 
-		`"buyerBank_sign": "` + res.BuyerBank_sign + `" , `+ 
-		`"seller_sign": "` + res.Seller_sign + `" , `+ 
-		`"sellerBank_sign" : "` + res.SellerBank_sign + `" , `+ 
-		`"industry" : "` + res.Industry + `" , `+ 
-		`"goodsPrice" : "` + res.GoodsPrice + `" `+ 
-		`}`
-	fmt.Println("input: "+input)
-	err = stub.PutState(agreementId, []byte(input))									//store Agreement with id as key
+		}
+		if isNew {
+			newIds = append(newIds, fraud.FraudID)
+		}
+	}
+	err = appendFraudListIndex(stub, newIds)
 	if err != nil {
 		return nil, err
-	}*/
-	/*tosend := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"" + str + " updated succcessfully\", \"code\" : \"200\"}"
+	}
+	tosend := "{ \"message\" : \"" + strconv.Itoa(len(frauds)) + " Fraud IDs imported succcessfully\", \"code\" : \"200\"}"
 	err = stub.SetEvent("evtsender", []byte(tosend))
 	if err != nil {
 		return nil, err
-	} 
-	fmt.Println("end approve_agreement")
+	}
+	fmt.Println("end import_fraud_list")
 	return nil, nil
-}*/
+}
+// ============================================================================================================================
+// fraudEntryFromRow - build a fraud entry from the "fraudID","fraudName","category","reason","reportedBy","listedDate","expiryDate" fields
+// ============================================================================================================================
+func fraudEntryFromRow(row []string) Fraud_list {
+	return Fraud_list{
+		FraudID: strings.TrimSpace(row[0]),
+		FraudName: strings.TrimSpace(row[1]),
+		Category: strings.TrimSpace(row[2]),
+		Reason: strings.TrimSpace(row[3]),
+		ReportedBy: strings.TrimSpace(row[4]),
+		ListedDate: strings.TrimSpace(row[5]),
+		ExpiryDate: strings.TrimSpace(row[6]),
+	}
+}
+// ============================================================================================================================
+// validateFraudEntry - returns why a fraud entry cannot be stored, or "" when it is valid. A legacy entry, listed before
+// listing dates were recorded, is valid without a listedDate
+// ============================================================================================================================
+func validateFraudEntry(fraud Fraud_list, legacy bool) string {
+	if fraud.FraudID == "" || fraud.FraudName == "" {
+		return "fraudId and fraudName are required"
+	}
+	if !FraudCategories[fraud.Category] {
+		return "Unknown category " + fraud.Category
+	}
+	if fraud.Reason == "" || fraud.ReportedBy == "" {
+		return "reason and reportedBy are required"
+	}
+	if legacy && fraud.ListedDate == "" {
+		if fraud.ExpiryDate != "" && !isDate(fraud.ExpiryDate) {
+			return "expiryDate must be a YYYY-MM-DD date"
+		}
+		return ""
+	}
+	if !isDate(fraud.ListedDate) {
+		return "listedDate must be a YYYY-MM-DD date"
+	}
+	if fraud.ExpiryDate != "" && (!isDate(fraud.ExpiryDate) || fraud.ExpiryDate <= fraud.ListedDate) {
+		return "expiryDate must be a YYYY-MM-DD date after listedDate"
+	}
+	return ""
+}
+// ============================================================================================================================
+// checkFraudListing - validate a new listing, returns the removed entry it re-lists if there is one
+// ============================================================================================================================
+func checkFraudListing(stub shim.ChaincodeStubInterface, fraud Fraud_list) (Fraud_list, string, error) {
+	existing := Fraud_list{}
+	problem := validateFraudEntry(fraud, false)
+	if problem != "" {
+		return existing, problem, nil
+	}
+	fraudAsBytes, err := stub.GetState(fraud.FraudID)
+	if err != nil {
+		return existing, "", errors.New("Failed to get fraudID")
+	}
+	json.Unmarshal(fraudAsBytes, &existing)
+	if existing.FraudID == fraud.FraudID && existing.Status != FraudRemovedStatus {
+		return existing, "This Fraud ID is already listed, use update_fraud_entry to change it", nil
+	}
+	return existing, "", nil
+}
+// ============================================================================================================================
+// writeFraudListing - store a checked listing as Active, returns true when the Fraud ID is new to the index
+// ============================================================================================================================
+func writeFraudListing(stub shim.ChaincodeStubInterface, fraud Fraud_list, existing Fraud_list, by string) (bool, error) {
+	isNew := existing.FraudID != fraud.FraudID
+	action := "listed"
+	if !isNew {
+		action = "relisted"
+		fraud.History = existing.History
+	}
+	fraud.Status = FraudActiveStatus
+	fraud.History = append(fraud.History, FraudAudit{Action: action, By: by, At: fraud.ListedDate, Reason: fraud.Reason})
+	fraudAsBytes, _ := json.Marshal(fraud)
+	err := stub.PutState(fraud.FraudID, fraudAsBytes)									//store Fraud with fraudId as key
+	if err != nil {
+		return false, err
+	}
+	return isNew, advanceFraudListDate(stub, fraud.ListedDate)
+}
+// ============================================================================================================================
+// appendFraudListIndex - add Fraud IDs to the fraud list index
+// ============================================================================================================================
+func appendFraudListIndex(stub shim.ChaincodeStubInterface, fraudIds []string) error {
+	if len(fraudIds) == 0 {
+		return nil
+	}
+	fraudListIndexAsBytes, err := stub.GetState(FraudListIndexStr)
+	if err != nil {
+		return errors.New("Failed to get Fraud List index")
+	}
+	var fraudListIndex []string
+	json.Unmarshal(fraudListIndexAsBytes, &fraudListIndex)							//un stringify it aka JSON.parse()
+	fraudListIndex = append(fraudListIndex, fraudIds...)
+	fmt.Println("! fraud List index after appending: ", fraudListIndex)
+	jsonAsBytes, _ := json.Marshal(fraudListIndex)
+	return stub.PutState(FraudListIndexStr, jsonAsBytes)
+}
+// ============================================================================================================================
+// set_fraud_list_date - compliance moves the date the fraud list is screened as of, so entries expired by then are left out
+// ============================================================================================================================
+func (t *ManageAgreement) set_fraud_list_date(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// set_fraud_list_date("asOfDate")
+	var err error
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"asOfDate\" as an argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	fmt.Println("start set_fraud_list_date")
+	asOf := args[0]
+	current, err := fraudListDate(stub)
+	if err != nil {
+		return nil, err
+	}
+	problem := ""
+	if _, ok := complianceUser(stub); !ok {
+		problem = "Only a compliance user can change the fraud list"
+	} else if !isDate(asOf) {
+		problem = "asOfDate must be a YYYY-MM-DD date"
+	} else if asOf < current {
+		problem = "The fraud list is already screened as of " + current
+	}
+	if problem != "" {
+		errMsg := "{ \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	err = advanceFraudListDate(stub, asOf)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"message\" : \"Fraud list screened as of " + asOf + "\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end set_fraud_list_date")
+	return nil, nil
+}
+// ============================================================================================================================
+// fraudListDate - date the fraud list is screened as of, "" until compliance has changed the list
+// ============================================================================================================================
+func fraudListDate(stub shim.ChaincodeStubInterface) (string, error) {
+	dateAsBytes, err := stub.GetState(FraudListDateStr)
+	if err != nil {
+		return "", errors.New("Failed to get the fraud list date")
+	}
+	return string(dateAsBytes), nil
+}
+// ============================================================================================================================
+// advanceFraudListDate - move the fraud list date forward to the given date, an earlier date leaves it as it is
+// ============================================================================================================================
+func advanceFraudListDate(stub shim.ChaincodeStubInterface, date string) error {
+	current, err := fraudListDate(stub)
+	if err != nil {
+		return err
+	}
+	if !isDate(date) || date <= current {
+		return nil
+	}
+	return stub.PutState(FraudListDateStr, []byte(date))
+}
+// ============================================================================================================================
+// fraudEntryActive - whether an entry is screened on the given date, an empty date only leaves out removed entries
+// ============================================================================================================================
+func fraudEntryActive(fraud Fraud_list, asOf string) bool {
+	if fraud.Status == FraudRemovedStatus {
+		return false
+	}
+	if fraud.ExpiryDate == "" || len(asOf) < len(DateLayout) {
+		return true
+	}
+	return asOf[:len(DateLayout)] <= fraud.ExpiryDate
+}
+// ============================================================================================================================
+// txDate - the YYYY-MM-DD date of the transaction timestamp, the latest date a client can act as of
+// ============================================================================================================================
+func txDate(stub shim.ChaincodeStubInterface) (string, error) {
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return "", errors.New("Failed to get the transaction timestamp")
+	}
+	return time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).UTC().Format(DateLayout), nil
+}
+// ============================================================================================================================
+// isDate - whether a value is a YYYY-MM-DD date
+// ============================================================================================================================
+func isDate(value string) bool {
+	_, err := time.Parse(DateLayout, value)
+	return err == nil
+}
+// ============================================================================================================================
+// includeArchived - whether the argument at position n asks a list query for archived records too
+// ============================================================================================================================
+func includeArchived(args []string, n int) bool {
+	return len(args) > n && args[n] == IncludeArchived
+}
+// ============================================================================================================================
+// callerName - username attribute of the caller's certificate, "" when it has none
+// ============================================================================================================================
+func callerName(stub shim.ChaincodeStubInterface) string {
+	username, err := stub.ReadCertAttribute("username")
+	if err != nil {
+		return ""
+	}
+	return string(username)
+}
+// ============================================================================================================================
+// sign_agreement - sign an Agreement as seller, buyer bank, seller bank or buyer, in that order
+// ============================================================================================================================
+func (t *ManageAgreement) sign_agreement(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// sign_agreement("agreementId", "role", "signedAt")
+	var err error
+	fmt.Println("start sign_agreement")
+	if len(args) != 3 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 3 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	agreementId := args[0]
+	role := args[1]
+	signedAt := args[2]
+	agreementAsBytes, err := stub.GetState(agreementId)
+	if err != nil {
+		return nil, errors.New("{\"Error\":\"Failed to get state for " + agreementId + "\"}")
+	}
+	res := Agreement{}
+	json.Unmarshal(agreementAsBytes, &res)
+	if res.AgreementID != agreementId {
+		errMsg := "{ \"message\" : \""+ agreementId+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if res.Archive != nil {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Agreement is archived\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	if res.Agreement_status == HeldScreeningStatus || res.Agreement_status == ConfirmedFraudStatus {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Agreement cannot be signed, status is " + res.Agreement_status + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	party, ok := partyForRole(res, role)
+	if !ok {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Unknown role " + role + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	// the caller's certificate must belong to the party holding the role
+	caller, err := stub.ReadCertAttribute("username")
+	if err != nil || string(caller) != party {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Caller is not the " + role + " of this Agreement\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	next := nextSigner(res)
+	if next != role {
+		msg := "Agreement is already fully signed"
+		if next != "" {
+			msg = "Waiting for the " + next + " to sign first"
+		}
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"" + msg + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	addSignature(&res, role, party, signedAt)
+	advanceAgreementStatus(&res)
+	agreementAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(agreementId, agreementAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Agreement signed by " + role + ", status is " + res.Agreement_status + "\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end sign_agreement")
+	return nil, nil
+}
+// ============================================================================================================================
+// attach_document - attach the SHA-256 hash of a trade document to an Agreement
+// ============================================================================================================================
+func (t *ManageAgreement) attach_document(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// attach_document("agreementId", "docType", "name", "url", "sha256", "attachedBy")
+	var err error
+	fmt.Println("start attach_document")
+	if len(args) != 6 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 6 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	agreementId := args[0]
+	document := Document{DocType: args[1], Name: args[2], URL: args[3], Hash: strings.ToLower(args[4]), AttachedBy: args[5]}
+	if !DocumentTypes[document.DocType] {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Unknown document type " + document.DocType + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if !isSHA256(document.Hash) {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Document hash must be a hex encoded SHA-256\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	agreementAsBytes, err := stub.GetState(agreementId)
+	if err != nil {
+		return nil, errors.New("{\"Error\":\"Failed to get state for " + agreementId + "\"}")
+	}
+	res := Agreement{}
+	json.Unmarshal(agreementAsBytes, &res)
+	if res.AgreementID != agreementId {
+		errMsg := "{ \"message\" : \""+ agreementId+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if res.Archive != nil {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Agreement is archived\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	// signatures cover the documents, so the set is frozen once anybody has signed
+	if len(res.Signatures) > 0 {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Documents cannot change once signing has started\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	replaced := false
+	for i := range res.Documents {
+		if res.Documents[i].DocType == document.DocType {
+			res.Documents[i] = document
+			replaced = true
+		}
+	}
+	if !replaced {
+		res.Documents = append(res.Documents, document)
+	}
+	agreementAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(agreementId, agreementAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"" + document.DocType + " attached succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end attach_document")
+	return nil, nil
+}
+// ============================================================================================================================
+// verify_document - check whether a document hash matches a document of the Agreement and who signed over it
+// ============================================================================================================================
+func (t *ManageAgreement) verify_document(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// verify_document("agreementId", "sha256")
+	var err error
+	fmt.Println("start verify_document")
+	if len(args) != 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"agreementID\" and \"documentHash\" as arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	agreementId := args[0]
+	hash := strings.ToLower(args[1])
+	agreementAsBytes, err := stub.GetState(agreementId)
+	if err != nil {
+		return nil, errors.New("{\"Error\":\"Failed to get state for " + agreementId + "\"}")
+	}
+	res := Agreement{}
+	json.Unmarshal(agreementAsBytes, &res)
+	if res.AgreementID != agreementId {
+		errMsg := "{ \"message\" : \""+ agreementId+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	result := struct{
+		AgreementID string `json:"agreementId"`
+		DocumentHash string `json:"documentHash"`
+		Matches bool `json:"matches"`
+		Document *Document `json:"document,omitempty"`
+		SignedBy []string `json:"signedBy"`			// roles whose signature covers the current documents
+	}{AgreementID: agreementId, DocumentHash: hash, SignedBy: []string{}}
+	for i := range res.Documents {
+		if res.Documents[i].Hash == hash {
+			result.Matches = true
+			result.Document = &res.Documents[i]
+		}
+	}
+	if result.Matches {
+		digest := documentsDigest(res)
+		for _, signature := range res.Signatures {
+			if signature.DocumentsHash == digest {
+				result.SignedBy = append(result.SignedBy, signature.Role)
+			}
+		}
+	}
+	jsonResp, _ := json.Marshal(result)
+	fmt.Println("end verify_document")
+	return jsonResp, nil
+}
+// ============================================================================================================================
+// documentsDigest - SHA-256 over the sorted "docType:hash" pairs of the Agreement's documents
+// ============================================================================================================================
+func documentsDigest(agreement Agreement) string {
+	var entries []string
+	for _, document := range agreement.Documents {
+		entries = append(entries, document.DocType + ":" + document.Hash)
+	}
+	sort.Strings(entries)
+	digest := sha256.Sum256([]byte(agreement.AgreementID + "|" + strings.Join(entries, "|")))
+	return hex.EncodeToString(digest[:])
+}
+// ============================================================================================================================
+// isSHA256 - true for a 64 character hex string
+// ============================================================================================================================
+func isSHA256(hash string) bool {
+	decoded, err := hex.DecodeString(hash)
+	return err == nil && len(decoded) == sha256.Size
+}
+// ============================================================================================================================
+// review_screening - a compliance user clears or confirms the fraud list hit that holds an Agreement
+// ============================================================================================================================
+func (t *ManageAgreement) review_screening(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// review_screening("agreementId", "clear|confirm", "reason", "reviewedAt")
+	var err error
+	fmt.Println("start review_screening")
+	if len(args) != 4 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 4 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	agreementId := args[0]
+	decision := args[1]
+	reason := args[2]
+	reviewedAt := args[3]
+	reviewer, ok := complianceUser(stub)
+	if !ok {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Only a compliance user can review a screening hit\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if (decision != "clear" && decision != "confirm") || reason == "" {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Expecting decision clear or confirm with a reason\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	agreementAsBytes, err := stub.GetState(agreementId)
+	if err != nil {
+		return nil, errors.New("{\"Error\":\"Failed to get state for " + agreementId + "\"}")
+	}
+	res := Agreement{}
+	json.Unmarshal(agreementAsBytes, &res)
+	if res.AgreementID != agreementId || res.Screening == nil || res.Agreement_status != HeldScreeningStatus {
+		errMsg := "{ \"message\" : \""+ agreementId+ " is not held for screening.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if decision == "clear" {
+		res.Screening.Status = "Cleared"
+		res.Agreement_status = res.Screening.PreviousStatus
+	} else {
+		res.Screening.Status = "Confirmed"
+		res.Agreement_status = ConfirmedFraudStatus
+	}
+	res.Screening.Reason = reason
+	res.Screening.ReviewedBy = reviewer
+	res.Screening.ReviewedAt = reviewedAt
+	agreementAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(agreementId, agreementAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Screening hit " + strings.ToLower(res.Screening.Status) + ", status is " + res.Agreement_status + "\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end review_screening")
+	return nil, nil
+}
+// ============================================================================================================================
+// screen_parties - screen "role", "name" pairs against the fraud list, used by the PO and Payment chaincodes.
+// Entries are screened as of the fraud list date, a trailing date argument from older callers is ignored.
+// ============================================================================================================================
+func (t *ManageAgreement) screen_parties(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start screen_parties")
+	if len(args) < 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"role\", \"name\" pairs.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if len(args) % 2 != 0 {
+		args = args[:len(args)-1]
+	}
+	hits, err := screenParties(stub, args)
+	if err != nil {
+		return nil, err
+	}
+	if hits == nil {
+		hits = []ScreeningHit{}
+	}
+	jsonResp, _ := json.Marshal(hits)
+	fmt.Println("end screen_parties")
+	return jsonResp, nil
+}
+// ============================================================================================================================
+// screenParties - match every "role", "name" pair against every entry of the fraud list active on the fraud list date
+// ============================================================================================================================
+func screenParties(stub shim.ChaincodeStubInterface, parties []string) ([]ScreeningHit, error) {
+	var fraudListIndex []string
+	var hits []ScreeningHit
+	asOf, err := fraudListDate(stub)
+	if err != nil {
+		return nil, err
+	}
+	fraudListAsBytes, err := stub.GetState(FraudListIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Fraud List index")
+	}
+	json.Unmarshal(fraudListAsBytes, &fraudListIndex)
+	for _, fraudId := range fraudListIndex {
+		valueAsBytes, err := stub.GetState(fraudId)
+		if err != nil {
+			return nil, errors.New("Failed to get state for " + fraudId)
+		}
+		fraud := Fraud_list{}
+		json.Unmarshal(valueAsBytes, &fraud)
+		if !fraudEntryActive(fraud, asOf) {
+			continue
+		}
+		for i := 0; i+1 < len(parties); i += 2 {
+			if parties[i+1] == "" {
+				continue
+			}
+			matchType, score := matchNames(parties[i+1], fraud.FraudName)
+			if matchType != "" {
+				hits = append(hits, ScreeningHit{parties[i], parties[i+1], fraud.FraudID, fraud.FraudName, matchType, strconv.FormatFloat(score, 'f', 2, 64)})
+			}
+		}
+	}
+	return hits, nil
+}
+// ============================================================================================================================
+// matchNames - compare two names after normalising them, returns the kind of match ("" for none) and a 0 to 1 score
+// ============================================================================================================================
+func matchNames(name string, listed string) (string, float64) {
+	a := normaliseName(name)
+	b := normaliseName(listed)
+	if a == "" || b == "" {
+		return "", 0
+	}
+	if a == b {
+		return "exact", 1
+	}
+	tokensA := strings.Fields(a)
+	tokensB := strings.Fields(b)
+	sort.Strings(tokensA)
+	sort.Strings(tokensB)
+	if strings.Join(tokensA, " ") == strings.Join(tokensB, " ") {
+		return "token", 1
+	}
+	ra := []rune(a)
+	rb := []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	score := 1 - float64(levenshtein(ra, rb)) / float64(longest)
+	if score >= FuzzyMatchThreshold {
+		return "fuzzy", score
+	}
+	return "", score
+}
+// ============================================================================================================================
+// normaliseName - lower case, punctuation removed, corporate suffixes dropped, single spaced
+// ============================================================================================================================
+func normaliseName(name string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, name)
+	var words []string
+	for _, word := range strings.Fields(cleaned) {
+		if !NameNoiseWords[word] {
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " ")
+}
+// ============================================================================================================================
+// levenshtein - edit distance between two strings
+// ============================================================================================================================
+func levenshtein(a []rune, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j] + 1
+			if current[j-1] + 1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+			if previous[j-1] + cost < current[j] {
+				current[j] = previous[j-1] + cost
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+// ============================================================================================================================
+// complianceUser - name of the caller when its certificate carries the compliance role
+// ============================================================================================================================
+func complianceUser(stub shim.ChaincodeStubInterface) (string, bool) {
+	role, err := stub.ReadCertAttribute("role")
+	if err != nil || string(role) != "compliance" {
+		return "", false
+	}
+	username, err := stub.ReadCertAttribute("username")
+	if err != nil {
+		return "", false
+	}
+	return string(username), true
+}
+// ============================================================================================================================
+// partyForRole - name of the party holding a signing role on the Agreement
+// ============================================================================================================================
+func partyForRole(agreement Agreement, role string) (string, bool) {
+	switch role {
+	case "seller":
+		return agreement.SellerName, true
+	case "buyerBank":
+		return agreement.BB_name, true
+	case "sellerBank":
+		return agreement.SB_name, true
+	case "buyer":
+		return agreement.BuyerName, true
+	}
+	return "", false
+}
+// ============================================================================================================================
+// signFlag - the "true"/"false" signature field for a role
+// ============================================================================================================================
+func signFlag(agreement *Agreement, role string) *string {
+	switch role {
+	case "seller":
+		return &agreement.Seller_sign
+	case "buyerBank":
+		return &agreement.BuyerBank_sign
+	case "sellerBank":
+		return &agreement.SellerBank_sign
+	case "buyer":
+		return &agreement.Buyer_sign
+	}
+	return nil
+}
+// ============================================================================================================================
+// nextSigner - first role in SigningOrder that has not signed yet, "" once everybody has signed
+// ============================================================================================================================
+func nextSigner(agreement Agreement) string {
+	for _, role := range SigningOrder {
+		if *signFlag(&agreement, role) != "true" {
+			return role
+		}
+	}
+	return ""
+}
+// ============================================================================================================================
+// addSignature - record a signature for a role, a role signs only once
+// ============================================================================================================================
+func addSignature(agreement *Agreement, role string, party string, signedAt string) {
+	flag := signFlag(agreement, role)
+	if *flag == "true" {
+		return
+	}
+	*flag = "true"
+	agreement.Signatures = append(agreement.Signatures, Signature{Role: role, Party: party, SignedAt: signedAt, DocumentsHash: documentsDigest(*agreement)})
+}
+// ============================================================================================================================
+// advanceAgreementStatus - move Agreement_status to the last step of SigningOrder completed without gaps
+// ============================================================================================================================
+func advanceAgreementStatus(agreement *Agreement) {
+	for _, role := range SigningOrder {
+		if *signFlag(agreement, role) != "true" {
+			return
+		}
+		agreement.Agreement_status = SignedStatus[role]
+	}
+}
+// ============================================================================================================================
+// checkAgreementTotal - Total_Value must match the sum of the line items (including tax) copied from the PO
+// ============================================================================================================================
+func checkAgreementTotal(agreement Agreement) error {
+	if len(agreement.Line_items) == 0 {
+		return nil
+	}
+	var linesTotal float64
+	for _, line := range agreement.Line_items {
+		lineTotal, err := strconv.ParseFloat(line.Line_total, 64)
+		if err != nil {
+			return errors.New("Invalid line total on line " + line.LineNo)
+		}
+		taxAmount, _ := strconv.ParseFloat(line.Tax_amount, 64)
+		linesTotal += lineTotal + taxAmount
+	}
+	totalValue, err := strconv.ParseFloat(agreement.Total_Value, 64)
+	if err != nil {
+		return errors.New("Error while converting string 'total_value' to float")
+	}
+	if math.Abs(totalValue - linesTotal) >= 0.005 {
+		return errors.New("total_value " + agreement.Total_Value + " does not match the PO line items total " + strconv.FormatFloat(linesTotal, 'f', 2, 64))
+	}
+	return nil
+}
+/*func (t *ManageAgreement) approve_agreement(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	/*var jsonResp , str string
+	var err error
+	fmt.Println("start approve_agreement")
+	if len(args) != 3{
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 3 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set agreementId
+	agreementId := args[0]
+	bb_name := args[1]
+	sb_name := args[2]
+	//sign := args[2]
+	agreementAsBytes, err := stub.GetState(agreementId)									//get the Agreement for the specified agreementId from chaincode state
+	if err != nil {
+		jsonResp = "{\"Error\":\"Failed to get state for " + agreementId + "\"}"
+		return nil, errors.New(jsonResp)
+	}
+	res := Agreement{}
+	json.Unmarshal(agreementAsBytes, &res)
+	if res.AgreementID == agreementId{
+		fmt.Println("Agreement found with agreementId : " + agreementId)
+		fmt.Println("res: "+res);
+		if res.BB_name == bb_name {
+			totalValue,err := strconv.Atoi(res.Total_Value)
+			if err != nil {
+				return nil, errors.New("Error while converting string 'Total_Value' to int ")
+			}
+			if (totalValue <= 10000 && res.Industry == "Books" || res.Industry == "Mobiles & Tablets"){
+					res.BuyerBank_sign = "true";
+					str = "Buyer Bank Signature"
+			}
+		} else if res.SB_name == sb_name {
+			if (res.Industry == "Books" || res.Industry == "Mobiles & Tablets"){
+					res.SellerBank_sign = "true";
+					str = "Seller Bank Signature"
+			}
+		}
+	}else{
+		errMsg := "{ \"message\" : \""+ agreementId+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}*/
+	
+	//build the Agreement json string manually
+	/*input := 	`{`+
+		`"agreementId": "` + res.AgreementID + `" , `+
+		`"transId": "` + res.TransID + `" , `+ 
+		`"agreement_status": "` + res.Agreement_status + `" , `+ 
+		`"buyer_name": "` + res.BuyerName + `" , `+
+		`"seller_name": "` + res.SellerName + `" , `+
+		`"shipper_name": "` + res.ShipperName + `" , `+ 
+		`"bb_name": "` + res.BB_name + `" , `+ 
+		`"sb_name": "` + res.SB_name + `" , `+ 
+		`"agreementPortAuth_name": "` + res.PortAuthName + `" , `+ 
+		`"agreementCU_date": "` + res.AgreementCU_date + `" , `+ 
+		`"item_id": "` + res.ItemId + `" , `+ 
+		`"item_name": "` + res.Item_name + `" , `+ 
+		`"item_quantity": "` + res.Item_quantity + `" , `+ 
+		`"total_value": "` + res.Total_Value + `" , `+ 
+		`"delivery_date": "` + res.Delivery_date + `" , `+ 
+		`"extraCharges": "` + res.ExtraCharges + `" , `+ 
+		`"shipper_fees": "` + res.Shipper_fees + `" , `+ 
+		`"document_name": "` + res.DocumentName + `" , `+ 
+		`"document_url": "` + res.DocumentURL + `" , `+ 
+		`"tc_text" : "` + res.TC_Text + `" , `+ 
+		`"buyer_sign": "` + res.Buyer_sign + `" , `+ 
+		`"buyerBank_sign": "` + res.BuyerBank_sign + `" , `+ 
+		`"seller_sign": "` + res.Seller_sign + `" , `+ 
+		`"sellerBank_sign" : "` + res.SellerBank_sign + `" , `+ 
+		`"industry" : "` + res.Industry + `" , `+ 
+		`"goodsPrice" : "` + res.GoodsPrice + `" `+ 
+		`}`
+	fmt.Println("input: "+input)
+	err = stub.PutState(agreementId, []byte(input))									//store Agreement with id as key
+	if err != nil {
+		return nil, err
+	}*/
+	/*tosend := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"" + str + " updated succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+	fmt.Println("end approve_agreement")
+	return nil, nil
+}*/
+// ============================================================================================================================
+// post_milestone - the shipper or port authority records the next shipment milestone of a signed Agreement
+// ============================================================================================================================
+func (t *ManageAgreement) post_milestone(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// post_milestone("agreementId", "milestone", "at", "location", "remarks")
+	var err error
+	fmt.Println("start post_milestone")
+	if len(args) != 5 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 5 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	agreementId := args[0]
+	milestone := args[1]
+	at := args[2]
+	agreementAsBytes, err := stub.GetState(agreementId)
+	if err != nil {
+		return nil, errors.New("{\"Error\":\"Failed to get state for " + agreementId + "\"}")
+	}
+	res := Agreement{}
+	json.Unmarshal(agreementAsBytes, &res)
+	if res.AgreementID != agreementId {
+		errMsg := "{ \"message\" : \""+ agreementId+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if res.Archive != nil {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Agreement is archived\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if res.Shipment == nil {
+		res.Shipment = &Shipment{}
+	}
+	next := nextMilestone(*res.Shipment)
+	role := MilestoneRoles[milestone]
+	party := res.ShipperName
+	if role == "portAuthority" {
+		party = res.PortAuthName
+	}
+	problem := ""
+	if res.Agreement_status == HeldScreeningStatus || res.Agreement_status == ConfirmedFraudStatus {
+		problem = "Shipment cannot be tracked, status is " + res.Agreement_status
+	} else if nextSigner(res) != "" {
+		problem = "Agreement is not signed by all parties"
+	} else if role == "" {
+		problem = "Unknown milestone " + milestone
+	} else if next != milestone {
+		problem = "Expecting milestone " + next
+		if next == "" {
+			problem = "Shipment is already delivered"
+		}
+	} else if !isDate(at) {
+		problem = "Expecting a YYYY-MM-DD date"
+	} else if len(res.Shipment.Milestones) > 0 && at < res.Shipment.Milestones[len(res.Shipment.Milestones)-1].At {
+		problem = "Milestone date is before the previous milestone"
+	}
+	caller, err := stub.ReadCertAttribute("username")
+	if problem == "" && (err != nil || string(caller) != party) {
+		problem = "Only the " + role + " of this Agreement can post " + milestone
+	}
+	if problem != "" {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	res.Shipment.Milestones = append(res.Shipment.Milestones, Milestone{milestone, role, party, at, args[3], args[4]})
+	res.Shipment.Status = milestone
+	newlyLate := markLateDelivery(&res, at)
+	agreementAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(agreementId, agreementAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	if newlyLate {
+		err = stub.SetEvent("lateDelivery", []byte(lateDeliveryMessage(res)))
+	} else {
+		tosend := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Milestone " + milestone + " posted succcessfully\", \"code\" : \"200\"}"
+		err = stub.SetEvent("evtsender", []byte(tosend))
+	}
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end post_milestone")
+	return nil, nil
+}
+// ============================================================================================================================
+// check_delivery - a party or bank of the Agreement marks an undelivered shipment late once asOf is past the Delivery_date,
+// raises the lateDelivery event. asOf can't be after the transaction date
+// ============================================================================================================================
+func (t *ManageAgreement) check_delivery(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// check_delivery("agreementId", "asOf")
+	var err error
+	fmt.Println("start check_delivery")
+	if len(args) != 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 2 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	agreementId := args[0]
+	asOf := args[1]
+	agreementAsBytes, err := stub.GetState(agreementId)
+	if err != nil {
+		return nil, errors.New("{\"Error\":\"Failed to get state for " + agreementId + "\"}")
+	}
+	res := Agreement{}
+	json.Unmarshal(agreementAsBytes, &res)
+	if res.AgreementID != agreementId || !isDate(asOf) {
+		errMsg := "{ \"message\" : \""+ agreementId+ " Not Found or date is not YYYY-MM-DD.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	today, err := txDate(stub)
+	if err != nil {
+		return nil, err
+	}
+	caller := callerName(stub)
+	problem := ""
+	if caller == "" || (caller != res.BuyerName && caller != res.SellerName && caller != res.ShipperName &&
+		caller != res.BB_name && caller != res.SB_name && caller != res.PortAuthName) {
+		problem = "Only a party or a bank of the Agreement can check its delivery"
+	} else if asOf > today {
+		problem = "asOf can't be after the transaction date " + today
+	}
+	if problem != "" {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if res.Shipment == nil {
+		res.Shipment = &Shipment{}
+	}
+	if res.Shipment.Status == "delivered" || !markLateDelivery(&res, asOf) {
+		msg := "Delivery is not late"
+		if res.Shipment.Late {
+			msg = "Delivery was already flagged late on " + res.Shipment.LateSince
+		}
+		tosend := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"" + msg + "\", \"code\" : \"200\"}"
+		err = stub.SetEvent("evtsender", []byte(tosend))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	agreementAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(agreementId, agreementAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	err = stub.SetEvent("lateDelivery", []byte(lateDeliveryMessage(res)))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end check_delivery")
+	return nil, nil
+}
+// ============================================================================================================================
+// get_shipment - get the shipment milestones of an Agreement
+// ============================================================================================================================
+func (t *ManageAgreement) get_shipment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start get_shipment")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"AgreementID\" as an argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	agreementId := args[0]
+	agreementAsBytes, err := stub.GetState(agreementId)
+	if err != nil {
+		return nil, errors.New("{\"Error\":\"Failed to get state for " + agreementId + "\"}")
+	}
+	res := Agreement{}
+	json.Unmarshal(agreementAsBytes, &res)
+	if res.AgreementID != agreementId {
+		errMsg := "{ \"message\" : \""+ agreementId+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if res.Shipment == nil {
+		res.Shipment = &Shipment{Milestones: []Milestone{}}
+	}
+	shipmentAsBytes, _ := json.Marshal(res.Shipment)
+	fmt.Println("end get_shipment")
+	return shipmentAsBytes, nil
+}
+// ============================================================================================================================
+// nextMilestone - the milestone the shipment is waiting for, "" once it is delivered
+// ============================================================================================================================
+func nextMilestone(shipment Shipment) string {
+	if len(shipment.Milestones) < len(ShipmentMilestones) {
+		return ShipmentMilestones[len(shipment.Milestones)]
+	}
+	return ""
+}
+// ============================================================================================================================
+// markLateDelivery - flag the shipment late when asOf is past a YYYY-MM-DD Delivery_date, returns true the first time only
+// ============================================================================================================================
+func markLateDelivery(agreement *Agreement, asOf string) bool {
+	if agreement.Shipment.Late || !isDate(agreement.Delivery_date) || asOf <= agreement.Delivery_date {
+		return false
+	}
+	agreement.Shipment.Late = true
+	agreement.Shipment.LateSince = asOf
+	return true
+}
+// ============================================================================================================================
+// lateDeliveryMessage - payload of the lateDelivery event
+// ============================================================================================================================
+func lateDeliveryMessage(agreement Agreement) string {
+	return "{ \"agreementID\" : \""+agreement.AgreementID+"\", \"transID\" : \""+agreement.TransID+"\", \"deliveryDate\" : \""+agreement.Delivery_date+"\", " +
+		"\"lateSince\" : \""+agreement.Shipment.LateSince+"\", \"milestone\" : \""+agreement.Shipment.Status+"\", \"message\" : \"Delivery is late\", \"code\" : \"200\"}"
+}
+// ============================================================================================================================
+// set_instalments - set the instalment schedule of an Agreement, given as a JSON array of percent and trigger lines
+// ============================================================================================================================
+func (t *ManageAgreement) set_instalments(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// set_instalments("agreementId", "[{\"description\":\"On shipment\",\"percent\":\"30\",\"trigger\":\"picked_up\"}, ...]")
+	var err error
+	fmt.Println("start set_instalments")
+	if len(args) != 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 2 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	agreementId := args[0]
+	agreementAsBytes, err := stub.GetState(agreementId)
+	if err != nil {
+		return nil, errors.New("{\"Error\":\"Failed to get state for " + agreementId + "\"}")
+	}
+	res := Agreement{}
+	json.Unmarshal(agreementAsBytes, &res)
+	var instalments []Instalment
+	problem := ""
+	if res.AgreementID != agreementId {
+		problem = agreementId + " Not Found."
+	} else if res.Archive != nil {
+		problem = "Agreement is archived"
+	} else if len(res.Payments) > 0 {
+		problem = "The schedule cannot change once payments are recorded"
+	} else if err = json.Unmarshal([]byte(args[1]), &instalments); err != nil || len(instalments) == 0 {
+		problem = "Expecting a JSON array of instalments"
+	} else {
+		problem = checkInstalments(instalments)
+	}
+	if problem != "" {
+		errMsg := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	for i := range instalments {
+		instalments[i].LineNo = strconv.Itoa(i + 1)
+	}
+	res.Instalments = instalments
+	agreementAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(agreementId, agreementAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"agreementID\" : \""+agreementId+"\", \"message\" : \"Instalment schedule set succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end set_instalments")
+	return nil, nil
+}
+// ============================================================================================================================
+// record_instalment_payment - record a settled Payment against an instalment line ("" when there is no schedule).
+// Called by the Payment chaincode when a bank of the Agreement settles, a refused payment returns an error so the
+// settlement is rolled back with it. The Payment is read back from the registered Payment chaincode.
+// ============================================================================================================================
+func (t *ManageAgreement) record_instalment_payment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// record_instalment_payment("agreementId", "lineNo", "paymentId", "amount", "paidAt")
+	var err error
+	fmt.Println("start record_instalment_payment")
+	if len(args) != 5 {
+		return nil, errors.New("Incorrect number of arguments. Expecting 5 arguments.")
+	}
+	agreementId := args[0]
+	lineNo := args[1]
+	paymentId := args[2]
+	agreementAsBytes, err := stub.GetState(agreementId)
+	if err != nil {
+		return nil, errors.New("{\"Error\":\"Failed to get state for " + agreementId + "\"}")
+	}
+	res := Agreement{}
+	json.Unmarshal(agreementAsBytes, &res)
+	if res.AgreementID != agreementId {
+		return nil, errors.New("Agreement " + agreementId + " Not Found")
+	}
+	if !callerIsBank(stub, res) {
+		return nil, errors.New("Only a bank of Agreement " + agreementId + " can record a Payment")
+	}
+	for _, payment := range res.Payments {
+		if payment.PaymentID == paymentId && !payment.Reversed {
+			fmt.Println("Payment " + paymentId + " is already recorded")
+			return nil, nil
+		}
+	}
+	cents, ok := toCents(args[3])
+	if !ok || cents <= 0 {
+		return nil, errors.New("Payment amount must be a positive amount")
+	}
+	payment, err := getPayment(stub, paymentId)
+	if err != nil {
+		return nil, err
+	}
+	amount, _ := toCents(payment.AmountTransferred)
+	if payment.PaymentID != paymentId || payment.AgreementID != agreementId || payment.InstalmentNo != lineNo ||
+		payment.PaymentStatus != PaymentSettledStatus || amount != cents {
+		return nil, errors.New("Payment " + paymentId + " is not a settled payment of " + args[3] + " on Agreement " + agreementId)
+	}
+	problem := checkInstalmentPayment(res, lineNo, cents)
+	if problem != "" {
+		return nil, errors.New(problem)
+	}
+	res.Payments = append(res.Payments, AgreementPayment{PaymentID: paymentId, LineNo: lineNo, Amount: formatCents(cents), PaidAt: args[4]})
+	balance := agreementBalance(res)
+	if balance.Outstanding == formatCents(0) {
+		res.Agreement_status = PaidStatus
+	}
+	agreementAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(agreementId, agreementAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"agreementID\" : \""+agreementId+"\", \"paymentID\" : \""+paymentId+"\", \"message\" : \"Payment recorded, outstanding " + balance.Outstanding + "\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end record_instalment_payment")
+	return nil, nil
+}
+// ============================================================================================================================
+// reverse_instalment_payment - take a refunded Payment back out of the amount paid. Called by the Payment chaincode when
+// a bank of the Agreement refunds, the refund is read back from the registered Payment chaincode.
+// ============================================================================================================================
+func (t *ManageAgreement) reverse_instalment_payment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// reverse_instalment_payment("agreementId", "paymentId", "reversedAt"[, "amount"])
+	var err error
+	fmt.Println("start reverse_instalment_payment")
+	if len(args) != 3 && len(args) != 4 {
+		return nil, errors.New("Incorrect number of arguments. Expecting 3 or 4 arguments.")
+	}
+	agreementId := args[0]
+	paymentId := args[1]
+	// without an amount the whole Payment is reversed
+	var amount int64
+	if len(args) == 4 {
+		var ok bool
+		amount, ok = toCents(args[3])
+		if !ok || amount <= 0 {
+			return nil, errors.New("Reversed amount must be a positive amount")
+		}
+	}
+	agreementAsBytes, err := stub.GetState(agreementId)
+	if err != nil {
+		return nil, errors.New("{\"Error\":\"Failed to get state for " + agreementId + "\"}")
+	}
+	res := Agreement{}
+	json.Unmarshal(agreementAsBytes, &res)
+	if res.AgreementID != agreementId || !callerIsBank(stub, res) {
+		return nil, errors.New("Only a bank of Agreement " + agreementId + " can reverse a Payment")
+	}
+	payment, err := getPayment(stub, paymentId)
+	if err != nil {
+		return nil, err
+	}
+	if payment.PaymentID != paymentId || payment.AgreementID != agreementId {
+		return nil, errors.New("Payment " + paymentId + " is not a payment of Agreement " + agreementId)
+	}
+	paymentRefunded, _ := toCents(payment.RefundedAmount)
+	found := false
+	for i := range res.Payments {
+		if res.Payments[i].PaymentID == paymentId && !res.Payments[i].Reversed {
+			paid, _ := toCents(res.Payments[i].Amount)
+			refunded, _ := toCents(res.Payments[i].Refunded)
+			if amount > paid - refunded {
+				return nil, errors.New("Cannot reverse more than the " + formatCents(paid - refunded) + " paid by " + paymentId)
+			}
+			// a whole reversal needs the Payment refunded, a partial one a refund on the Payment that covers it
+			if (amount == 0 && payment.PaymentStatus != PaymentRefundedStatus) || (amount > 0 && paymentRefunded < refunded + amount) {
+				return nil, errors.New("Payment " + paymentId + " is not refunded by that amount")
+			}
+			if amount == 0 || amount == paid - refunded {
+				res.Payments[i].Reversed = true
+				res.Payments[i].ReversedAt = args[2]
+			} else {
+				res.Payments[i].Refunded = formatCents(refunded + amount)
+			}
+			found = true
+		}
+	}
+	if !found {
+		fmt.Println("Payment " + paymentId + " is not recorded against " + agreementId)
+		return nil, nil
+	}
+	if res.Agreement_status == PaidStatus {
+		res.Agreement_status = SignedStatus[SigningOrder[len(SigningOrder)-1]]
+	}
+	agreementAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(agreementId, agreementAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"agreementID\" : \""+agreementId+"\", \"paymentID\" : \""+paymentId+"\", \"message\" : \"Payment reversed, outstanding " + agreementBalance(res).Outstanding + "\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end reverse_instalment_payment")
+	return nil, nil
+}
+// ============================================================================================================================
+// set_payment_chaincode - an admin registers the Payment chaincode that settlements and refunds are read back from
+// ============================================================================================================================
+func (t *ManageAgreement) set_payment_chaincode(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// set_payment_chaincode("paymentChaincode")
+	var err error
+	fmt.Println("start set_payment_chaincode")
+	problem := ""
+	role, err := stub.ReadCertAttribute("role")
+	if len(args) != 1 || args[0] == "" {
+		problem = "Incorrect number of arguments. Expecting \"paymentChaincode\" as an argument"
+	} else if err != nil || string(role) != "admin" {
+		problem = "Only an admin can register the Payment chaincode"
+	}
+	if problem != "" {
+		errMsg := "{ \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	err = stub.PutState(PaymentChaincodeStr, []byte(args[0]))
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"message\" : \"Payment chaincode registered succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end set_payment_chaincode")
+	return nil, nil
+}
+// ============================================================================================================================
+// getPayment - query a Payment from the registered Payment chaincode
+// ============================================================================================================================
+func getPayment(stub shim.ChaincodeStubInterface, paymentId string) (Payment, error) {
+	payment := Payment{}
+	paymentChaincode, err := stub.GetState(PaymentChaincodeStr)
+	if err != nil {
+		return payment, errors.New("Failed to get the Payment chaincode")
+	}
+	if len(paymentChaincode) == 0 {
+		return payment, errors.New("The Payment chaincode is not registered")
+	}
+	f := "getPaymentByID"
+	queryArgs := util.ToChaincodeArgs(f, paymentId)
+	paymentAsBytes, err := stub.QueryChaincode(string(paymentChaincode), queryArgs)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to query Payment chaincode. Got error: %s", err.Error())
+		fmt.Println(errStr)
+		return payment, errors.New(errStr)
+	}
+	json.Unmarshal(paymentAsBytes, &payment)
+	return payment, nil
+}
+// ============================================================================================================================
+// callerIsBank - whether the caller is the buyer bank or the seller bank of the Agreement
+// ============================================================================================================================
+func callerIsBank(stub shim.ChaincodeStubInterface, agreement Agreement) bool {
+	caller := callerName(stub)
+	return caller != "" && (caller == agreement.BB_name || caller == agreement.SB_name)
+}
+// ============================================================================================================================
+// getAgreementBalance - get the amount paid and outstanding on an Agreement and on each instalment line
+// ============================================================================================================================
+func (t *ManageAgreement) getAgreementBalance(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start getAgreementBalance")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"AgreementID\" as an argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	agreementId := args[0]
+	agreementAsBytes, err := stub.GetState(agreementId)
+	if err != nil {
+		return nil, errors.New("{\"Error\":\"Failed to get state for " + agreementId + "\"}")
+	}
+	res := Agreement{}
+	json.Unmarshal(agreementAsBytes, &res)
+	if res.AgreementID != agreementId {
+		errMsg := "{ \"message\" : \""+ agreementId+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	balanceAsBytes, _ := json.Marshal(agreementBalance(res))
+	fmt.Println("end getAgreementBalance")
+	return balanceAsBytes, nil
+}
+// ============================================================================================================================
+// checkInstalments - returns why a schedule is invalid, or "" when its percents add up to 100 with known triggers
+// ============================================================================================================================
+func checkInstalments(instalments []Instalment) string {
+	var total float64
+	for i, line := range instalments {
+		percent, err := strconv.ParseFloat(line.Percent, 64)
+		if err != nil || percent <= 0 {
+			return "Instalment " + strconv.Itoa(i + 1) + " needs a positive percent"
+		}
+		if line.Trigger != "signing" && MilestoneRoles[line.Trigger] == "" {
+			return "Instalment " + strconv.Itoa(i + 1) + " has unknown trigger " + line.Trigger
+		}
+		total += percent
+	}
+	if math.Abs(total - 100) >= 0.005 {
+		return "Instalment percents add up to " + strconv.FormatFloat(total, 'f', 2, 64) + " instead of 100"
+	}
+	return ""
+}
+// ============================================================================================================================
+// checkInstalmentPayment - returns why an amount cannot be paid against a line, or "" when it can
+// ============================================================================================================================
+func checkInstalmentPayment(agreement Agreement, lineNo string, cents int64) string {
+	if nextSigner(agreement) != "" {
+		return "Agreement is not signed by all parties"
+	}
+	balance := agreementBalance(agreement)
+	if lineNo == "" {
+		if len(balance.Instalments) > 0 {
+			return "Agreement has an instalment schedule, expecting an instalment line"
+		}
+		outstanding, _ := toCents(balance.Outstanding)
+		if cents > outstanding {
+			return "Amount is more than the outstanding " + balance.Outstanding
+		}
+		return ""
+	}
+	for _, line := range balance.Instalments {
+		if line.LineNo != lineNo {
+			continue
+		}
+		if !line.Due {
+			return "Instalment " + lineNo + " is not due before " + line.Trigger
+		}
+		outstanding, _ := toCents(line.Outstanding)
+		if cents > outstanding {
+			return "Amount is more than the outstanding " + line.Outstanding + " on instalment " + lineNo
+		}
+		return ""
+	}
+	return "Instalment " + lineNo + " Not Found"
+}
+// ============================================================================================================================
+// agreementBalance - amounts due, paid and outstanding, in total and per instalment line.
+// The amount due is Total_Value + ExtraCharges + Shipper_fees, the last line takes the rounding remainder.
+// ============================================================================================================================
+func agreementBalance(agreement Agreement) AgreementBalance {
+	var total int64
+	for _, amount := range []string{agreement.Total_Value, agreement.ExtraCharges, agreement.Shipper_fees} {
+		cents, _ := toCents(amount)
+		total += cents
+	}
+	paidByLine := map[string]int64{}
+	var paid int64
+	for _, payment := range agreement.Payments {
+		if payment.Reversed {
+			continue
+		}
+		cents, _ := toCents(payment.Amount)
+		refunded, _ := toCents(payment.Refunded)
+		cents -= refunded
+		paidByLine[payment.LineNo] += cents
+		paid += cents
+	}
+	balance := AgreementBalance{
+		AgreementID: agreement.AgreementID,
+		Agreement_status: agreement.Agreement_status,
+		Currency: agreement.Currency,
+		Total: formatCents(total),
+		Paid: formatCents(paid),
+		Outstanding: formatCents(total - paid),
+		Instalments: []InstalmentBalance{},
+	}
+	var allocated int64
+	for i, line := range agreement.Instalments {
+		percent, _ := strconv.ParseFloat(line.Percent, 64)
+		amount := int64(math.Floor(float64(total) * percent / 100 + 0.5))
+		if i == len(agreement.Instalments)-1 {
+			amount = total - allocated
+		}
+		allocated += amount
+		balance.Instalments = append(balance.Instalments, InstalmentBalance{
+			LineNo: line.LineNo,
+			Description: line.Description,
+			Percent: line.Percent,
+			Trigger: line.Trigger,
+			Due: instalmentDue(agreement, line.Trigger),
+			Amount: formatCents(amount),
+			Paid: formatCents(paidByLine[line.LineNo]),
+			Outstanding: formatCents(amount - paidByLine[line.LineNo]),
+		})
+	}
+	return balance
+}
+// ============================================================================================================================
+// instalmentDue - whether the trigger of an instalment has happened: signing, or a shipment milestone being posted
+// ============================================================================================================================
+func instalmentDue(agreement Agreement, trigger string) bool {
+	if trigger == "signing" {
+		return nextSigner(agreement) == ""
+	}
+	if agreement.Shipment == nil {
+		return false
+	}
+	for _, milestone := range agreement.Shipment.Milestones {
+		if milestone.Milestone == trigger {
+			return true
+		}
+	}
+	return false
+}
+// ============================================================================================================================
+// toCents - parse a decimal amount into whole cents so totals add up exactly
+// ============================================================================================================================
+func toCents(amount string) (int64, bool) {
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return 0, false
+	}
+	return int64(math.Floor(value * 100 + 0.5)), true
+}
+// ============================================================================================================================
+// formatCents - format cents as a decimal amount with two places
+// ============================================================================================================================
+func formatCents(cents int64) string {
+	return strconv.FormatFloat(float64(cents) / 100, 'f', 2, 64)
+}
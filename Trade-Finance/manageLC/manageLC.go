@@ -0,0 +1,1019 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+"errors"
+"fmt"
+"strconv"
+"strings"
+"time"
+"encoding/json"
+
+"github.com/hyperledger/fabric/core/chaincode/shim"
+"github.com/hyperledger/fabric/core/util"
+)
+
+// ManageLC example simple Chaincode implementation
+type ManageLC struct {
+}
+
+var LCIndexStr = "_LCindex"				//name for the key/value that will store a list of all known Letters of Credit
+var LCDateLayout = "2006-01-02"
+
+// Statuses of a Letter of Credit
+var LCIssuedStatus = "Issued"
+var LCAdvisedStatus = "Advised"
+var LCAmendedStatus = "Amended"					// amended after issue, has to be advised again
+var LCPresentedStatus = "Documents Presented"
+var LCCompliantStatus = "Compliant"
+var LCDiscrepantStatus = "Discrepant"
+var LCHonouredStatus = "Honoured"
+var LCExpiredStatus = "Expired"
+// Status of a Payment once its money has moved, in the Payment chaincode
+var PaymentSettledStatus = "Settled"
+
+// Documents a Letter of Credit can call for, the same types that can be attached to an Agreement
+var DocumentTypes = map[string]bool{"bill_of_lading": true, "invoice": true, "certificate_of_origin": true}
+
+type LetterOfCredit struct{					// Documentary credit opened by the buyer's bank for an Agreement
+	LCID string `json:"lcId"`
+	AgreementID string `json:"agreementId"`
+	TransID string `json:"transId"`
+	Applicant string `json:"applicant"`				// buyer
+	Beneficiary string `json:"beneficiary"`			// seller
+	IssuingBank string `json:"issuingBank"`			// BB_name of the Agreement
+	AdvisingBank string `json:"advisingBank"`			// SB_name of the Agreement
+	Amount string `json:"amount"`
+	Currency string `json:"currency"`
+	ExpiryDate string `json:"expiryDate"`				// YYYY-MM-DD, last day documents can be presented
+	RequiredDocuments []string `json:"requiredDocuments"`
+	LC_status string `json:"lc_status"`
+	Version string `json:"version"`
+	IssueDate string `json:"issueDate"`
+	AdvisedDate string `json:"advisedDate"`
+	Presented []PresentedDocument `json:"presented"`
+	PresentedDate string `json:"presentedDate"`
+	Discrepancies []string `json:"discrepancies"`
+	CheckedDate string `json:"checkedDate"`
+	PaymentID string `json:"paymentId"`				// Payment created when the LC is honoured
+	HonouredDate string `json:"honouredDate"`
+	ExpiredDate string `json:"expiredDate"`
+	Amendments []LCAmendment `json:"amendments"`
+}
+type PresentedDocument struct{
+	DocType string `json:"docType"`
+	Hash string `json:"hash"`					// SHA-256 of the presented document, hex encoded
+}
+type LCAmendment struct{
+	Version string `json:"version"`
+	AmendedAt string `json:"amendedAt"`
+	Reason string `json:"reason"`
+	Changes []FieldChange `json:"changes"`
+}
+type FieldChange struct{
+	Field string `json:"field"`
+	From string `json:"from"`
+	To string `json:"to"`
+}
+type Agreement struct{					// Subset of the Agreement returned by the Agreement chaincode
+	AgreementID string `json:"agreementId"`
+	TransID string `json:"transId"`
+	Agreement_status string `json:"agreement_status"`
+	BuyerName string `json:"buyer_name"`
+	SellerName string `json:"seller_name"`
+	BB_name string `json:"bb_name"`
+	SB_name string `json:"sb_name"`
+	Total_Value string `json:"total_value"`
+	Currency string `json:"currency"`
+	ExtraCharges string `json:"extraCharges"`
+	Shipper_fees string `json:"shipper_fees"`
+	Buyer_sign string `json:"buyer_sign"`
+	BuyerBank_sign string `json:"buyerBank_sign"`
+	Seller_sign string `json:"seller_sign"`
+	SellerBank_sign string `json:"sellerBank_sign"`
+	Line_items []LineItem `json:"line_items"`
+	Documents []Document `json:"documents"`
+	Archive *json.RawMessage `json:"archive,omitempty"`	// set when the Agreement is archived
+}
+type LineItem struct{
+	Currency string `json:"currency"`
+}
+type Payment struct{					// Subset of the Payment returned by the Payment chaincode
+	PaymentID string `json:"paymentId"`
+	PaymentStatus string `json:"paymentStatus"`
+}
+type Document struct{
+	DocType string `json:"docType"`
+	Name string `json:"name"`
+	Hash string `json:"hash"`
+}
+// ============================================================================================================================
+// Main - start the chaincode for Letter of Credit management
+// ============================================================================================================================
+func main() {
+	err := shim.Start(new(ManageLC))
+	if err != nil {
+		fmt.Printf("Error starting Letter of Credit management chaincode: %s", err)
+	}
+}
+// ============================================================================================================================
+// Init - reset all the things
+// ============================================================================================================================
+func (t *ManageLC) Init(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	var err error
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"Intial_Value\" as an argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	fmt.Println("ManageLC chaincode is deployed successfully.");
+	var empty []string
+	jsonAsBytes, _ := json.Marshal(empty)								//marshal an emtpy array of strings to clear the index
+	err = stub.PutState(LCIndexStr, jsonAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"message\" : \"ManageLC chaincode is deployed successfully.\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+// ============================================================================================================================
+// Run - Our entry point for Invocations - [LEGACY] obc-peer 4/25/2016
+// ============================================================================================================================
+func (t *ManageLC) Run(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	fmt.Println("run is running " + function)
+	return t.Invoke(stub, function, args)
+}
+// ============================================================================================================================
+// Invoke - Our entry point for Invocations
+// ============================================================================================================================
+func (t *ManageLC) Invoke(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	fmt.Println("invoke is running " + function)
+
+	// Handle different functions
+	if function == "init" {													//initialize the chaincode state, used as reset
+		return t.Init(stub, "init", args)
+	} else if function == "issue_lc" {											//issuing bank opens a Letter of Credit
+		return t.issue_lc(stub, args)
+	}else if function == "advise_lc" {									//advising bank advises it to the beneficiary
+		return t.advise_lc(stub, args)
+	}else if function == "amend_lc" {									//issuing bank changes amount, expiry or documents
+		return t.amend_lc(stub, args)
+	}else if function == "present_documents" {									//beneficiary presents the documents
+		return t.present_documents(stub, args)
+	}else if function == "check_documents" {									//issuing bank checks them against the Agreement
+		return t.check_documents(stub, args)
+	}else if function == "honour_lc" {									//issuing bank pays, creating the Payment
+		return t.honour_lc(stub, args)
+	}else if function == "expire_lc" {									//close a Letter of Credit past its expiry date
+		return t.expire_lc(stub, args)
+	}
+	fmt.Println("invoke did not find func: " + function)					//error
+	errMsg := "{ \"message\" : \"Received unknown function invocation\", \"code\" : \"503\"}"
+	err := stub.SetEvent("errEvent", []byte(errMsg))
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+// ============================================================================================================================
+// Query - Our entry point for Queries
+// ============================================================================================================================
+func (t *ManageLC) Query(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	fmt.Println("query is running " + function)
+
+	// Handle different functions
+	if function == "get_lc_byID" {													//Read a Letter of Credit by its ID
+		return t.get_lc_byID(stub, args)
+	} else if function == "get_lc_byAgreement" {													//Read the Letters of Credit of an Agreement
+		return t.get_lc_byAgreement(stub, args)
+	} else if function == "get_all_lc" {													//Read all Letters of Credit
+		return t.get_all_lc(stub, args)
+	}
+	fmt.Println("query did not find func: " + function)						//error
+	errMsg := "{ \"message\" : \"Received unknown function query\", \"code\" : \"503\"}"
+	err := stub.SetEvent("errEvent", []byte(errMsg))
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+// ============================================================================================================================
+// get_lc_byID - get Letter of Credit details for a specific ID from chaincode state
+// ============================================================================================================================
+func (t *ManageLC) get_lc_byID(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start get_lc_byID")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"LCID\" as an argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	lcId := args[0]
+	lc, err := getLC(stub, lcId)
+	if err != nil {
+		return nil, err
+	}
+	if lc.LCID != lcId {
+		errMsg := "{ \"message\" : \""+ lcId+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	lcAsBytes, _ := json.Marshal(lc)
+	fmt.Println("end get_lc_byID")
+	return lcAsBytes, nil
+}
+// ============================================================================================================================
+// get_lc_byAgreement - get the Letters of Credit opened for an Agreement
+// ============================================================================================================================
+func (t *ManageLC) get_lc_byAgreement(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start get_lc_byAgreement")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"AgreementID\" as an argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	lcs, err := listLCs(stub, args[0])
+	if err != nil {
+		return nil, err
+	}
+	jsonResp, _ := json.Marshal(lcs)
+	fmt.Println("end get_lc_byAgreement")
+	return jsonResp, nil
+}
+// ============================================================================================================================
+// get_all_lc - get details of all Letters of Credit from chaincode state
+// ============================================================================================================================
+func (t *ManageLC) get_all_lc(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	fmt.Println("start get_all_lc")
+	lcs, err := listLCs(stub, "")
+	if err != nil {
+		return nil, err
+	}
+	jsonResp, _ := json.Marshal(lcs)
+	fmt.Println("end get_all_lc")
+	return jsonResp, nil
+}
+// ============================================================================================================================
+// issue_lc - the buyer's bank opens a Letter of Credit for a fully signed Agreement
+// ============================================================================================================================
+func (t *ManageLC) issue_lc(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// issue_lc("lcId", "agreementId", "amount", "expiryDate", "requiredDocuments", "issueDate", "agreementChaincode")
+	var err error
+	if len(args) != 7 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 7 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	fmt.Println("start issue_lc")
+	lcId := args[0]
+	agreementId := args[1]
+	amount := args[2]
+	expiryDate := args[3]
+	issueDate := args[5]
+	agreementChaincode := args[6]						// name of the Agreement chaincode holding agreementId
+
+	existing, err := getLC(stub, lcId)
+	if err != nil {
+		return nil, err
+	}
+	if existing.LCID == lcId {
+		errMsg := "{ \"message\" : \"This Letter of Credit already exists.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	agreement, err := getAgreement(stub, agreementChaincode, agreementId)
+	if err != nil {
+		return nil, err
+	}
+	problem := ""
+	requiredDocuments, docProblem := parseRequiredDocuments(args[4])
+	if agreement.AgreementID != agreementId {
+		problem = "Agreement " + agreementId + " Not Found."
+	} else if !isFullySigned(agreement) {
+		problem = "Agreement " + agreementId + " is not signed by all parties."
+	} else if agreement.Archive != nil {
+		problem = "Agreement " + agreementId + " is archived."
+	} else if !callerIs(stub, agreement.BB_name) {
+		problem = "Only the buyer bank of the Agreement can issue a Letter of Credit"
+	} else if docProblem != "" {
+		problem = docProblem
+	} else if !isLCDate(issueDate) || !isLCDate(expiryDate) || expiryDate <= issueDate {
+		problem = "Expecting YYYY-MM-DD dates with the expiry date after the issue date"
+	} else {
+		problem, err = checkLCAmount(stub, lcId, amount, agreement)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if problem != "" {
+		errMsg := "{ \"lcID\" : \""+lcId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	currency := agreement.Currency
+	if currency == "" && len(agreement.Line_items) > 0 {
+		currency = agreement.Line_items[0].Currency
+	}
+	lc := LetterOfCredit{
+		LCID: lcId,
+		AgreementID: agreementId,
+		TransID: agreement.TransID,
+		Applicant: agreement.BuyerName,
+		Beneficiary: agreement.SellerName,
+		IssuingBank: agreement.BB_name,
+		AdvisingBank: agreement.SB_name,
+		Amount: amount,
+		Currency: currency,
+		ExpiryDate: expiryDate,
+		RequiredDocuments: requiredDocuments,
+		LC_status: LCIssuedStatus,
+		Version: "1",
+		IssueDate: issueDate,
+	}
+	err = putLC(stub, lc)
+	if err != nil {
+		return nil, err
+	}
+	//get the LC index
+	lcIndexAsBytes, err := stub.GetState(LCIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get LC index")
+	}
+	var lcIndex []string
+	json.Unmarshal(lcIndexAsBytes, &lcIndex)							//un stringify it aka JSON.parse()
+	lcIndex = append(lcIndex, lcId)									//add LC ID to index list
+	fmt.Println("! LC index: ", lcIndex)
+	jsonAsBytes, _ := json.Marshal(lcIndex)
+	err = stub.PutState(LCIndexStr, jsonAsBytes)						//store name of LC
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"lcID\" : \""+lcId+"\", \"message\" : \"Letter of Credit issued succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end issue_lc")
+	return nil, nil
+}
+// ============================================================================================================================
+// advise_lc - the seller's bank advises an issued or amended Letter of Credit to the beneficiary
+// ============================================================================================================================
+func (t *ManageLC) advise_lc(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// advise_lc("lcId", "advisedAt")
+	var err error
+	if len(args) != 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 2 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	fmt.Println("start advise_lc")
+	lcId := args[0]
+	advisedAt := args[1]
+	lc, err := getLC(stub, lcId)
+	if err != nil {
+		return nil, err
+	}
+	problem := ""
+	if lc.LCID != lcId {
+		problem = lcId + " Not Found."
+	} else if lc.LC_status != LCIssuedStatus && lc.LC_status != LCAmendedStatus {
+		problem = "Letter of Credit cannot be advised, status is " + lc.LC_status
+	} else if !callerIs(stub, lc.AdvisingBank) {
+		problem = "Only the advising bank can advise this Letter of Credit"
+	} else if !isLCDate(advisedAt) || advisedAt > lc.ExpiryDate {
+		problem = "Expecting a YYYY-MM-DD date on or before the expiry date"
+	}
+	if problem != "" {
+		errMsg := "{ \"lcID\" : \""+lcId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	lc.LC_status = LCAdvisedStatus
+	lc.AdvisedDate = advisedAt
+	err = putLC(stub, lc)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"lcID\" : \""+lcId+"\", \"message\" : \"Letter of Credit advised succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end advise_lc")
+	return nil, nil
+}
+// ============================================================================================================================
+// amend_lc - the issuing bank changes the amount, expiry date or required documents before documents are presented
+// ============================================================================================================================
+func (t *ManageLC) amend_lc(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// amend_lc("lcId", "amount", "expiryDate", "requiredDocuments", "amendedAt", "reason", "agreementChaincode")
+	var err error
+	if len(args) != 7 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 7 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	fmt.Println("start amend_lc")
+	lcId := args[0]
+	amount := args[1]
+	expiryDate := args[2]
+	amendedAt := args[4]
+	reason := args[5]
+	agreementChaincode := args[6]
+	lc, err := getLC(stub, lcId)
+	if err != nil {
+		return nil, err
+	}
+	problem := ""
+	requiredDocuments, docProblem := parseRequiredDocuments(args[3])
+	if lc.LCID != lcId {
+		problem = lcId + " Not Found."
+	} else if lc.LC_status != LCIssuedStatus && lc.LC_status != LCAdvisedStatus && lc.LC_status != LCAmendedStatus {
+		problem = "Letter of Credit cannot be amended, status is " + lc.LC_status
+	} else if !callerIs(stub, lc.IssuingBank) {
+		problem = "Only the issuing bank can amend this Letter of Credit"
+	} else if docProblem != "" {
+		problem = docProblem
+	} else if reason == "" || !isLCDate(amendedAt) || !isLCDate(expiryDate) || expiryDate <= amendedAt {
+		problem = "Expecting a reason and YYYY-MM-DD dates with the expiry date after the amendment date"
+	}
+	if problem == "" && amount != lc.Amount {
+		agreement, err := getAgreement(stub, agreementChaincode, lc.AgreementID)
+		if err != nil {
+			return nil, err
+		}
+		problem, err = checkLCAmount(stub, lcId, amount, agreement)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var changes []FieldChange
+	if amount != lc.Amount {
+		changes = append(changes, FieldChange{"amount", lc.Amount, amount})
+	}
+	if expiryDate != lc.ExpiryDate {
+		changes = append(changes, FieldChange{"expiryDate", lc.ExpiryDate, expiryDate})
+	}
+	if strings.Join(requiredDocuments, ",") != strings.Join(lc.RequiredDocuments, ",") {
+		changes = append(changes, FieldChange{"requiredDocuments", strings.Join(lc.RequiredDocuments, ","), strings.Join(requiredDocuments, ",")})
+	}
+	if problem == "" && len(changes) == 0 {
+		problem = "Nothing to amend"
+	}
+	if problem != "" {
+		errMsg := "{ \"lcID\" : \""+lcId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	version, _ := strconv.Atoi(lc.Version)
+	lc.Version = strconv.Itoa(version + 1)
+	lc.Amount = amount
+	lc.ExpiryDate = expiryDate
+	lc.RequiredDocuments = requiredDocuments
+	lc.LC_status = LCAmendedStatus
+	lc.Amendments = append(lc.Amendments, LCAmendment{lc.Version, amendedAt, reason, changes})
+	err = putLC(stub, lc)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"lcID\" : \""+lcId+"\", \"message\" : \"Letter of Credit amended to version " + lc.Version + "\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end amend_lc")
+	return nil, nil
+}
+// ============================================================================================================================
+// present_documents - the beneficiary presents "docType", "sha256" pairs for an advised Letter of Credit
+// ============================================================================================================================
+func (t *ManageLC) present_documents(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// present_documents("lcId", "presentedAt", "docType", "sha256", ...)
+	var err error
+	if len(args) < 4 || len(args) % 2 != 0 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting LC ID, date and \"docType\", \"sha256\" pairs.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	fmt.Println("start present_documents")
+	lcId := args[0]
+	presentedAt := args[1]
+	lc, err := getLC(stub, lcId)
+	if err != nil {
+		return nil, err
+	}
+	problem := ""
+	if lc.LCID != lcId {
+		problem = lcId + " Not Found."
+	} else if lc.LC_status != LCAdvisedStatus && lc.LC_status != LCDiscrepantStatus {
+		problem = "Documents cannot be presented, status is " + lc.LC_status
+	} else if !callerIs(stub, lc.Beneficiary) {
+		problem = "Only the beneficiary can present documents"
+	} else if !isLCDate(presentedAt) || presentedAt > lc.ExpiryDate {
+		problem = "Documents must be presented on or before the expiry date " + lc.ExpiryDate
+	}
+	var presented []PresentedDocument
+	for i := 2; problem == "" && i+1 < len(args); i += 2 {
+		if !DocumentTypes[args[i]] {
+			problem = "Unknown document type " + args[i]
+		} else {
+			presented = append(presented, PresentedDocument{args[i], strings.ToLower(args[i+1])})
+		}
+	}
+	if problem != "" {
+		errMsg := "{ \"lcID\" : \""+lcId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	lc.Presented = presented
+	lc.PresentedDate = presentedAt
+	lc.Discrepancies = nil
+	lc.LC_status = LCPresentedStatus
+	err = putLC(stub, lc)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"lcID\" : \""+lcId+"\", \"message\" : \"Documents presented succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end present_documents")
+	return nil, nil
+}
+// ============================================================================================================================
+// check_documents - the issuing bank checks the presented documents against those attached to the Agreement
+// ============================================================================================================================
+func (t *ManageLC) check_documents(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// check_documents("lcId", "checkedAt", "agreementChaincode")
+	var err error
+	if len(args) != 3 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 3 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	fmt.Println("start check_documents")
+	lcId := args[0]
+	checkedAt := args[1]
+	agreementChaincode := args[2]
+	lc, err := getLC(stub, lcId)
+	if err != nil {
+		return nil, err
+	}
+	problem := ""
+	if lc.LCID != lcId {
+		problem = lcId + " Not Found."
+	} else if lc.LC_status != LCPresentedStatus {
+		problem = "Documents cannot be checked, status is " + lc.LC_status
+	} else if !callerIs(stub, lc.IssuingBank) {
+		problem = "Only the issuing bank can check the documents"
+	} else if !isLCDate(checkedAt) {
+		problem = "Expecting a YYYY-MM-DD date"
+	}
+	if problem != "" {
+		errMsg := "{ \"lcID\" : \""+lcId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	agreement, err := getAgreement(stub, agreementChaincode, lc.AgreementID)
+	if err != nil {
+		return nil, err
+	}
+	lc.Discrepancies = documentDiscrepancies(lc, agreement)
+	lc.CheckedDate = checkedAt
+	lc.LC_status = LCCompliantStatus
+	if len(lc.Discrepancies) > 0 {
+		lc.LC_status = LCDiscrepantStatus
+	}
+	err = putLC(stub, lc)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"lcID\" : \""+lcId+"\", \"message\" : \"Documents checked, status is " + lc.LC_status + "\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end check_documents")
+	return nil, nil
+}
+// ============================================================================================================================
+// honour_lc - the issuing bank honours a compliant presentation, the Payment is created and settled in the Payment chaincode
+// ============================================================================================================================
+func (t *ManageLC) honour_lc(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// honour_lc("lcId", "paymentId", "honouredAt", "paymentDeadlineDate", "applicantAccount", "beneficiaryAccount", "instalmentNo",
+	//	"agreementChaincode", "paymentChaincode")
+	var err error
+	if len(args) != 9 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 9 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	fmt.Println("start honour_lc")
+	lcId := args[0]
+	paymentId := args[1]
+	honouredAt := args[2]
+	paymentDeadlineDate := args[3]
+	applicantAccount := args[4]
+	beneficiaryAccount := args[5]
+	instalmentNo := args[6]						// instalment line of the Agreement, "" when it has no schedule
+	agreementChaincode := args[7]
+	paymentChaincode := args[8]
+	lc, err := getLC(stub, lcId)
+	if err != nil {
+		return nil, err
+	}
+	problem := ""
+	if lc.LCID != lcId {
+		problem = lcId + " Not Found."
+	} else if lc.LC_status != LCCompliantStatus {
+		problem = "Letter of Credit cannot be honoured, status is " + lc.LC_status
+	} else if !callerIs(stub, lc.IssuingBank) {
+		problem = "Only the issuing bank can honour this Letter of Credit"
+	} else if paymentId == "" || !isLCDate(honouredAt) {
+		problem = "Expecting a payment ID and a YYYY-MM-DD date"
+	}
+	if problem != "" {
+		errMsg := "{ \"lcID\" : \""+lcId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	// createPayment("paymentId", "agreementId", "buyerName", "sellerName", "amount", "paymentCUDate", "paymentStatus",
+	//	"paymentDeadlineDate", "buyerBank_sign", "bb_name", "sb_name", "buyerAccount", "sellerAccount", "agreementChaincode", "instalmentNo")
+	function := "createPayment"
+	invokeArgs := util.ToChaincodeArgs(function, paymentId, lc.AgreementID, lc.Applicant, lc.Beneficiary, lc.Amount, honouredAt,
+		"Initiated", paymentDeadlineDate, "", lc.IssuingBank, lc.AdvisingBank, applicantAccount, beneficiaryAccount, agreementChaincode, instalmentNo)
+	result, err := stub.InvokeChaincode(paymentChaincode, invokeArgs)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to create Payment in 'Payment' chaincode. Got error: %s", err.Error())
+		fmt.Println(errStr)
+		return nil, errors.New(errStr)
+	}
+	fmt.Print("Payment hash returned: ")
+	fmt.Println(result)
+
+	// the issuing bank is the buyer bank of the Payment, approve and settle it straight away
+	function = "approvePayment"
+	invokeArgs = util.ToChaincodeArgs(function, paymentId, honouredAt)
+	result, err = stub.InvokeChaincode(paymentChaincode, invokeArgs)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to approve Payment in 'Payment' chaincode. Got error: %s", err.Error())
+		fmt.Println(errStr)
+		return nil, errors.New(errStr)
+	}
+	fmt.Print("Approval hash returned: ")
+	fmt.Println(result)
+
+	function = "settlePayment"
+	invokeArgs = util.ToChaincodeArgs(function, paymentId, honouredAt)
+	result, err = stub.InvokeChaincode(paymentChaincode, invokeArgs)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to settle Payment in 'Payment' chaincode. Got error: %s", err.Error())
+		fmt.Println(errStr)
+		return nil, errors.New(errStr)
+	}
+	fmt.Print("Settlement hash returned: ")
+	fmt.Println(result)
+
+	// the Payment chaincode reports a rejected payment with an event, not an error, so read the Payment back
+	payment, err := getPayment(stub, paymentChaincode, paymentId)
+	if err != nil {
+		return nil, err
+	}
+	if payment.PaymentID != paymentId || payment.PaymentStatus != PaymentSettledStatus {
+		return nil, errors.New("Payment " + paymentId + " was not settled, Letter of Credit " + lcId + " is not honoured")
+	}
+
+	lc.PaymentID = paymentId
+	lc.HonouredDate = honouredAt
+	lc.LC_status = LCHonouredStatus
+	err = putLC(stub, lc)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"lcID\" : \""+lcId+"\", \"paymentID\" : \""+paymentId+"\", \"message\" : \"Letter of Credit honoured succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end honour_lc")
+	return nil, nil
+}
+// ============================================================================================================================
+// expire_lc - the issuing or advising bank closes a Letter of Credit that was not honoured by its expiry date. Documents
+// presented on time keep it open until they are honoured or found discrepant
+// ============================================================================================================================
+func (t *ManageLC) expire_lc(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// expire_lc("lcId", "asOf")
+	var err error
+	if len(args) != 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 2 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	fmt.Println("start expire_lc")
+	lcId := args[0]
+	asOf := args[1]
+	lc, err := getLC(stub, lcId)
+	if err != nil {
+		return nil, err
+	}
+	problem := ""
+	if lc.LCID != lcId {
+		problem = lcId + " Not Found."
+	} else if lc.LC_status == LCHonouredStatus || lc.LC_status == LCExpiredStatus {
+		problem = "Letter of Credit cannot be expired, status is " + lc.LC_status
+	} else if !callerIs(stub, lc.IssuingBank) && !callerIs(stub, lc.AdvisingBank) {
+		problem = "Only the issuing or the advising bank can expire this Letter of Credit"
+	} else if (lc.LC_status == LCPresentedStatus || lc.LC_status == LCCompliantStatus) && lc.PresentedDate <= lc.ExpiryDate {
+		problem = "Documents were presented on " + lc.PresentedDate + ", before the expiry date, status is " + lc.LC_status
+	} else if !isLCDate(asOf) || asOf <= lc.ExpiryDate {
+		problem = "Letter of Credit is valid until " + lc.ExpiryDate
+	}
+	if problem != "" {
+		errMsg := "{ \"lcID\" : \""+lcId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	lc.LC_status = LCExpiredStatus
+	lc.ExpiredDate = asOf
+	err = putLC(stub, lc)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"lcID\" : \""+lcId+"\", \"message\" : \"Letter of Credit expired\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end expire_lc")
+	return nil, nil
+}
+// ============================================================================================================================
+// getLC - read a Letter of Credit, an empty LetterOfCredit is returned when it does not exist
+// ============================================================================================================================
+func getLC(stub shim.ChaincodeStubInterface, lcId string) (LetterOfCredit, error) {
+	lc := LetterOfCredit{}
+	lcAsBytes, err := stub.GetState(lcId)
+	if err != nil {
+		return lc, errors.New("{\"Error\":\"Failed to get state for " + lcId + "\"}")
+	}
+	json.Unmarshal(lcAsBytes, &lc)
+	return lc, nil
+}
+// ============================================================================================================================
+// putLC - store a Letter of Credit with its ID as key
+// ============================================================================================================================
+func putLC(stub shim.ChaincodeStubInterface, lc LetterOfCredit) error {
+	lcAsBytes, _ := json.Marshal(lc)
+	return stub.PutState(lc.LCID, lcAsBytes)
+}
+// ============================================================================================================================
+// listLCs - the Letters of Credit in index order, only those of agreementId unless it is empty
+// ============================================================================================================================
+func listLCs(stub shim.ChaincodeStubInterface, agreementId string) ([]LetterOfCredit, error) {
+	var lcIndex []string
+	lcs := []LetterOfCredit{}
+	lcIndexAsBytes, err := stub.GetState(LCIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get LC index")
+	}
+	json.Unmarshal(lcIndexAsBytes, &lcIndex)
+	for _, lcId := range lcIndex {
+		lc, err := getLC(stub, lcId)
+		if err != nil {
+			return nil, err
+		}
+		if agreementId == "" || lc.AgreementID == agreementId {
+			lcs = append(lcs, lc)
+		}
+	}
+	return lcs, nil
+}
+// ============================================================================================================================
+// getAgreement - query an Agreement from the Agreement chaincode
+// ============================================================================================================================
+func getAgreement(stub shim.ChaincodeStubInterface, agreementChaincode string, agreementId string) (Agreement, error) {
+	agreement := Agreement{}
+	f := "getAgreement_byID"
+	queryArgs := util.ToChaincodeArgs(f, agreementId)
+	agreementAsBytes, err := stub.QueryChaincode(agreementChaincode, queryArgs)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to query Agreement chaincode. Got error: %s", err.Error())
+		fmt.Println(errStr)
+		return agreement, errors.New(errStr)
+	}
+	json.Unmarshal(agreementAsBytes, &agreement)
+	return agreement, nil
+}
+// ============================================================================================================================
+// getPayment - query a Payment from the Payment chaincode
+// ============================================================================================================================
+func getPayment(stub shim.ChaincodeStubInterface, paymentChaincode string, paymentId string) (Payment, error) {
+	payment := Payment{}
+	f := "getPaymentByID"
+	queryArgs := util.ToChaincodeArgs(f, paymentId)
+	paymentAsBytes, err := stub.QueryChaincode(paymentChaincode, queryArgs)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to query Payment chaincode. Got error: %s", err.Error())
+		fmt.Println(errStr)
+		return payment, errors.New(errStr)
+	}
+	json.Unmarshal(paymentAsBytes, &payment)
+	return payment, nil
+}
+// ============================================================================================================================
+// isFullySigned - every party of the Agreement has signed it
+// ============================================================================================================================
+func isFullySigned(agreement Agreement) bool {
+	return agreement.Buyer_sign == "true" && agreement.BuyerBank_sign == "true" &&
+		agreement.Seller_sign == "true" && agreement.SellerBank_sign == "true"
+}
+// ============================================================================================================================
+// checkLCAmount - returns why lcId cannot credit amount for the Agreement, or "" when it can. The amounts of the other
+// Letters of Credit of the Agreement that have not expired count against the Agreement value.
+// ============================================================================================================================
+func checkLCAmount(stub shim.ChaincodeStubInterface, lcId string, amount string, agreement Agreement) (string, error) {
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil || value <= 0 {
+		return "Amount must be a positive number", nil
+	}
+	limit := 0.0
+	for _, charge := range []string{agreement.Total_Value, agreement.ExtraCharges, agreement.Shipper_fees} {
+		value, _ := strconv.ParseFloat(charge, 64)
+		limit += value
+	}
+	lcs, err := listLCs(stub, agreement.AgreementID)
+	if err != nil {
+		return "", err
+	}
+	credited := value
+	for _, lc := range lcs {
+		if lc.LCID == lcId || lc.LC_status == LCExpiredStatus {
+			continue
+		}
+		other, _ := strconv.ParseFloat(lc.Amount, 64)
+		credited += other
+	}
+	if credited > limit + 0.005 {
+		return "Letters of Credit for the Agreement would total " + strconv.FormatFloat(credited, 'f', 2, 64) +
+			", more than the Agreement value " + strconv.FormatFloat(limit, 'f', 2, 64), nil
+	}
+	return "", nil
+}
+// ============================================================================================================================
+// parseRequiredDocuments - split a comma separated list of document types
+// ============================================================================================================================
+func parseRequiredDocuments(list string) ([]string, string) {
+	var docs []string
+	seen := map[string]bool{}
+	for _, doc := range strings.Split(list, ",") {
+		doc = strings.TrimSpace(doc)
+		if !DocumentTypes[doc] {
+			return nil, "Unknown document type " + doc
+		}
+		if !seen[doc] {
+			seen[doc] = true
+			docs = append(docs, doc)
+		}
+	}
+	return docs, ""
+}
+// ============================================================================================================================
+// documentDiscrepancies - compare the presentation with the Letter of Credit terms and the documents attached to the Agreement
+// ============================================================================================================================
+func documentDiscrepancies(lc LetterOfCredit, agreement Agreement) []string {
+	var discrepancies []string
+	if agreement.AgreementID != lc.AgreementID {
+		return []string{"Agreement " + lc.AgreementID + " Not Found"}
+	}
+	if !isFullySigned(agreement) {
+		discrepancies = append(discrepancies, "Agreement is not signed by all parties")
+	}
+	if lc.PresentedDate > lc.ExpiryDate {
+		discrepancies = append(discrepancies, "Documents presented after expiry")
+	}
+	presented := map[string]string{}
+	for _, doc := range lc.Presented {
+		presented[doc.DocType] = doc.Hash
+	}
+	for _, docType := range lc.RequiredDocuments {
+		hash, ok := presented[docType]
+		if !ok {
+			discrepancies = append(discrepancies, docType + " not presented")
+			continue
+		}
+		matched := false
+		attached := false
+		for _, doc := range agreement.Documents {
+			if doc.DocType == docType {
+				attached = true
+				if strings.ToLower(doc.Hash) == hash {
+					matched = true
+				}
+			}
+		}
+		if !attached {
+			discrepancies = append(discrepancies, docType + " is not attached to the Agreement")
+		} else if !matched {
+			discrepancies = append(discrepancies, docType + " does not match the Agreement")
+		}
+	}
+	return discrepancies
+}
+// ============================================================================================================================
+// callerIs - whether the username attribute of the caller's certificate is party
+// ============================================================================================================================
+func callerIs(stub shim.ChaincodeStubInterface, party string) bool {
+	caller, err := stub.ReadCertAttribute("username")
+	return err == nil && party != "" && string(caller) == party
+}
+// ============================================================================================================================
+// isLCDate - whether a value is a YYYY-MM-DD date
+// ============================================================================================================================
+func isLCDate(value string) bool {
+	_, err := time.Parse(LCDateLayout, value)
+	return err == nil
+}
@@ -23,9 +23,13 @@ import (
 "errors"
 "fmt"
 "strconv"
+"strings"
+"sort"
+"time"
 "encoding/json"
 
 "github.com/hyperledger/fabric/core/chaincode/shim"
+"github.com/hyperledger/fabric/core/util"
 )
 
 // ManagePO example simple Chaincode implementation
@@ -33,6 +37,15 @@ type ManagePO struct {
 }
 
 var POIndexStr = "_POindex"				//name for the key/value that will store a list of all known PO
+var POVersionPrefix = "_POversion_"		//prefix of the keys that store superseded versions of a PO
+var HeldScreeningStatus = "Held – Screening"	//status of a PO whose parties matched the fraud list
+var ConfirmedFraudStatus = "Rejected – Fraud"
+var DateLayout = "2006-01-02"
+var IncludeArchived = "include_archived"	//optional last argument of the list queries to return archived records too
+// ISO 4217 codes accepted for amounts
+var ISOCurrencies = map[string]bool{"USD": true, "EUR": true, "GBP": true, "JPY": true, "CHF": true, "CNY": true, "INR": true, "AUD": true,
+	"CAD": true, "SGD": true, "HKD": true, "AED": true, "SAR": true, "NZD": true, "SEK": true, "NOK": true, "DKK": true, "ZAR": true,
+	"BRL": true, "MXN": true, "KRW": true, "RUB": true, "TRY": true, "THB": true, "MYR": true, "IDR": true, "PHP": true, "PLN": true}
 
 type PO struct{							// Attributes of a PO 
 	TransID string `json:"transId"`					
@@ -41,13 +54,68 @@ type PO struct{							// Attributes of a PO
 	ExpectedDeliveryDate string `json:"expectedDeliveryDate"`
 	PO_status string `json:"po_status"`
 	PO_date string `json:"po_date"`
-	ItemId string `json:"item_id"`
-	Item_name string `json:"item_name"`
-	Item_quantity string `json:"item_quantity"`
-	Price string `json:"price"`
+	Line_items []LineItem `json:"line_items"`
+	Currency string `json:"currency"`
+	Sub_total string `json:"sub_total"`
+	Tax_total string `json:"tax_total"`
+	Total_value string `json:"total_value"`
 	Buyer_sign string `json:"buyer_sign"`
 	Seller_sign string `json:"seller_sign"`
 	Seller_Remarks string `json:"seller_remarks"`
+	Version string `json:"version"`
+	Amended_by string `json:"amended_by"`
+	Amendment_date string `json:"amendment_date"`
+	Screening *Screening `json:"screening,omitempty"`
+	Archive *Archive `json:"archive,omitempty"`		// set instead of deleting the PO
+}
+
+type Archive struct{						// Why and when a record was archived
+	Reason string `json:"reason"`
+	ArchivedBy string `json:"archivedBy"`
+	ArchivedAt string `json:"archivedAt"`
+}
+
+type Screening struct{						// Outcome of screening the parties against the fraud list of the Agreement chaincode
+	Status string `json:"status"`				// Held, Cleared or Confirmed
+	Hits []ScreeningHit `json:"hits"`
+	PreviousStatus string `json:"previousStatus"`		// status restored when the hit is cleared
+	Reason string `json:"reason"`
+	ReviewedBy string `json:"reviewedBy"`
+	ReviewedAt string `json:"reviewedAt"`
+}
+
+type ScreeningHit struct{
+	Role string `json:"role"`
+	PartyName string `json:"partyName"`
+	FraudID string `json:"fraudId"`
+	FraudName string `json:"fraudName"`
+	MatchType string `json:"matchType"`
+	Score string `json:"score"`
+}
+
+type POVersionDiff struct{					// Changes between two consecutive versions of a PO
+	FromVersion string `json:"fromVersion"`
+	ToVersion string `json:"toVersion"`
+	Changes []FieldChange `json:"changes"`
+}
+
+type FieldChange struct{
+	Field string `json:"field"`
+	From string `json:"from"`
+	To string `json:"to"`
+}
+
+type LineItem struct{						// Attributes of a single PO line
+	LineNo string `json:"line_no"`
+	ItemId string `json:"item_id"`
+	Item_name string `json:"item_name"`
+	Quantity string `json:"quantity"`
+	Unit string `json:"unit"`
+	Unit_price string `json:"unit_price"`
+	Currency string `json:"currency"`
+	Tax_rate string `json:"tax_rate"`					// percentage, e.g. "5" for 5%
+	Tax_amount string `json:"tax_amount"`				// computed on-chain
+	Line_total string `json:"line_total"`				// computed on-chain, excluding tax
 }
 // ============================================================================================================================
 // Main - start the chaincode for PO management
@@ -114,6 +182,12 @@ func (t *ManagePO) Invoke(stub shim.ChaincodeStubInterface, function string, arg
 		return t.delete_po(stub, args)
 	}else if function == "update_po" {									//update a PO
 		return t.update_po(stub, args)
+	}else if function == "amend_po" {									//amend a PO as a new version
+		return t.amend_po(stub, args)
+	}else if function == "sign_po" {									//buyer or seller signs the current version
+		return t.sign_po(stub, args)
+	}else if function == "review_screening" {									//compliance clears or confirms a fraud list hit
+		return t.review_screening(stub, args)
 	}
 	fmt.Println("invoke did not find func: " + function)
 	errMsg := "{ \"message\" : \"Received unknown function invocation\", \"code\" : \"503\"}"
@@ -138,6 +212,8 @@ func (t *ManagePO) Query(stub shim.ChaincodeStubInterface, function string, args
 		return t.getPO_bySeller(stub, args)
 	} else if function == "get_AllPO" {													//Read all POs
 		return t.get_AllPO(stub, args)
+	} else if function == "getPO_versions" {													//Read all versions of a PO
+		return t.getPO_versions(stub, args)
 	}
 	fmt.Println("query did not find func: " + function)						//error
 	errMsg := "{ \"message\" : \"Received unknown function query\", \"code\" : \"503\"}"
@@ -173,6 +249,27 @@ func (t *ManagePO) getPO_byID(stub shim.ChaincodeStubInterface, args []string) (
 		} 
 		return nil, nil
 	}
+	res := PO{}
+	json.Unmarshal(valAsbytes, &res)
+	if res.TransID != transId {
+		errMsg := "{ \"message\" : \""+ transId + " not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	// totals are always derived from the line items, never trusted from storage
+	err = computePOTotals(&res)
+	if err != nil {
+		errMsg := "{ \"message\" : \"" + err.Error() + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	valAsbytes, _ = json.Marshal(res)
 	//fmt.Print("valAsbytes : ")
 	//fmt.Println(valAsbytes)
 	fmt.Println("end getPO_byID")
@@ -187,7 +284,7 @@ func (t *ManagePO) getPO_byBuyer(stub shim.ChaincodeStubInterface, args []string
 	var valIndex PO
 	fmt.Println("start getPO_byBuyer")
 	var err error
-	if len(args) != 1 {
+	if len(args) != 1 && len(args) != 2 {
 		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'buyerName' as an argument\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
@@ -219,7 +316,11 @@ func (t *ManagePO) getPO_byBuyer(stub shim.ChaincodeStubInterface, args []string
 		}
 		//fmt.Print("valueAsBytes : ")
 		//fmt.Println(valueAsBytes)
+		valIndex = PO{}
 		json.Unmarshal(valueAsBytes, &valIndex)
+		if valIndex.Archive != nil && !includeArchived(args, 1) {
+			continue
+		}
 		fmt.Print("valIndex: ")
 		fmt.Print(valIndex)
 		if valIndex.BuyerName == buyerName{
@@ -236,6 +337,7 @@ func (t *ManagePO) getPO_byBuyer(stub shim.ChaincodeStubInterface, args []string
 	fmt.Println("jsonResp : " + jsonResp)
 	//fmt.Print("jsonResp in bytes : ")
 	//fmt.Println([]byte(jsonResp))
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
 	fmt.Println("end getPO_byBuyer")
 	return []byte(jsonResp), nil											//send it onward
 }
@@ -249,7 +351,7 @@ func (t *ManagePO) getPO_bySeller(stub shim.ChaincodeStubInterface, args []strin
 	var valIndex PO
 	fmt.Println("start getPO_bySeller")
 	var err error
-	if len(args) != 1 {
+	if len(args) != 1 && len(args) != 2 {
 		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'sellerName' as an argument\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
@@ -281,7 +383,11 @@ func (t *ManagePO) getPO_bySeller(stub shim.ChaincodeStubInterface, args []strin
 		}
 		//fmt.Print("valueAsBytes : ")
 		//fmt.Println(valueAsBytes)
+		valIndex = PO{}
 		json.Unmarshal(valueAsBytes, &valIndex)
+		if valIndex.Archive != nil && !includeArchived(args, 1) {
+			continue
+		}
 		fmt.Print("valIndex: ")
 		fmt.Print(valIndex)
 		if valIndex.SellerName == sellerName{
@@ -300,6 +406,7 @@ func (t *ManagePO) getPO_bySeller(stub shim.ChaincodeStubInterface, args []strin
 	fmt.Println("jsonResp : " + jsonResp)
 	//fmt.Print("jsonResp in bytes : ")
 	//fmt.Println([]byte(jsonResp))
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
 	fmt.Println("end getPO_bySeller")
 	return []byte(jsonResp), nil											//send it onward
 }
@@ -336,6 +443,11 @@ func (t *ManagePO) get_AllPO(stub shim.ChaincodeStubInterface, args []string) ([
 			errResp = "{\"Error\":\"Failed to get state for " + val + "\"}"
 			return nil, errors.New(errResp)
 		}
+		valIndex := PO{}
+		json.Unmarshal(valueAsBytes, &valIndex)
+		if valIndex.Archive != nil && !includeArchived(args, 0) {
+			continue
+		}
 		//fmt.Print("valueAsBytes : ")
 		//fmt.Println(valueAsBytes)
 		jsonResp = jsonResp + "\""+ val + "\":" + string(valueAsBytes[:])
@@ -349,17 +461,20 @@ func (t *ManagePO) get_AllPO(stub shim.ChaincodeStubInterface, args []string) ([
 	//fmt.Println("jsonResp : " + jsonResp)
 	//fmt.Print("jsonResp in bytes : ")
 	//fmt.Println([]byte(jsonResp))
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
 	fmt.Println("end get_AllPO")
 	return []byte(jsonResp), nil
 											//send it onward
 }
 // ============================================================================================================================
-// Delete - remove a PO from chain
+// Delete - archive a PO with a reason once no Agreement raised against it is left, the PO stays on the ledger
 // ============================================================================================================================
 func (t *ManagePO) delete_po(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	if len(args) != 1 {
-		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'transId' as an argument\", \"code\" : \"503\"}"
-		err := stub.SetEvent("errEvent", []byte(errMsg))
+	// delete_po("transId", "reason", "archivedAt", "agreementChaincode")
+	var err error
+	if len(args) != 4 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'transId', 'reason', 'archivedAt' and 'agreementChaincode' as arguments\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
 		} 
@@ -367,64 +482,76 @@ func (t *ManagePO) delete_po(stub shim.ChaincodeStubInterface, args []string) ([
 	}
 	// set transId
 	transId := args[0]
-	err := stub.DelState(transId)													//remove the PO from chaincode
+	reason := args[1]
+	archivedAt := args[2]
+	agreementChaincode := args[3]
+	poAsBytes, err := stub.GetState(transId)
 	if err != nil {
-		errMsg := "{ \"message\" : \"Failed to delete state\", \"code\" : \"503\"}"
-		err = stub.SetEvent("errEvent", []byte(errMsg))
+		return nil, errors.New("Failed to get PO transID")
+	}
+	res := PO{}
+	json.Unmarshal(poAsBytes, &res)
+	problem := ""
+	if res.TransID != transId {
+		problem = transId + " Not Found."
+	} else if res.Archive != nil {
+		problem = "PO is already archived"
+	} else if reason == "" || !isDate(archivedAt) {
+		problem = "Expecting a reason and a YYYY-MM-DD date"
+	} else {
+		// Agreements that are not archived still depend on the PO
+		f := "getAgreement_byTransID"
+		queryArgs := util.ToChaincodeArgs(f, transId)
+		agreementsAsBytes, err := stub.QueryChaincode(agreementChaincode, queryArgs)
 		if err != nil {
-			return nil, err
-		} 
-		return nil, nil
+			errStr := fmt.Sprintf("Failed to query Agreement chaincode. Got error: %s", err.Error())
+			fmt.Println(errStr)
+			return nil, errors.New(errStr)
+		}
+		agreements := make(map[string]json.RawMessage)
+		json.Unmarshal(agreementsAsBytes, &agreements)
+		var agreementIds []string
+		for agreementId := range agreements {
+			agreementIds = append(agreementIds, agreementId)
+		}
+		sort.Strings(agreementIds)							// report the same Agreements on every peer
+		if len(agreementIds) > 0 {
+			problem = "PO is still referenced by Agreement " + strings.Join(agreementIds, ", ") + ", archive it first"
+		}
 	}
-
-	//get the PO index
-	poAsBytes, err := stub.GetState(POIndexStr)
-	if err != nil {
-		errMsg := "{ \"message\" : \"Failed to get PO index\", \"code\" : \"503\"}"
+	if problem != "" {
+		errMsg := "{ \"transID\" : \""+transId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
-		} 
+		}
 		return nil, nil
 	}
-	//fmt.Println("poAsBytes in delete po")
-	//fmt.Println(poAsBytes);
-	var poIndex []string
-	json.Unmarshal(poAsBytes, &poIndex)								//un stringify it aka JSON.parse()
-	//fmt.Println("poIndex in delete po")
-	//fmt.Println(poIndex);
-	//remove marble from index
-	for i,val := range poIndex{
-		fmt.Println(strconv.Itoa(i) + " - looking at " + val + " for " + transId)
-		if val == transId{															//find the correct PO
-			fmt.Println("found PO with matching transId")
-			poIndex = append(poIndex[:i], poIndex[i+1:]...)			//remove it
-			for x:= range poIndex{											//debug prints...
-				fmt.Println(string(x) + " - " + poIndex[x])
-			}
-			break
-		}
+	res.Archive = &Archive{reason, callerName(stub), archivedAt}
+	poAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(transId, poAsBytes)
+	if err != nil {
+		return nil, err
 	}
-	jsonAsBytes, _ := json.Marshal(poIndex)									//save new index
-	err = stub.PutState(POIndexStr, jsonAsBytes)
-
-	tosend := "{ \"transID\" : \""+transId+"\", \"message\" : \"PO deleted succcessfully\", \"code\" : \"200\"}"
+	tosend := "{ \"transID\" : \""+transId+"\", \"message\" : \"PO archived succcessfully\", \"code\" : \"200\"}"
 	err = stub.SetEvent("evtsender", []byte(tosend))
 	if err != nil {
 		return nil, err
 	} 
 
-	fmt.Println("PO deleted succcessfully")
+	fmt.Println("PO archived succcessfully")
 	return nil, nil
 }
 // ============================================================================================================================
-// Write - update PO into chaincode state
+// Write - update PO into chaincode state, only before anyone has signed it. The parties were screened when the PO was
+// created and can't change, line items, status and signatures change through amend_po and sign_po
 // ============================================================================================================================
 func (t *ManagePO) update_po(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// update_po("transId", "expectedDeliveryDate", "po_date", "seller_remarks")
 	var err error
 	fmt.Println("Updating PO")
-	if len(args) != 13 {
-		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 13\", \"code\" : \"503\"}"
+	if len(args) != 4 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 4\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
@@ -444,46 +571,47 @@ func (t *ManagePO) update_po(stub shim.ChaincodeStubInterface, args []string) ([
 	}
 	res := PO{}
 	json.Unmarshal(poAsBytes, &res)
-	if res.TransID == transId{
-		fmt.Println("PO found with transId : " + transId)
-		res.SellerName = args[1]
-		res.BuyerName = args[2]
-		res.ExpectedDeliveryDate = args[3]
-		res.PO_date = args[4]
-		res.PO_status = args[5]
-		res.ItemId = args[6]
-		res.Item_name = args[7]
-		res.Item_quantity = args[8]
-		res.Price = args[9]
-		res.Buyer_sign = args[10]
-		res.Seller_sign = args[11]
-		res.Seller_Remarks = args[12]
-	}else{
-		errMsg := "{ \"message\" : \""+ transId+ " Not Found.\", \"code\" : \"503\"}"
+	if res.TransID == transId && (res.PO_status == HeldScreeningStatus || res.PO_status == ConfirmedFraudStatus || res.Archive != nil) {
+		errMsg := "{ \"transID\" : \""+transId+"\", \"message\" : \"PO cannot be updated, status is " + res.PO_status + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	problem := ""
+	caller := callerName(stub)
+	if res.TransID != transId {
+		problem = transId + " Not Found."
+	} else if caller == "" || (caller != res.BuyerName && caller != res.SellerName) {
+		problem = "Only the buyer or the seller can update a PO"
+	} else if res.Buyer_sign == "true" || res.Seller_sign == "true" {
+		problem = "PO is already signed, use amend_po to change it"
+	}
+	if problem != "" {
+		errMsg := "{ \"transID\" : \""+transId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
 		} 
 		return nil, nil
 	}
+	fmt.Println("PO found with transId : " + transId)
+	res.ExpectedDeliveryDate = args[1]
+	res.PO_date = args[2]
+	res.Seller_Remarks = args[3]
+	err = computePOTotals(&res)
+	if err != nil {
+		errMsg := "{ \"message\" : \"" + err.Error() + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
 	
-	//build the PO json string manually
-	po_json := 	`{`+
-		`"transId": "` + res.TransID + `" , `+
-		`"sellerName": "` + res.SellerName + `" , `+
-		`"buyerName": "` + res.BuyerName + `" , `+
-		`"expectedDeliveryDate": "` + res.ExpectedDeliveryDate + `" , `+ 
-		`"po_date": "` + res.PO_date + `" , `+ 
-		`"po_status": "` + res.PO_status + `" , `+ 
-		`"item_id": "` + res.ItemId + `" , `+ 
-		`"item_name": "` + res.Item_name + `" , `+ 
-		`"item_quantity": "` +  res.Item_quantity + `", `+ 
-		`"price": "` + res.Price + `" , `+ 
-		`"buyer_sign": "` + res.Buyer_sign + `" , `+ 
-		`"seller_sign": "` + res.Seller_sign + `" , `+ 
-		`"seller_remarks": "` +  res.Seller_Remarks + `" `+ 
-	`}`
-	err = stub.PutState(transId, []byte(po_json))									//store PO with id as key
+	poAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(transId, poAsBytes)									//store PO with id as key
 	if err != nil {
 		return nil, err
 	}
@@ -502,8 +630,8 @@ func (t *ManagePO) update_po(stub shim.ChaincodeStubInterface, args []string) ([
 // ============================================================================================================================
 func (t *ManagePO) create_po(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	var err error
-	if len(args) != 12 {
-		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 12\", \"code\" : \"503\"}"
+	if len(args) != 10 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 10\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
@@ -511,83 +639,103 @@ func (t *ManagePO) create_po(stub shim.ChaincodeStubInterface, args []string) ([
 		return nil, nil
 	}
 	fmt.Println("start create_po")
-	/*if len(args[0]) <= 0 {
-		return nil, errors.New("1st argument must be a non-empty string")
-	}
-	if len(args[1]) <= 0 {
-		return nil, errors.New("2nd argument must be a non-empty string")
-	}
-	if len(args[2]) <= 0 {
-		return nil, errors.New("3rd argument must be a non-empty string")
+	transId := args[0]
+	sellerName := args[1]
+	buyerName := args[2]
+	expectedDeliveryDate := args[3]
+	po_date := args[4]
+	po_status := args[5]
+	line_items := args[6]						// JSON array of line items
+	buyer_sign := args[7]
+	seller_sign := args[8]
+	agreementChaincode := args[9]					// name of the Agreement chaincode holding the fraud list
+	seller_remarks := "NA"
+
+	poAsBytes, err := stub.GetState(transId)
+	if err != nil {
+		return nil, errors.New("Failed to get PO transID")
 	}
-	if len(args[3]) <= 0 {
-		return nil, errors.New("4th argument must be a non-empty string")
+
+	res := PO{}
+	json.Unmarshal(poAsBytes, &res)
+	if res.TransID == transId{
+		errMsg := "{ \"message\" : \"This PO arleady exists\", \"code\" : \"503\"}"
+		err := stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil				//all stop a PO by this name exists
 	}
-	if len(args[4]) <= 0 {
-		return nil, errors.New("5th argument must be a non-empty string")
+
+	// the caller can only sign for its own side, the PO is Accepted once both sides have signed through sign_po
+	problem := ""
+	if buyer_sign == "true" && callerName(stub) != buyerName {
+		problem = "Only the buyer can sign for the buyer"
+	} else if seller_sign == "true" && callerName(stub) != sellerName {
+		problem = "Only the seller can sign for the seller"
+	} else if po_status == "Accepted" && (buyer_sign != "true" || seller_sign != "true") {
+		problem = "A PO is Accepted only once the buyer and the seller have signed"
 	}
-	if len(args[5]) <= 0 {
-		return nil, errors.New("6th argument must be a non-empty string")
+	if problem != "" {
+		errMsg := "{ \"transID\" : \""+transId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
 	}
-	if len(args[6]) <= 0 {
-		return nil, errors.New("7th argument must be a non-empty string")
+	lines, err := parseLineItems(line_items)
+	if err != nil {
+		errMsg := "{ \"message\" : \"" + err.Error() + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
 	}
-	if len(args[7]) <= 0 {
-		return nil, errors.New("8th argument must be a non-empty string")
+	po := PO{
+		TransID: transId,
+		SellerName: sellerName,
+		BuyerName: buyerName,
+		ExpectedDeliveryDate: expectedDeliveryDate,
+		PO_status: po_status,
+		PO_date: po_date,
+		Line_items: lines,
+		Buyer_sign: buyer_sign,
+		Seller_sign: seller_sign,
+		Seller_Remarks: seller_remarks,
+		Version: "1",
 	}
-	if len(args[8]) <= 0 {
-		return nil, errors.New("9th argument must be a non-empty string")
-		}*/
-		transId := args[0]
-		sellerName := args[1]
-		buyerName := args[2]
-		expectedDeliveryDate := args[3]
-		po_date := args[4]
-		po_status := args[5]
-		item_id := args[6]
-		item_name := args[7]
-		item_quantity := args[8]
-		price := args[9]
-		buyer_sign := args[10]
-		seller_sign := args[11]
-		seller_remarks := "NA"
-
-		poAsBytes, err := stub.GetState(transId)
+	err = computePOTotals(&po)
+	if err != nil {
+		errMsg := "{ \"message\" : \"" + err.Error() + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
-			return nil, errors.New("Failed to get PO transID")
+			return nil, err
 		}
-	
-		res := PO{}
-		json.Unmarshal(poAsBytes, &res)
-		if res.TransID == transId{
-			errMsg := "{ \"message\" : \"This PO arleady exists\", \"code\" : \"503\"}"
-			err := stub.SetEvent("errEvent", []byte(errMsg))
-			if err != nil {
-				return nil, err
-			} 
-		return nil, nil				//all stop a PO by this name exists
+		return nil, nil
 	}
-	
-	//build the PO json string manually
-	po_json := 	`{`+
-		`"transId": "` + transId + `" , `+
-		`"sellerName": "` + sellerName + `" , `+
-		`"buyerName": "` + buyerName + `" , `+
-		`"expectedDeliveryDate": "` + expectedDeliveryDate + `" , `+ 
-		`"po_date": "` + po_date + `" , `+ 
-		`"po_status": "` + po_status + `" , `+ 
-		`"item_id": "` + item_id + `" , `+ 
-		`"item_name": "` + item_name + `" , `+ 
-		`"item_quantity": "` +  item_quantity + `", `+ 
-		`"price": "` + price + `" , `+ 
-		`"buyer_sign": "` + buyer_sign + `" , `+ 
-		`"seller_sign": "` + seller_sign + `" , `+ 
-		`"seller_remarks": "` +  seller_remarks + `" `+ 
-	`}`
+
+	// Screen buyer and seller against the fraud list
+	f := "screen_parties"
+	queryArgs := util.ToChaincodeArgs(f, "buyer", buyerName, "seller", sellerName)
+	hitsAsBytes, err := stub.QueryChaincode(agreementChaincode, queryArgs)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to screen PO parties. Got error: %s", err.Error())
+		fmt.Println(errStr)
+		return nil, errors.New(errStr)
+	}
+	var hits []ScreeningHit
+	json.Unmarshal(hitsAsBytes, &hits)
+	if len(hits) > 0 {
+		po.Screening = &Screening{Status: "Held", Hits: hits, PreviousStatus: po.PO_status}
+		po.PO_status = HeldScreeningStatus
+	}
+	po_json, _ := json.Marshal(po)
 	
 	fmt.Print("po_json in bytes array: ")
-	fmt.Println([]byte(po_json))
-	err = stub.PutState(transId, []byte(po_json))									//store PO with transId as key
+	fmt.Println(po_json)
+	err = stub.PutState(transId, po_json)									//store PO with transId as key
 	if err != nil {
 		return nil, err
 	}
@@ -615,6 +763,9 @@ func (t *ManagePO) create_po(stub shim.ChaincodeStubInterface, args []string) ([
 	}
 
 	tosend := "{ \"transID\" : \""+transId+"\", \"message\" : \"PO created succcessfully\", \"code\" : \"200\"}"
+	if po.Screening != nil {
+		tosend = "{ \"transID\" : \""+transId+"\", \"message\" : \"PO created and held for fraud screening\", \"code\" : \"200\"}"
+	}
 	err = stub.SetEvent("evtsender", []byte(tosend))
 	if err != nil {
 		return nil, err
@@ -623,3 +774,502 @@ func (t *ManagePO) create_po(stub shim.ChaincodeStubInterface, args []string) ([
 	fmt.Println("end create_po")
 	return nil, nil
 }
+// ============================================================================================================================
+// amend_po - create a new version of a PO, keeping the previous one, both parties have to sign the new version
+// ============================================================================================================================
+func (t *ManagePO) amend_po(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// amend_po("transId", "amendedBy", "expectedDeliveryDate", "line_items", "amendment_date", "remarks")
+	var err error
+	fmt.Println("start amend_po")
+	if len(args) != 6 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 6\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	transId := args[0]
+	amendedBy := args[1]
+	poAsBytes, err := stub.GetState(transId)
+	if err != nil {
+		return nil, errors.New("Failed to get PO transID")
+	}
+	res := PO{}
+	json.Unmarshal(poAsBytes, &res)
+	if res.TransID != transId {
+		errMsg := "{ \"message\" : \""+ transId+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if res.Archive != nil {
+		errMsg := "{ \"transID\" : \""+transId+"\", \"message\" : \"PO is archived\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if res.PO_status == HeldScreeningStatus || res.PO_status == ConfirmedFraudStatus {
+		errMsg := "{ \"transID\" : \""+transId+"\", \"message\" : \"PO cannot be amended, status is " + res.PO_status + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if (amendedBy != res.BuyerName && amendedBy != res.SellerName) || callerName(stub) != amendedBy {
+		errMsg := "{ \"transID\" : \""+transId+"\", \"message\" : \"Only the buyer or the seller can amend a PO\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	lines, err := parseLineItems(args[3])
+	if err != nil {
+		errMsg := "{ \"message\" : \"" + err.Error() + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	version, err := strconv.Atoi(res.Version)
+	if err != nil {
+		version = 1										// POs created before versioning
+		res.Version = "1"
+	}
+
+	// keep the current version before replacing it
+	err = stub.PutState(POVersionPrefix + transId + "_" + res.Version, poAsBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	amended := res
+	amended.ExpectedDeliveryDate = args[2]
+	amended.Line_items = lines
+	amended.Amendment_date = args[4]
+	amended.Seller_Remarks = args[5]
+	amended.Amended_by = amendedBy
+	amended.Version = strconv.Itoa(version + 1)
+	amended.PO_status = "Amended"
+	amended.Buyer_sign = "false"
+	amended.Seller_sign = "false"
+	err = computePOTotals(&amended)
+	if err != nil {
+		errMsg := "{ \"message\" : \"" + err.Error() + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	poAsBytes, _ = json.Marshal(amended)
+	err = stub.PutState(transId, poAsBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"transID\" : \""+transId+"\", \"version\" : \""+amended.Version+"\", \"message\" : \"PO amended succcessfully, awaiting buyer and seller signatures\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end amend_po")
+	return nil, nil
+}
+// ============================================================================================================================
+// sign_po - buyer or seller signs the current version of a PO, the PO is Accepted once both have signed
+// ============================================================================================================================
+func (t *ManagePO) sign_po(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// sign_po("transId", "version", "signer")
+	var err error
+	fmt.Println("start sign_po")
+	if len(args) != 3 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 3\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	transId := args[0]
+	version := args[1]
+	signer := args[2]
+	poAsBytes, err := stub.GetState(transId)
+	if err != nil {
+		return nil, errors.New("Failed to get PO transID")
+	}
+	res := PO{}
+	json.Unmarshal(poAsBytes, &res)
+	if res.TransID != transId {
+		errMsg := "{ \"message\" : \""+ transId+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if res.Archive != nil {
+		errMsg := "{ \"transID\" : \""+transId+"\", \"message\" : \"PO is archived\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if res.PO_status == HeldScreeningStatus || res.PO_status == ConfirmedFraudStatus {
+		errMsg := "{ \"transID\" : \""+transId+"\", \"message\" : \"PO cannot be signed, status is " + res.PO_status + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if res.Version == "" {
+		res.Version = "1"
+	}
+	if res.Version != version {
+		errMsg := "{ \"transID\" : \""+transId+"\", \"message\" : \"Version " + version + " is not the current version (" + res.Version + ")\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	// the caller's certificate must belong to the party signing
+	if callerName(stub) != signer {
+		errMsg := "{ \"transID\" : \""+transId+"\", \"message\" : \"Caller is not " + signer + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if signer == res.BuyerName {
+		res.Buyer_sign = "true"
+	} else if signer == res.SellerName {
+		res.Seller_sign = "true"
+	} else {
+		errMsg := "{ \"transID\" : \""+transId+"\", \"message\" : \"" + signer + " is not a party to this PO\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if res.Buyer_sign == "true" && res.Seller_sign == "true" {
+		res.PO_status = "Accepted"
+	}
+	poAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(transId, poAsBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"transID\" : \""+transId+"\", \"version\" : \""+res.Version+"\", \"message\" : \"PO signed by " + signer + " succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end sign_po")
+	return nil, nil
+}
+// ============================================================================================================================
+// review_screening - a compliance user clears or confirms the fraud list hit that holds a PO
+// ============================================================================================================================
+func (t *ManagePO) review_screening(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// review_screening("transId", "clear|confirm", "reason", "reviewedAt")
+	var err error
+	fmt.Println("start review_screening")
+	if len(args) != 4 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 4\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	transId := args[0]
+	decision := args[1]
+	reason := args[2]
+	reviewedAt := args[3]
+	reviewer, ok := complianceUser(stub)
+	if !ok {
+		errMsg := "{ \"transID\" : \""+transId+"\", \"message\" : \"Only a compliance user can review a screening hit\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if (decision != "clear" && decision != "confirm") || reason == "" {
+		errMsg := "{ \"transID\" : \""+transId+"\", \"message\" : \"Expecting decision clear or confirm with a reason\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	poAsBytes, err := stub.GetState(transId)
+	if err != nil {
+		return nil, errors.New("Failed to get PO transID")
+	}
+	res := PO{}
+	json.Unmarshal(poAsBytes, &res)
+	if res.TransID != transId || res.Screening == nil || res.PO_status != HeldScreeningStatus {
+		errMsg := "{ \"message\" : \""+ transId+ " is not held for screening.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if decision == "clear" {
+		res.Screening.Status = "Cleared"
+		res.PO_status = res.Screening.PreviousStatus
+	} else {
+		res.Screening.Status = "Confirmed"
+		res.PO_status = ConfirmedFraudStatus
+	}
+	res.Screening.Reason = reason
+	res.Screening.ReviewedBy = reviewer
+	res.Screening.ReviewedAt = reviewedAt
+	poAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(transId, poAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"transID\" : \""+transId+"\", \"message\" : \"Screening reviewed, status is " + res.PO_status + "\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end review_screening")
+	return nil, nil
+}
+// ============================================================================================================================
+// getPO_versions - get every version of a PO, oldest first, with the changes between each pair of versions
+// ============================================================================================================================
+func (t *ManagePO) getPO_versions(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start getPO_versions")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 'transId' as an argument\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	transId := args[0]
+	poAsBytes, err := stub.GetState(transId)
+	if err != nil {
+		return nil, errors.New("Failed to get PO transID")
+	}
+	current := PO{}
+	json.Unmarshal(poAsBytes, &current)
+	if current.TransID != transId {
+		errMsg := "{ \"message\" : \""+ transId + " not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	latest, err := strconv.Atoi(current.Version)
+	if err != nil {
+		latest = 1
+		current.Version = "1"
+	}
+	var versions []PO
+	for v := 1; v < latest; v++ {
+		versionAsBytes, err := stub.GetState(POVersionPrefix + transId + "_" + strconv.Itoa(v))
+		if err != nil {
+			return nil, errors.New("Failed to get version " + strconv.Itoa(v) + " of " + transId)
+		}
+		previous := PO{}
+		json.Unmarshal(versionAsBytes, &previous)
+		if previous.Version == "" {
+			previous.Version = strconv.Itoa(v)
+		}
+		versions = append(versions, previous)
+	}
+	versions = append(versions, current)
+
+	var diffs []POVersionDiff
+	for i := 1; i < len(versions); i++ {
+		diffs = append(diffs, POVersionDiff{
+			FromVersion: versions[i-1].Version,
+			ToVersion: versions[i].Version,
+			Changes: diffPO(versions[i-1], versions[i]),
+		})
+	}
+	history := struct{
+		TransID string `json:"transId"`
+		Versions []PO `json:"versions"`
+		Diffs []POVersionDiff `json:"diffs"`
+	}{transId, versions, diffs}
+	jsonResp, _ := json.Marshal(history)
+	fmt.Println("end getPO_versions")
+	return jsonResp, nil
+}
+// ============================================================================================================================
+// parseLineItems - decode the line items argument of create_po/update_po
+// ============================================================================================================================
+func parseLineItems(input string) ([]LineItem, error) {
+	var lines []LineItem
+	err := json.Unmarshal([]byte(input), &lines)
+	if err != nil {
+		return nil, errors.New("Line items must be a JSON array")
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("A PO needs at least one line item")
+	}
+	for i := range lines {
+		if lines[i].LineNo == "" {
+			lines[i].LineNo = strconv.Itoa(i+1)
+		}
+		if !ISOCurrencies[lines[i].Currency] {
+			return nil, errors.New("Line " + lines[i].LineNo + " needs an ISO currency code")
+		}
+	}
+	return lines, nil
+}
+// ============================================================================================================================
+// computePOTotals - compute line totals, tax and PO totals from the line items
+// ============================================================================================================================
+func computePOTotals(po *PO) error {
+	var subTotal, taxTotal float64
+	currency := ""
+	for i := range po.Line_items {
+		line := &po.Line_items[i]
+		quantity, err := strconv.ParseFloat(line.Quantity, 64)
+		if err != nil || quantity <= 0 {
+			return errors.New("Invalid quantity on line " + line.LineNo)
+		}
+		unitPrice, err := strconv.ParseFloat(line.Unit_price, 64)
+		if err != nil || unitPrice < 0 {
+			return errors.New("Invalid unit price on line " + line.LineNo)
+		}
+		taxRate := 0.0
+		if line.Tax_rate != "" {
+			taxRate, err = strconv.ParseFloat(line.Tax_rate, 64)
+			if err != nil || taxRate < 0 {
+				return errors.New("Invalid tax rate on line " + line.LineNo)
+			}
+		}
+		if !ISOCurrencies[line.Currency] {
+			return errors.New("Line " + line.LineNo + " needs an ISO currency code")
+		}
+		if currency == "" {
+			currency = line.Currency
+		} else if line.Currency != currency {
+			return errors.New("All line items of a PO must use the same currency")
+		}
+		lineTotal := quantity * unitPrice
+		taxAmount := lineTotal * taxRate / 100
+		line.Line_total = strconv.FormatFloat(lineTotal, 'f', 2, 64)
+		line.Tax_amount = strconv.FormatFloat(taxAmount, 'f', 2, 64)
+		subTotal += lineTotal
+		taxTotal += taxAmount
+	}
+	po.Currency = currency
+	po.Sub_total = strconv.FormatFloat(subTotal, 'f', 2, 64)
+	po.Tax_total = strconv.FormatFloat(taxTotal, 'f', 2, 64)
+	po.Total_value = strconv.FormatFloat(subTotal + taxTotal, 'f', 2, 64)
+	return nil
+}
+// ============================================================================================================================
+// diffPO - list the terms that changed between two versions of a PO
+// ============================================================================================================================
+func diffPO(from PO, to PO) []FieldChange {
+	var changes []FieldChange
+	compare := func(field string, a string, b string) {
+		if a != b {
+			changes = append(changes, FieldChange{field, a, b})
+		}
+	}
+	compare("sellerName", from.SellerName, to.SellerName)
+	compare("buyerName", from.BuyerName, to.BuyerName)
+	compare("expectedDeliveryDate", from.ExpectedDeliveryDate, to.ExpectedDeliveryDate)
+	compare("po_status", from.PO_status, to.PO_status)
+	compare("po_date", from.PO_date, to.PO_date)
+	compare("currency", from.Currency, to.Currency)
+	compare("sub_total", from.Sub_total, to.Sub_total)
+	compare("tax_total", from.Tax_total, to.Tax_total)
+	compare("total_value", from.Total_value, to.Total_value)
+	compare("seller_remarks", from.Seller_Remarks, to.Seller_Remarks)
+
+	fromLines := make(map[string]LineItem)
+	for _, line := range from.Line_items {
+		fromLines[line.LineNo] = line
+	}
+	toLines := make(map[string]LineItem)
+	for _, line := range to.Line_items {
+		toLines[line.LineNo] = line
+	}
+	for _, line := range from.Line_items {
+		if _, ok := toLines[line.LineNo]; !ok {
+			compare("line_items[" + line.LineNo + "]", line.Item_name, "")
+		}
+	}
+	for _, line := range to.Line_items {
+		old, ok := fromLines[line.LineNo]
+		if !ok {
+			compare("line_items[" + line.LineNo + "]", "", line.Item_name)
+			continue
+		}
+		prefix := "line_items[" + line.LineNo + "]."
+		compare(prefix + "item_id", old.ItemId, line.ItemId)
+		compare(prefix + "item_name", old.Item_name, line.Item_name)
+		compare(prefix + "quantity", old.Quantity, line.Quantity)
+		compare(prefix + "unit", old.Unit, line.Unit)
+		compare(prefix + "unit_price", old.Unit_price, line.Unit_price)
+		compare(prefix + "currency", old.Currency, line.Currency)
+		compare(prefix + "tax_rate", old.Tax_rate, line.Tax_rate)
+	}
+	return changes
+}
+// ============================================================================================================================
+// complianceUser - name of the caller when its certificate carries the compliance role
+// ============================================================================================================================
+func complianceUser(stub shim.ChaincodeStubInterface) (string, bool) {
+	role, err := stub.ReadCertAttribute("role")
+	if err != nil || string(role) != "compliance" {
+		return "", false
+	}
+	username, err := stub.ReadCertAttribute("username")
+	if err != nil {
+		return "", false
+	}
+	return string(username), true
+}
+// ============================================================================================================================
+// callerName - username attribute of the caller's certificate, "" when it has none
+// ============================================================================================================================
+func callerName(stub shim.ChaincodeStubInterface) string {
+	username, err := stub.ReadCertAttribute("username")
+	if err != nil {
+		return ""
+	}
+	return string(username)
+}
+// ============================================================================================================================
+// isDate - whether a value is a YYYY-MM-DD date
+// ============================================================================================================================
+func isDate(value string) bool {
+	_, err := time.Parse(DateLayout, value)
+	return err == nil
+}
+// ============================================================================================================================
+// includeArchived - whether the argument at position n asks a list query for archived records too
+// ============================================================================================================================
+func includeArchived(args []string, n int) bool {
+	return len(args) > n && args[n] == IncludeArchived
+}
@@ -23,11 +23,16 @@ import (
 "errors"
 "fmt"
 "strconv"
+"sort"
+"math"
+"time"
 "encoding/json"
-	//"time"
-	//"strings"
+"encoding/hex"
+"crypto/sha256"
+"strings"
 
 "github.com/hyperledger/fabric/core/chaincode/shim"
+"github.com/hyperledger/fabric/core/util"
 )
 
 // ManagePayment example simple Chaincode implementation
@@ -36,9 +41,48 @@ type ManagePayment struct {
 
 var PaymentIndexStr = "_PaymentIndex"	//name for the key/value that will store a list of all known payments
 
-var AccountIndexStr = "_AccountIndex"	//name for the key/value that will store a list of all known accounts
-var BuyerAccountNumber = "965832147012"
-var SellerAccountNumber = "741258963512"
+var AccountIndexStr = "_AccountIndex"	//name for the key/value that will store a list of all known account numbers
+var AccountPrefix = "_Account_"		//accounts are stored under this prefix and their account number
+var TransferPrefix = "_Transfer_"	//transfers are stored under this prefix and their transfer ID
+var FXRatesPrefix = "_FXRates_"		//the FX rates of a base currency are stored under this prefix and its ISO code
+// ISO 4217 codes accepted for amounts
+var ISOCurrencies = map[string]bool{"USD": true, "EUR": true, "GBP": true, "JPY": true, "CHF": true, "CNY": true, "INR": true, "AUD": true,
+	"CAD": true, "SGD": true, "HKD": true, "AED": true, "SAR": true, "NZD": true, "SEK": true, "NOK": true, "DKK": true, "ZAR": true,
+	"BRL": true, "MXN": true, "KRW": true, "RUB": true, "TRY": true, "THB": true, "MYR": true, "IDR": true, "PHP": true, "PLN": true}
+var HeldScreeningStatus = "Held – Screening"	//status of a payment whose parties matched the fraud list
+var ConfirmedFraudStatus = "Rejected – Fraud"
+var LateDeliveryHoldStatus = "Held – Late Delivery"	//status of a payment whose agreement was not delivered on time
+var RefundSuffix = "_refund"		//the refund transfer of a payment is stored under its payment ID and this suffix
+var OpeningCreditPrefix = "_opening_"	//the opening credit of an account is stored under this prefix and its account number
+var IssuanceAccountPrefix = "_Issuance_"	//account of a currency that admins fund opening credits from, its negative balance is the money issued
+var IssuanceOwner = "issuance"		//owner of the issuance accounts
+var DisputeIndexStr = "_DisputeIndex"	//name for the key/value that will store a list of all known dispute IDs
+var DisputePrefix = "_Dispute_"		//disputes are stored under this prefix and their dispute ID
+var DisputeTransferInfix = "_dispute_"	//dispute refunds are stored under the payment ID, this infix and the dispute ID
+var DateLayout = "2006-01-02"
+var IncludeArchived = "include_archived"	//optional last argument of the list queries to return archived records too
+
+// Statuses of a Payment and the statuses each one can move to
+var PaymentInitiatedStatus = "Initiated"
+var PaymentApprovedStatus = "Approved by buyer bank"
+var PaymentSettledStatus = "Settled"
+var PaymentFailedStatus = "Failed"
+var PaymentOverdueStatus = "Overdue"
+var PaymentRefundedStatus = "Refunded"
+// Decisions of a bank on a dispute and the statuses of a Dispute
+var DecisionRefund = "refund"
+var DecisionPartialRefund = "partial_refund"
+var DecisionReject = "reject"
+var DisputeOpenStatus = "Open"
+var DisputeRefundedStatus = "Refunded"
+var DisputePartiallyRefundedStatus = "Partially Refunded"
+var DisputeRejectedStatus = "Rejected"
+var PaymentTransitions = map[string][]string{
+	PaymentInitiatedStatus: {PaymentApprovedStatus, PaymentFailedStatus, PaymentOverdueStatus},
+	PaymentApprovedStatus: {PaymentSettledStatus, PaymentFailedStatus, PaymentOverdueStatus},
+	PaymentOverdueStatus: {PaymentSettledStatus, PaymentFailedStatus},
+	PaymentSettledStatus: {PaymentRefundedStatus},
+}
 
 type Payment struct{
 	PaymentID string `json:"paymentId"`					//the fieldtags are needed to keep case from bouncing around
@@ -54,13 +98,179 @@ type Payment struct{
 	BuyerBank_sign string `json:"buyerBank_sign"`
 	BB_name string `json:"bb_name"`
 	SB_name string `json:"sb_name"`
+	Screening *Screening `json:"screening,omitempty"`
+	Hold *PaymentHold `json:"hold,omitempty"`
+	StatusHistory []StatusChange `json:"statusHistory"`
+	InstalmentNo string `json:"instalmentNo"`			// instalment line of the Agreement this Payment settles, "" when it has no schedule
+	AgreementChaincode string `json:"agreementChaincode"`	// Agreement chaincode the settlement is recorded in
+	Currency string `json:"currency"`				// ISO code of AmountTransferred, the Agreement currency
+	SettledAmount string `json:"settledAmount"`			// amount credited to the seller account
+	SettledCurrency string `json:"settledCurrency"`
+	SettlementFXRate string `json:"settlementFXRate"`		// rate from Currency to SettledCurrency
+	DebitedAmount string `json:"debitedAmount"`			// amount debited from the buyer account
+	DebitedCurrency string `json:"debitedCurrency"`
+	DebitFXRate string `json:"debitFXRate"`
+	RefundedAmount string `json:"refundedAmount"`		// refunded through disputes, in Currency
+	OpenDispute string `json:"openDispute"`			// ID of the open dispute on this Payment
+	Archive *Archive `json:"archive,omitempty"`		// set instead of deleting the Payment
+}
+
+type Archive struct{					// Why and when a record was archived
+	Reason string `json:"reason"`
+	ArchivedBy string `json:"archivedBy"`
+	ArchivedAt string `json:"archivedAt"`
+}
+
+type Dispute struct{					// Buyer's dispute of a Payment or an Agreement, adjudicated by a bank
+	DisputeID string `json:"disputeId"`
+	Target string `json:"target"`				// payment or agreement
+	PaymentID string `json:"paymentId"`			// "" when the whole Agreement is disputed
+	AgreementID string `json:"agreementId"`
+	AgreementChaincode string `json:"agreementChaincode"`
+	BuyerName string `json:"buyerName"`
+	SellerName string `json:"sellerName"`
+	BB_name string `json:"bb_name"`
+	SB_name string `json:"sb_name"`
+	Reason string `json:"reason"`
+	Dispute_status string `json:"dispute_status"`
+	OpenedBy string `json:"openedBy"`
+	OpenedAt string `json:"openedAt"`
+	Evidence []Evidence `json:"evidence"`
+	Decision string `json:"decision"`
+	Remarks string `json:"remarks"`
+	ResolvedBy string `json:"resolvedBy"`
+	ResolvedAt string `json:"resolvedAt"`
+	Refunds []DisputeRefund `json:"refunds"`
+}
+
+type Evidence struct{
+	Name string `json:"name"`
+	Hash string `json:"hash"`				// sha256 of the document, the document itself stays off the ledger
+	AddedBy string `json:"addedBy"`
+	AddedAt string `json:"addedAt"`
+}
+
+type DisputeRefund struct{				// Compensating transfer posted by a resolution
+	PaymentID string `json:"paymentId"`
+	TransferID string `json:"transferId"`
+	Amount string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// Use as Object.Rates["EUR"], the same shape as the rates used by the TCM Allocation chaincode
+type CurrencyConversion struct {
+	Base  string             `json:"base"`
+	Date  string             `json:"date"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+type AgreementBalance struct{				// Subset of the balance returned by getAgreementBalance
+	Outstanding string `json:"outstanding"`
+	Instalments []InstalmentBalance `json:"instalments"`
+}
+
+type InstalmentBalance struct{
+	LineNo string `json:"lineNo"`
+	Trigger string `json:"trigger"`
+	Due bool `json:"due"`
+	Outstanding string `json:"outstanding"`
+}
+
+type StatusChange struct{
+	From string `json:"from"`
+	To string `json:"to"`
+	At string `json:"at"`
+	By string `json:"by"`
+}
+
+type PaymentHold struct{					// Late delivery hold, released by the buyer
+	Reason string `json:"reason"`
+	PreviousStatus string `json:"previousStatus"`		// status restored when the hold is released
+	HeldAt string `json:"heldAt"`
+	ReleasedBy string `json:"releasedBy"`
+	ReleaseReason string `json:"releaseReason"`
+	ReleasedAt string `json:"releasedAt"`
+}
+
+type Screening struct{						// Outcome of screening the parties against the fraud list of the Agreement chaincode
+	Status string `json:"status"`				// Held, Cleared or Confirmed
+	Hits []ScreeningHit `json:"hits"`
+	PreviousStatus string `json:"previousStatus"`		// status restored when the hit is cleared
+	Reason string `json:"reason"`
+	ReviewedBy string `json:"reviewedBy"`
+	ReviewedAt string `json:"reviewedAt"`
+}
+
+type ScreeningHit struct{
+	Role string `json:"role"`
+	PartyName string `json:"partyName"`
+	FraudID string `json:"fraudId"`
+	FraudName string `json:"fraudName"`
+	MatchType string `json:"matchType"`
+	Score string `json:"score"`
+}
+
+type Agreement struct{					// Subset of the Agreement returned by the Agreement chaincode
+	AgreementID string `json:"agreementId"`
+	TransID string `json:"transId"`
+	Agreement_status string `json:"agreement_status"`
+	BuyerName string `json:"buyer_name"`
+	SellerName string `json:"seller_name"`
+	BB_name string `json:"bb_name"`
+	SB_name string `json:"sb_name"`
+	Buyer_sign string `json:"buyer_sign"`
+	BuyerBank_sign string `json:"buyerBank_sign"`
+	Seller_sign string `json:"seller_sign"`
+	SellerBank_sign string `json:"sellerBank_sign"`
+	Currency string `json:"currency"`
+	Delivery_date string `json:"delivery_date"`
+	Shipment *Shipment `json:"shipment,omitempty"`
+	Archive *Archive `json:"archive,omitempty"`
+}
+
+type Shipment struct{					// Subset of the Agreement shipment
+	Status string `json:"status"`
+	Late bool `json:"late"`
+	LateSince string `json:"lateSince"`
+}
+
+type TradeLifecycle struct{				// PO, Agreements and Payments of one trade
+	TradeID string `json:"tradeId"`
+	PO json.RawMessage `json:"po"`
+	Agreements map[string]json.RawMessage `json:"agreements"`
+	Payments map[string]json.RawMessage `json:"payments"`
+	Statuses []TradeStage `json:"statuses"`
+}
+
+type TradeStage struct{
+	Stage string `json:"stage"`				// PO, Agreement or Payment
+	ID string `json:"id"`
+	Status string `json:"status"`
 }
 
-type AccountInfo struct{
-	BuyerAccountNumber string `json:"buyerAccountNumber"`
-	BuyerAccountBalance string `json:"buyerAccountBalance"`
-	SellerAccountNumber string `json:"sellerAccountNumber"`
-	SellerAccountBalance string `json:"sellerAccountBalance"`
+type Account struct{					// An account of the ledger, balances only change through transferFunds
+	AccountNumber string `json:"accountNumber"`
+	Owner string `json:"owner"`
+	Currency string `json:"currency"`
+	Balance string `json:"balance"`
+}
+
+type Transfer struct{					// Double-entry record of money moved between two accounts
+	TransferID string `json:"transferId"`
+	PaymentID string `json:"paymentId"`
+	Currency string `json:"currency"`			// currency the amount was given in
+	Amount string `json:"amount"`
+	At string `json:"at"`
+	Entries []LedgerEntry `json:"entries"`		// one debit and one credit, equal once converted back to Currency
+}
+
+type LedgerEntry struct{
+	AccountNumber string `json:"accountNumber"`
+	Side string `json:"side"`				// debit or credit
+	Amount string `json:"amount"`				// in the account currency
+	Currency string `json:"currency"`
+	FXRate string `json:"fxRate"`				// rate used from the Transfer currency
+	BalanceAfter string `json:"balanceAfter"`
 }
 // ============================================================================================================================
 // Main
@@ -76,7 +286,6 @@ func main() {
 // Init - reset all the things
 // ============================================================================================================================
 func (t *ManagePayment) Init(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
-	var balance string
 	var err error
 
 	if len(args) != 1 {
@@ -89,28 +298,22 @@ func (t *ManagePayment) Init(stub shim.ChaincodeStubInterface, function string,
 	}
 	// Initialize the chaincode
 	
-	balance = args[0]
 	fmt.Println("ManagePayment chaincode is deployed successfully.")
 
-	//build the Account json string manually
-	account := `{`+
-		`"buyerAccountNumber" : "` +  BuyerAccountNumber  + `", `+
-		`"buyerAccountBalance" : "` + balance   + `", `+
-		`"sellerAccountNumber" : "` +  SellerAccountNumber  + `", `+
-		`"sellerAccountBalance" : "` + balance   + `"`+
-		`}`
-
-	err = stub.PutState(AccountIndexStr, []byte(account))			//store Account with id as key
-	if err != nil {
-		return nil, err
-	}
-	
 	var empty []string
 	jsonAsBytes, _ := json.Marshal(empty)								//marshal an emtpy array of strings to clear the index
 	err = stub.PutState(PaymentIndexStr, jsonAsBytes)
 	if err != nil {
 		return nil, err
 	}
+	err = stub.PutState(AccountIndexStr, jsonAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	err = stub.PutState(DisputeIndexStr, jsonAsBytes)
+	if err != nil {
+		return nil, err
+	}
 	tosend := "{ \"message\" : \"ManagePayment chaincode is deployed successfully.\", \"code\" : \"200\"}"
 	err = stub.SetEvent("evtsender", []byte(tosend))
 	if err != nil {
@@ -142,6 +345,30 @@ func (t *ManagePayment) Invoke(stub shim.ChaincodeStubInterface, function string
 		return t.deletePayment(stub, args)
 	}else if function == "updatePayment" {									//create a new trade order
 		return t.updatePayment(stub, args)
+	}else if function == "reviewScreening" {									//compliance clears or confirms a fraud list hit
+		return t.reviewScreening(stub, args)
+	}else if function == "holdForLateDelivery" {									//hold a payment whose agreement is delivered late
+		return t.holdForLateDelivery(stub, args)
+	}else if function == "approvePayment" {									//buyer bank approves an initiated payment
+		return t.approvePayment(stub, args)
+	}else if function == "settlePayment" {									//move the money of an approved payment, once
+		return t.settlePayment(stub, args)
+	}else if function == "refundPayment" {									//return the money of a settled payment
+		return t.refundPayment(stub, args)
+	}else if function == "markOverduePayments" {									//sweep payments past their deadline
+		return t.markOverduePayments(stub, args)
+	}else if function == "createAccount" {									//open an account on the ledger
+		return t.createAccount(stub, args)
+	}else if function == "setFXRates" {									//treasury sets the FX rates of a base currency
+		return t.setFXRates(stub, args)
+	}else if function == "openDispute" {									//buyer disputes a payment or an agreement
+		return t.openDispute(stub, args)
+	}else if function == "addDisputeEvidence" {									//buyer or seller adds evidence hashes
+		return t.addDisputeEvidence(stub, args)
+	}else if function == "resolveDispute" {									//bank rejects or refunds a dispute
+		return t.resolveDispute(stub, args)
+	}else if function == "releasePaymentHold" {									//buyer releases a late delivery hold
+		return t.releasePaymentHold(stub, args)
 	}
 	fmt.Println("invoke did not find func: " + function)					//error
 
@@ -166,10 +393,24 @@ func (t *ManagePayment) Query(stub shim.ChaincodeStubInterface, function string,
 		return t.getPaymentByBuyer(stub, args)
 	} else if function == "getPaymentBySeller" {													//read a variable
 		return t.getPaymentBySeller(stub, args)
+	} else if function == "getPaymentByAgreement" {													//read the payments of an agreement
+		return t.getPaymentByAgreement(stub, args)
 	} else if function == "getAllPayment" {													//read a variable
 		return t.getAllPayment(stub, args)
-	} else if function == "getAccountDetails" {													//read a variable
+	} else if function == "getAccountDetails" {													//read an account by account number
 		return t.getAccountDetails(stub, args)
+	} else if function == "getAllAccounts" {													//read every account
+		return t.getAllAccounts(stub, args)
+	} else if function == "getTransfer" {													//read the entries of a transfer
+		return t.getTransfer(stub, args)
+	} else if function == "getFXRatesByBase" {													//read the FX rates of a base currency
+		return t.getFXRatesByBase(stub, args)
+	} else if function == "getDisputeByID" {													//read a dispute
+		return t.getDisputeByID(stub, args)
+	} else if function == "getDisputesByAgreement" {													//read the disputes of an agreement and its payments
+		return t.getDisputesByAgreement(stub, args)
+	} else if function == "getTradeLifecycle" {													//read PO, Agreements and Payments of a trade
+		return t.getTradeLifecycle(stub, args)
 	}
 	fmt.Println("query did not find func: " + function)						//error
 
@@ -221,8 +462,8 @@ func (t *ManagePayment) getPaymentByBuyer(stub shim.ChaincodeStubInterface, args
 	var valIndex Payment
 	var err error
 	fmt.Println("start getPaymentByBuyer")
-	if len(args) != 1 {
-		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"Buyer_Name\" arguments.\", \"code\" : \"503\"}"
+	if len(args) != 1 && len(args) != 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"Buyer_Name\" and optionally \"include_archived\" arguments.\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
@@ -254,9 +495,13 @@ func (t *ManagePayment) getPaymentByBuyer(stub shim.ChaincodeStubInterface, args
 		}
 		fmt.Print("valueAsBytes : ")
 		fmt.Println(valueAsBytes)
+		valIndex = Payment{}
 		json.Unmarshal(valueAsBytes, &valIndex)
 		fmt.Print("valIndex: ")
 		fmt.Print(valIndex)
+		if valIndex.Archive != nil && !includeArchived(args, 1) {
+			continue
+		}
 		if valIndex.BuyerName == buyerName{
 			fmt.Println("Buyer found")
 			jsonResp = jsonResp + "\""+ val + "\":" + string(valueAsBytes[:])
@@ -278,6 +523,7 @@ func (t *ManagePayment) getPaymentByBuyer(stub shim.ChaincodeStubInterface, args
 	fmt.Println("jsonResp : " + jsonResp)
 	fmt.Print("jsonResp in bytes : ")
 	fmt.Println([]byte(jsonResp))
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
 	fmt.Println("end getPaymentByBuyer")
 	return []byte(jsonResp), nil													//send it onward
 }
@@ -290,8 +536,8 @@ func (t *ManagePayment) getPaymentBySeller(stub shim.ChaincodeStubInterface, arg
 	var valIndex Payment
 	var err error
 	fmt.Println("start getPaymentBySeller")
-	if len(args) != 1 {
-		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"Seller_Name\" arguments.\", \"code\" : \"503\"}"
+	if len(args) != 1 && len(args) != 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"Seller_Name\" and optionally \"include_archived\" arguments.\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
@@ -322,9 +568,13 @@ func (t *ManagePayment) getPaymentBySeller(stub shim.ChaincodeStubInterface, arg
 		}
 		fmt.Print("valueAsBytes : ")
 		fmt.Println(valueAsBytes)
+		valIndex = Payment{}
 		json.Unmarshal(valueAsBytes, &valIndex)
 		fmt.Print("valIndex: ")
 		fmt.Print(valIndex)
+		if valIndex.Archive != nil && !includeArchived(args, 1) {
+			continue
+		}
 		if valIndex.SellerName == sellerName{
 			fmt.Println("Seller found")
 			jsonResp = jsonResp + "\""+ val + "\":" + string(valueAsBytes[:])
@@ -347,11 +597,48 @@ func (t *ManagePayment) getPaymentBySeller(stub shim.ChaincodeStubInterface, arg
 	fmt.Println("jsonResp : " + jsonResp)
 	fmt.Print("jsonResp in bytes : ")
 	fmt.Println([]byte(jsonResp))
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
 	fmt.Println("end getPaymentBySeller")
 
 	return []byte(jsonResp), nil											//send it onward
 }
 // ============================================================================================================================
+//  getPaymentByAgreement - get the Payments of an Agreement, keyed by payment ID
+// ============================================================================================================================
+func (t *ManagePayment) getPaymentByAgreement(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start getPaymentByAgreement")
+	if len(args) != 1 && len(args) != 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"agreementId\" and optionally \"include_archived\" arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	var paymentIndex []string
+	paymentIndexAsBytes, err := stub.GetState(PaymentIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Payment index")
+	}
+	json.Unmarshal(paymentIndexAsBytes, &paymentIndex)
+	payments := make(map[string]json.RawMessage)
+	for _, val := range paymentIndex {
+		valueAsBytes, err := stub.GetState(val)
+		if err != nil {
+			return nil, errors.New("{\"Error\":\"Failed to get state for " + val + "\"}")
+		}
+		payment := Payment{}
+		json.Unmarshal(valueAsBytes, &payment)
+		if payment.AgreementID == args[0] && (payment.Archive == nil || includeArchived(args, 1)) {
+			payments[val] = json.RawMessage(valueAsBytes)
+		}
+	}
+	jsonResp, _ := json.Marshal(payments)
+	fmt.Println("end getPaymentByAgreement")
+	return jsonResp, nil
+}
+// ============================================================================================================================
 //  getAllPayment- display details of all Payment from chaincode state
 // ============================================================================================================================
 func (t *ManagePayment) getAllPayment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
@@ -360,7 +647,7 @@ func (t *ManagePayment) getAllPayment(stub shim.ChaincodeStubInterface, args []s
 	var err error
 	fmt.Println("start getAllPayment")
 	if len(args) != 1 {
-		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \" \" arguments.\", \"code\" : \"503\"}"
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \" \" or \"include_archived\" arguments.\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
@@ -384,6 +671,11 @@ func (t *ManagePayment) getAllPayment(stub shim.ChaincodeStubInterface, args []s
 			errResp = "{\"Error\":\"Failed to get state for " + val + "\"}"
 			return nil, errors.New(errResp)
 		}
+		valIndex := Payment{}
+		json.Unmarshal(valueAsBytes, &valIndex)
+		if valIndex.Archive != nil && !includeArchived(args, 0) {
+			continue
+		}
 		fmt.Print("valueAsBytes : ")
 		fmt.Println(valueAsBytes)
 		jsonResp = jsonResp + "\""+ val + "\":" + string(valueAsBytes[:])
@@ -397,354 +689,2090 @@ func (t *ManagePayment) getAllPayment(stub shim.ChaincodeStubInterface, args []s
 	fmt.Println("jsonResp : " + jsonResp)
 	fmt.Print("jsonResp in bytes : ")
 	fmt.Println([]byte(jsonResp))
+	jsonResp = strings.Replace(jsonResp, ",}", "}", -1)
 	fmt.Println("end getAllPayment")
 	return []byte(jsonResp), nil
 											//send it onward
 }
 // ============================================================================================================================
-//  getAccountDetails - get account details from chaincode
+//  setFXRates - store the rates of a base currency, given as a JSON object of ISO code to rate. Treasury users only.
 // ============================================================================================================================
-func (t *ManagePayment) getAccountDetails(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+func (t *ManagePayment) setFXRates(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// setFXRates("base", "date", "{\"EUR\": 0.92, \"GBP\": 0.79}")
 	var err error
-	fmt.Println("start getAccountDetails")
-	
-	accountAsBytes, err := stub.GetState(AccountIndexStr)
+	fmt.Println("start setFXRates")
+	if len(args) != 3 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 3 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	base := args[0]
+	date := args[1]
+	rates := map[string]float64{}
+	problem := ""
+	role, err := stub.ReadCertAttribute("role")
+	if err != nil || string(role) != "treasury" {
+		problem = "Only a treasury user can set FX rates"
+	} else if !ISOCurrencies[base] || !isDate(date) {
+		problem = "Expecting an ISO base currency and a YYYY-MM-DD date"
+	} else if err = json.Unmarshal([]byte(args[2]), &rates); err != nil || len(rates) == 0 {
+		problem = "Expecting a JSON object of currency rates"
+	}
+	for currency, rate := range rates {
+		if problem == "" && (!ISOCurrencies[currency] || currency == base || rate <= 0) {
+			problem = "Invalid rate for " + currency
+		}
+	}
+	if problem != "" {
+		errMsg := "{ \"base\" : \""+base+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	conversion, err := getFXRates(stub, base)
 	if err != nil {
-		return nil, errors.New("Failed to get Account index")
+		return nil, err
 	}
-	fmt.Print("accountAsBytes : ")
-	fmt.Println(accountAsBytes)
-	
-	fmt.Println("end getAccountDetails")
-	return accountAsBytes, nil													//send it onward
+	if conversion.Rates == nil {
+		conversion.Rates = map[string]float64{}
+	}
+	conversion.Base = base
+	conversion.Date = date
+	for currency, rate := range rates {
+		conversion.Rates[currency] = rate
+	}
+	conversionAsBytes, _ := json.Marshal(conversion)
+	err = stub.PutState(FXRatesPrefix + base, conversionAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"base\" : \""+base+"\", \"message\" : \"FX rates set succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end setFXRates")
+	return nil, nil
 }
 // ============================================================================================================================
-//  updateBalance - updates balance for accounts into chaincode
+//  getFXRatesByBase - get the rates stored for a base currency
 // ============================================================================================================================
-func (t *ManagePayment) updateBalance(stub shim.ChaincodeStubInterface, transferAmt string) ([]byte, error) {
-	
-	var accountIndex AccountInfo
+func (t *ManagePayment) getFXRatesByBase(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	var err error
-	fmt.Println("start updateBalance")
-	
-	transferAmount, _ := strconv.ParseFloat(transferAmt, 64)
-	fmt.Println("In updateBalance with transferAmount::")
-	fmt.Println(transferAmount)
-
-	//Get State
-	accountAsBytes, err := stub.GetState(AccountIndexStr)			//get the var from chaincode state
-	if err != nil {
-		errMsg := "{ \"message\" : \"Error while fetching Accounts.\", \"code\" : \"503\"}"
+	fmt.Println("start getFXRatesByBase")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"base\" as an argument.\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
-		} 
+		}
 		return nil, nil
 	}
-	json.Unmarshal(accountAsBytes, &accountIndex)
-	accountBuyerBal, _ := strconv.ParseFloat(accountIndex.BuyerAccountBalance, 64)
-	accountSellerBal, _ := strconv.ParseFloat(accountIndex.SellerAccountBalance, 64)
-	buyerAccountBalance	:= accountBuyerBal - transferAmount
-	sellerAccountBalance := accountSellerBal + transferAmount
-	fmt.Println("In updateBalance after balance calculation::")
-	fmt.Println(buyerAccountBalance)
-	fmt.Println(sellerAccountBalance)
-	
-	//build the Account json string manually
-	account := `{`+
-		`"buyerAccountNumber" : "` +  BuyerAccountNumber  + `", `+
-		`"buyerAccountBalance" : "` + strconv.FormatFloat(buyerAccountBalance, 'f', 2, 64)   + `", `+
-		`"sellerAccountNumber" : "` +  SellerAccountNumber  + `", `+
-		`"sellerAccountBalance" : "` + strconv.FormatFloat(sellerAccountBalance, 'f', 2, 64)   + `" `+
-		`}`
-	fmt.Println("In updateBalance account to commit::" + account)
-
-	err = stub.PutState(AccountIndexStr, []byte(account))			//store Account with id as key
+	conversion, err := getFXRates(stub, args[0])
 	if err != nil {
 		return nil, err
 	}
-	
-	fmt.Println("end updateBalance")
-	return nil, nil													//send it onward
+	if conversion.Base != args[0] {
+		errMsg := "{ \"message\" : \"No FX rates for "+ args[0]+ ".\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	conversionAsBytes, _ := json.Marshal(conversion)
+	fmt.Println("end getFXRatesByBase")
+	return conversionAsBytes, nil
 }
 // ============================================================================================================================
-// Delete - remove a Payment from state
+//  getAccountDetails - get an account by its account number
 // ============================================================================================================================
-func (t *ManagePayment) deletePayment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+func (t *ManagePayment) getAccountDetails(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start getAccountDetails")
 	if len(args) != 1 {
-		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"paymentID\" arguments.\", \"code\" : \"503\"}"
-		err := stub.SetEvent("errEvent", []byte(errMsg))
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"accountNumber\" as an argument.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
-		} 
+		}
 		return nil, nil
 	}
-	// set paymentId
-	paymentId := args[0]
-	err := stub.DelState(paymentId)													//remove the key from chaincode state
+	accountNumber := args[0]
+	account, err := getAccount(stub, accountNumber)
 	if err != nil {
-		errMsg := "{ \"message\" : \"Failed to delete state\", \"code\" : \"503\"}"
+		return nil, err
+	}
+	if account.AccountNumber != accountNumber {
+		errMsg := "{ \"message\" : \"Account "+ accountNumber+ " Not Found.\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
-		} 
+		}
 		return nil, nil
 	}
-
-	//get the payment index
-	paymentAsBytes, err := stub.GetState(PaymentIndexStr)
+	accountAsBytes, _ := json.Marshal(account)
+	fmt.Println("end getAccountDetails")
+	return accountAsBytes, nil													//send it onward
+}
+// ============================================================================================================================
+//  getAllAccounts - get every account of the ledger
+// ============================================================================================================================
+func (t *ManagePayment) getAllAccounts(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var accountIndex []string
+	fmt.Println("start getAllAccounts")
+	accountIndexAsBytes, err := stub.GetState(AccountIndexStr)
 	if err != nil {
-		return nil, errors.New("Failed to get Payment index")
+		return nil, errors.New("Failed to get Account index")
 	}
-	fmt.Println("paymentAsBytes in delete payment")
-	fmt.Println(paymentAsBytes);
-	var paymentIndex []string
-	json.Unmarshal(paymentAsBytes, &paymentIndex)								//un stringify it aka JSON.parse()
-	fmt.Println("paymentIndex in delete payment")
-	fmt.Println(paymentIndex);
-	//remove payment from index
-	for i,val := range paymentIndex{
-		fmt.Println(strconv.Itoa(i) + " - looking at " + val + " for " + paymentId)
-		if val == paymentId{															//find the correct payment
-			fmt.Println("found payment")
-			paymentIndex = append(paymentIndex[:i], paymentIndex[i+1:]...)			//remove it
-			for x:= range paymentIndex{											//debug prints...
-				fmt.Println(string(x) + " - " + paymentIndex[x])
-			}
-			break
+	json.Unmarshal(accountIndexAsBytes, &accountIndex)
+	accounts := []Account{}
+	for _, accountNumber := range accountIndex {
+		account, err := getAccount(stub, accountNumber)
+		if err != nil {
+			return nil, err
 		}
+		accounts = append(accounts, account)
 	}
-	jsonAsBytes, _ := json.Marshal(paymentIndex)									//save new index
-	err = stub.PutState(PaymentIndexStr, jsonAsBytes)
-	tosend := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Payment deleted succcessfully\", \"code\" : \"200\"}"
-	err = stub.SetEvent("evtsender", []byte(tosend))
-	if err != nil {
-		return nil, err
-	} 
-	return nil, nil
+	jsonResp, _ := json.Marshal(accounts)
+	fmt.Println("end getAllAccounts")
+	return jsonResp, nil
 }
-
 // ============================================================================================================================
-// Write - update Payment into chaincode state
+//  getTransfer - get the double-entry record of a transfer
 // ============================================================================================================================
-func (t *ManagePayment) updatePayment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
-	var jsonResp string
+func (t *ManagePayment) getTransfer(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 	var err error
-	fmt.Println("running updatePayment()")
-
-	if len(args) != 13 {
-		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 13 arguments.\", \"code\" : \"503\"}"
+	fmt.Println("start getTransfer")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"transferID\" as an argument.\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
-		} 
+		}
 		return nil, nil
 	}
-	//set paymentId
-	paymentId := args[0]
-	paymentAsBytes, err := stub.GetState(paymentId)									//get the var from chaincode state
+	transferAsBytes, err := stub.GetState(TransferPrefix + args[0])
 	if err != nil {
-		jsonResp = "{\"Error\":\"Failed to get state for " + paymentId + "\"}"
-		return nil, errors.New(jsonResp)
+		return nil, errors.New("Failed to get Transfer " + args[0])
 	}
-	fmt.Print("paymentAsBytes in update payment")
-	fmt.Println(paymentAsBytes);
-	res := Payment{}
-	json.Unmarshal(paymentAsBytes, &res)
-	if res.PaymentID == paymentId{
-		fmt.Println("Payment found with id : " + paymentId)
-		fmt.Println(res);
-
-		res.AgreementID = args[1]
-		res.BuyerName = args[2]
-		res.SellerName = args[3]
-		res.BuyerAccount = args[4]
-		res.SellerAccount = args[5]
-		res.AmountTransferred = args[6]
-		res.PaymentCUDate = args[7]
-		res.PaymentStatus = args[8]
-		res.PaymentDeadlineDate = args[9]
-		res.BuyerBank_sign = args[10]
-		res.BB_name = args[11]
-		res.SB_name = args[12]
-	}else{
-		errMsg := "{ \"message\" : \""+ paymentId+ " Not Found.\", \"code\" : \"503\"}"
+	if len(transferAsBytes) == 0 {
+		errMsg := "{ \"message\" : \"Transfer "+ args[0]+ " Not Found.\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
-		} 
+		}
 		return nil, nil
 	}
-	
-	//build the Payment json string manually
-	order := `{`+
-		`"paymentId" : "` + res.PaymentID   + `", `+
-		`"agreementId" : "` + res.AgreementID   + `", `+
-		`"buyerName" : "` + res.BuyerName   + `", `+
-		`"sellerName" : "` + res.SellerName   + `", `+
-		`"buyerAccount" : "` + res.BuyerAccount   + `", `+
-		`"sellerAccount" : "` + res.SellerAccount   + `", `+
-		`"amountTransferred" : "` + res.AmountTransferred   + `", `+
-		`"paymentCUDate" : "` + res.PaymentCUDate   + `", `+
-		`"paymentStatus" : "` + res.PaymentStatus   + `", `+
-		`"paymentDeadlineDate" : "` + res.PaymentDeadlineDate   + `", `+
-		`"buyerBank_sign" : "` + res.BuyerBank_sign   + `", `+
-		`"bb_name" : "` + res.BB_name   + `", `+
-		`"sb_name" : "` + res.SB_name   + `"`+
-		`}`
-
-	if res.BuyerBank_sign == "true"{
-		fmt.Println("Buyer Bank sign is true with amount to be transferred :: " + res.AmountTransferred)
-		t.updateBalance(stub, res.AmountTransferred)
-	}
-
-	err = stub.PutState(paymentId, []byte(order))									//store Payment with id as key
-	if err != nil {
-		return nil, err
-	}
-
-	tosend := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Payment updated succcessfully\", \"code\" : \"200\"}"
-	err = stub.SetEvent("evtsender", []byte(tosend))
-	if err != nil {
-		return nil, err
-	} 	
-	fmt.Println("end updatePayment()")
-	return nil, nil
+	fmt.Println("end getTransfer")
+	return transferAsBytes, nil
 }
-
 // ============================================================================================================================
-// Init Payment- create a new Payment, store into chaincode state
+//  createAccount - a bank or an admin opens an account with an owner and a currency. The account opens empty, an opening
+//  balance is credited through transferFunds from fundingAccount, which must be the caller's, or for an admin left empty to
+//  draw on the issuance account of the currency.
 // ============================================================================================================================
-func (t *ManagePayment) createPayment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+func (t *ManagePayment) createAccount(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// createAccount("accountNumber", "owner", "currency", "openingBalance", "fundingAccount", "openedAt")
 	var err error
-	if len(args) != 13 {
-		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 13 arguments.\", \"code\" : \"503\"}"
+	fmt.Println("start createAccount")
+	if len(args) != 6 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 6 arguments.\", \"code\" : \"503\"}"
 		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
-		} 
+		}
 		return nil, nil
 	}
-	//input sanitation
-	fmt.Println("- start createPayment")
-	/*if len(args[0]) <= 0 {
-		return nil, errors.New("1st argument must be a non-empty string")
+	accountNumber := args[0]
+	owner := args[1]
+	currency := args[2]
+	openingBalance, ok := toCents(args[3])
+	fundingAccount := args[4]
+	openedAt := args[5]
+	existing, err := getAccount(stub, accountNumber)
+	if err != nil {
+		return nil, err
 	}
-	if len(args[1]) <= 0 {
-		return nil, errors.New("2nd argument must be a non-empty string")
+	role, err := stub.ReadCertAttribute("role")
+	isAdmin := err == nil && string(role) == "admin"
+	isBank := err == nil && string(role) == "bank"
+	funding := Account{}
+	if fundingAccount != "" {
+		funding, err = getAccount(stub, fundingAccount)
+		if err != nil {
+			return nil, err
+		}
 	}
-	if len(args[2]) <= 0 {
-		return nil, errors.New("3rd argument must be a non-empty string")
+	problem := ""
+	if !isAdmin && !isBank {
+		problem = "Only a bank or an admin can create an Account"
+	} else if accountNumber == "" || owner == "" || currency == "" {
+		problem = "accountNumber, owner and currency are required"
+	} else if owner == IssuanceOwner || strings.HasPrefix(accountNumber, IssuanceAccountPrefix) {
+		problem = "Issuance accounts are opened by the chaincode"
+	} else if !ISOCurrencies[currency] {
+		problem = "Currency must be an ISO currency code"
+	} else if !ok || openingBalance < 0 {
+		problem = "Opening balance must be a positive amount"
+	} else if existing.AccountNumber == accountNumber {
+		problem = "This Account already exists"
+	} else if openingBalance > 0 && !isDate(openedAt) {
+		problem = "openedAt must be a YYYY-MM-DD date"
+	} else if openingBalance > 0 && fundingAccount == "" && !isAdmin {
+		problem = "Only an admin can fund an opening balance from the issuance account"
+	} else if openingBalance > 0 && fundingAccount != "" && (funding.AccountNumber != fundingAccount || !callerIs(stub, funding.Owner)) {
+		problem = "Funding account " + fundingAccount + " is not an account of the caller"
 	}
-	if len(args[3]) <= 0 {
-		return nil, errors.New("4th argument must be a non-empty string")
+	if problem != "" {
+		errMsg := "{ \"accountNumber\" : \""+accountNumber+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
 	}
-	if len(args[4]) <= 0 {
-		return nil, errors.New("5th argument must be a non-empty string")
+	newAccounts := []string{accountNumber}
+	err = putAccount(stub, Account{accountNumber, owner, currency, formatCents(0)})
+	if err != nil {
+		return nil, err
 	}
-	if len(args[5]) <= 0 {
-		return nil, errors.New("6th argument must be a non-empty string")
+	if openingBalance > 0 {
+		if fundingAccount == "" {
+			fundingAccount = IssuanceAccountPrefix + currency
+			issuance, err := getAccount(stub, fundingAccount)
+			if err != nil {
+				return nil, err
+			}
+			if issuance.AccountNumber != fundingAccount {
+				err = putAccount(stub, Account{fundingAccount, IssuanceOwner, currency, formatCents(0)})
+				if err != nil {
+					return nil, err
+				}
+				newAccounts = append(newAccounts, fundingAccount)
+			}
+		}
+		_, problem, err = transferFunds(stub, OpeningCreditPrefix + accountNumber, "", fundingAccount, accountNumber, formatCents(openingBalance), currency, openedAt)
+		if err != nil {
+			return nil, err
+		}
+		if problem != "" {
+			return nil, errors.New("Opening credit of " + accountNumber + " failed: " + problem)		// abort so the empty account is not kept
+		}
 	}
-	if len(args[6]) <= 0 {
-		return nil, errors.New("7th argument must be a non-empty string")
+	accountIndexAsBytes, err := stub.GetState(AccountIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Account index")
 	}
-	if len(args[7]) <= 0 {
-		return nil, errors.New("8th argument must be a non-empty string")
+	var accountIndex []string
+	json.Unmarshal(accountIndexAsBytes, &accountIndex)							//un stringify it aka JSON.parse()
+	accountIndex = append(accountIndex, newAccounts...)
+	jsonAsBytes, _ := json.Marshal(accountIndex)
+	err = stub.PutState(AccountIndexStr, jsonAsBytes)
+	if err != nil {
+		return nil, err
 	}
-	if len(args[8]) <= 0 {
-		return nil, errors.New("9th argument must be a non-empty string")
+	tosend := "{ \"accountNumber\" : \""+accountNumber+"\", \"message\" : \"Account created succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
 	}
-	if len(args[9]) <= 0 {
-		return nil, errors.New("10th argument must be a non-empty string")
+	fmt.Println("end createAccount")
+	return nil, nil
+}
+// ============================================================================================================================
+//  transferFunds - move an amount given in currency from one account to another as a debit and a matching credit.
+//  Each side is converted into its account currency at the FX rates on the ledger, an empty currency is the debit account currency.
+//  Returns why the transfer was refused, or "" once both accounts and the Transfer record are stored.
+// ============================================================================================================================
+func transferFunds(stub shim.ChaincodeStubInterface, transferId string, paymentId string, from string, to string, amount string, currency string, at string) (Transfer, string, error) {
+	transfer := Transfer{}
+	cents, ok := toCents(amount)
+	if !ok || cents <= 0 {
+		return transfer, "Transfer amount must be a positive amount", nil
 	}
-*/
+	if from == to {
+		return transfer, "Cannot transfer to the same account", nil
+	}
+	exists, err := transferExists(stub, transferId)
+	if err != nil {
+		return transfer, "", err
+	}
+	if exists {
+		return transfer, "Transfer " + transferId + " already exists", nil
+	}
+	debit, err := getAccount(stub, from)
+	if err != nil {
+		return transfer, "", err
+	}
+	credit, err := getAccount(stub, to)
+	if err != nil {
+		return transfer, "", err
+	}
+	if debit.AccountNumber != from || credit.AccountNumber != to {
+		return transfer, "Account " + from + " or " + to + " Not Found", nil
+	}
+	if currency == "" {
+		currency = debit.Currency
+	}
+	debitRate, ok, err := fxRate(stub, currency, debit.Currency)
+	if err != nil {
+		return transfer, "", err
+	}
+	if !ok {
+		return transfer, "No FX rate from " + currency + " to " + debit.Currency, nil
+	}
+	creditRate, ok, err := fxRate(stub, currency, credit.Currency)
+	if err != nil {
+		return transfer, "", err
+	}
+	if !ok {
+		return transfer, "No FX rate from " + currency + " to " + credit.Currency, nil
+	}
+	debitCents := int64(math.Floor(float64(cents) * debitRate + 0.5))
+	creditCents := int64(math.Floor(float64(cents) * creditRate + 0.5))
+	debitBalance, _ := toCents(debit.Balance)
+	creditBalance, _ := toCents(credit.Balance)
+	if debitBalance < debitCents && debit.Owner != IssuanceOwner {
+		return transfer, "Insufficient funds in account " + from, nil
+	}
+	debit.Balance = formatCents(debitBalance - debitCents)
+	credit.Balance = formatCents(creditBalance + creditCents)
+	transfer = Transfer{
+		TransferID: transferId,
+		PaymentID: paymentId,
+		Currency: currency,
+		Amount: formatCents(cents),
+		At: at,
+		Entries: []LedgerEntry{
+			{debit.AccountNumber, "debit", "-" + formatCents(debitCents), debit.Currency, formatRate(debitRate), debit.Balance},
+			{credit.AccountNumber, "credit", formatCents(creditCents), credit.Currency, formatRate(creditRate), credit.Balance},
+		},
+	}
+	transferAsBytes, _ := json.Marshal(transfer)
+	err = stub.PutState(TransferPrefix + transferId, transferAsBytes)
+	if err != nil {
+		return transfer, "", err
+	}
+	err = putAccount(stub, debit)
+	if err != nil {
+		return transfer, "", err
+	}
+	err = putAccount(stub, credit)
+	if err != nil {
+		return transfer, "", err
+	}
+	return transfer, "", nil
+}
+// ============================================================================================================================
+//  fxRate - rate converting one unit of from into to, the direct rate is used before the inverse of the reverse rate
+// ============================================================================================================================
+func fxRate(stub shim.ChaincodeStubInterface, from string, to string) (float64, bool, error) {
+	if from == to {
+		return 1, true, nil
+	}
+	rates, err := getFXRates(stub, from)
+	if err != nil {
+		return 0, false, err
+	}
+	if rate, ok := rates.Rates[to]; ok && rate > 0 {
+		return rate, true, nil
+	}
+	rates, err = getFXRates(stub, to)
+	if err != nil {
+		return 0, false, err
+	}
+	if rate, ok := rates.Rates[from]; ok && rate > 0 {
+		return 1 / rate, true, nil
+	}
+	return 0, false, nil
+}
+// ============================================================================================================================
+//  getFXRates - read the rates stored for a base currency
+// ============================================================================================================================
+func getFXRates(stub shim.ChaincodeStubInterface, base string) (CurrencyConversion, error) {
+	rates := CurrencyConversion{}
+	ratesAsBytes, err := stub.GetState(FXRatesPrefix + base)
+	if err != nil {
+		return rates, errors.New("Failed to get FX rates for " + base)
+	}
+	json.Unmarshal(ratesAsBytes, &rates)
+	return rates, nil
+}
+// ============================================================================================================================
+//  formatRate - format an FX rate without losing precision
+// ============================================================================================================================
+func formatRate(rate float64) string {
+	return strconv.FormatFloat(rate, 'f', -1, 64)
+}
+// ============================================================================================================================
+//  recordSettlement - copy the amounts and FX rates of the settlement transfer onto the payment
+// ============================================================================================================================
+func recordSettlement(res *Payment, transfer Transfer) {
+	for _, entry := range transfer.Entries {
+		if entry.Side == "debit" {
+			res.DebitedAmount = strings.TrimPrefix(entry.Amount, "-")
+			res.DebitedCurrency = entry.Currency
+			res.DebitFXRate = entry.FXRate
+		} else {
+			res.SettledAmount = entry.Amount
+			res.SettledCurrency = entry.Currency
+			res.SettlementFXRate = entry.FXRate
+		}
+	}
+}
+// ============================================================================================================================
+//  transferExists - whether a transfer with this ID has already been made
+// ============================================================================================================================
+func transferExists(stub shim.ChaincodeStubInterface, transferId string) (bool, error) {
+	transferAsBytes, err := stub.GetState(TransferPrefix + transferId)
+	if err != nil {
+		return false, errors.New("Failed to get Transfer " + transferId)
+	}
+	return len(transferAsBytes) > 0, nil
+}
+// ============================================================================================================================
+//  getAccount - read an account, an empty Account is returned when it does not exist
+// ============================================================================================================================
+func getAccount(stub shim.ChaincodeStubInterface, accountNumber string) (Account, error) {
+	account := Account{}
+	accountAsBytes, err := stub.GetState(AccountPrefix + accountNumber)
+	if err != nil {
+		return account, errors.New("Failed to get Account " + accountNumber)
+	}
+	json.Unmarshal(accountAsBytes, &account)
+	return account, nil
+}
+// ============================================================================================================================
+//  putAccount - store an account under its account number
+// ============================================================================================================================
+func putAccount(stub shim.ChaincodeStubInterface, account Account) error {
+	accountAsBytes, _ := json.Marshal(account)
+	return stub.PutState(AccountPrefix + account.AccountNumber, accountAsBytes)
+}
+// ============================================================================================================================
+//  toCents - parse a decimal amount into whole cents so balances add up exactly
+// ============================================================================================================================
+func toCents(amount string) (int64, bool) {
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return 0, false
+	}
+	return int64(math.Floor(value * 100 + 0.5)), true
+}
+// ============================================================================================================================
+//  formatCents - format cents as a decimal amount with two places
+// ============================================================================================================================
+func formatCents(cents int64) string {
+	return strconv.FormatFloat(float64(cents) / 100, 'f', 2, 64)
+}
+// ============================================================================================================================
+// Delete - archive a closed Payment with a reason, the Payment stays on the ledger and in the index
+// ============================================================================================================================
+func (t *ManagePayment) deletePayment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// deletePayment("paymentId", "reason", "archivedAt")
+	var err error
+	if len(args) != 3 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"paymentID\", \"reason\" and \"archivedAt\" arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	// set paymentId
 	paymentId := args[0]
-	agreementId := args[1]
-	buyerName := args[2]
-	sellerName := args[3]
-	buyerAccount := BuyerAccountNumber
-	sellerAccount := SellerAccountNumber
-	amountTransferred := args[4]
-	paymentCUDate := args[5]
-	paymentStatus := args[6]
-	paymentDeadlineDate := args[7]
-	buyerBank_sign := args[8]
-	bb_name := args[9]
-	sb_name := args[10]
-
+	reason := args[1]
+	archivedAt := args[2]
 	paymentAsBytes, err := stub.GetState(paymentId)
 	if err != nil {
 		return nil, errors.New("Failed to get Payment paymentId")
 	}
-	fmt.Print("paymentAsBytes: ")
-	fmt.Println(paymentAsBytes)
 	res := Payment{}
 	json.Unmarshal(paymentAsBytes, &res)
-	fmt.Print("res: ")
-	fmt.Println(res)
-	if res.PaymentID == paymentId{
-		fmt.Println("This Payment arleady exists: " + paymentId)
-		errMsg := "{ \"message\" : \"This Payment arleady exists.\", \"code\" : \"503\"}"
-		err := stub.SetEvent("errEvent", []byte(errMsg))
+	problem := ""
+	if res.PaymentID != paymentId {
+		problem = paymentId + " Not Found."
+	} else if res.Archive != nil {
+		problem = "Payment is already archived"
+	} else if reason == "" || !isDate(archivedAt) {
+		problem = "Expecting a reason and a YYYY-MM-DD date"
+	} else if res.OpenDispute != "" {
+		problem = "Payment cannot be archived while dispute " + res.OpenDispute + " is open"
+	} else if paymentIsOpen(res) {
+		problem = "Payment cannot be archived while it is open, status is " + res.PaymentStatus
+	}
+	if problem != "" {
+		errMsg := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
 		if err != nil {
 			return nil, err
-		} 
-		return nil, nil				//all stop a payment by this name exists
+		}
+		return nil, nil
 	}
-	
-	//build the Payment json string manually
-	
-	order := `{`+
-		`"paymentId" : "` + paymentId   + `", `+
-		`"agreementId" : "` + agreementId   + `", `+
-		`"buyerName" : "` + buyerName   + `", `+
-		`"sellerName" : "` + sellerName   + `", `+
-		`"buyerAccount" : "` + buyerAccount   + `", `+
-		`"sellerAccount" : "` + sellerAccount   + `", `+
-		`"amountTransferred" : "` + amountTransferred   + `", `+
-		`"paymentCUDate" : "` + paymentCUDate   + `", `+
-		`"paymentStatus" : "` + paymentStatus   + `", `+
-		`"paymentDeadlineDate" : "` + paymentDeadlineDate   + `", `+
-		`"buyerBank_sign" : "` + buyerBank_sign   + `", `+
-		`"bb_name" : "` + bb_name   + `", `+
-		`"sb_name" : "` + sb_name   + `"`+
-		`}`
-
-	err = stub.PutState(paymentId, []byte(order))									//store Payment with id as key
+	res.Archive = &Archive{reason, callerName(stub), archivedAt}
+	paymentAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(paymentId, paymentAsBytes)
 	if err != nil {
 		return nil, err
 	}
-	
-	//get the Payment index
-	paymentIndexAsBytes, err := stub.GetState(PaymentIndexStr)
+	tosend := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Payment archived succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
 	if err != nil {
-		return nil, errors.New("Failed to get Payment index")
+		return nil, err
+	} 
+	return nil, nil
+}
+
+// ============================================================================================================================
+// Write - update the dates of a Payment or fail it, the parties, accounts and amount are fixed once it is created and
+// the buyer bank approves it through approvePayment
+// ============================================================================================================================
+func (t *ManagePayment) updatePayment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var jsonResp string
+	var err error
+	fmt.Println("running updatePayment()")
+
+	if len(args) != 13 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 13 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	//set paymentId
+	paymentId := args[0]
+	paymentAsBytes, err := stub.GetState(paymentId)									//get the var from chaincode state
+	if err != nil {
+		jsonResp = "{\"Error\":\"Failed to get state for " + paymentId + "\"}"
+		return nil, errors.New(jsonResp)
+	}
+	fmt.Print("paymentAsBytes in update payment")
+	fmt.Println(paymentAsBytes);
+	res := Payment{}
+	json.Unmarshal(paymentAsBytes, &res)
+	if res.PaymentID == paymentId && (res.PaymentStatus == HeldScreeningStatus || res.PaymentStatus == ConfirmedFraudStatus || res.PaymentStatus == LateDeliveryHoldStatus) {
+		errMsg := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Payment cannot be updated, status is " + res.PaymentStatus + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if res.PaymentID == paymentId{
+		fmt.Println("Payment found with id : " + paymentId)
+		fmt.Println(res);
+
+		// status changes must follow PaymentTransitions, args[10] is kept for compatibility and ignored
+		status := args[8]
+		if status == "" {
+			status = res.PaymentStatus
+		}
+		problem := ""
+		if res.PaymentStatus == PaymentSettledStatus || res.PaymentStatus == PaymentRefundedStatus || res.PaymentStatus == PaymentFailedStatus {
+			problem = "Payment cannot be updated, status is " + res.PaymentStatus
+		} else if status == PaymentSettledStatus || status == PaymentRefundedStatus || status == PaymentOverdueStatus {
+			problem = "Payment cannot be moved to " + status + " by updatePayment"
+		} else if status != res.PaymentStatus && !canMovePayment(res.PaymentStatus, status) {
+			problem = "Payment cannot move from " + res.PaymentStatus + " to " + status
+		} else if status == PaymentApprovedStatus && status != res.PaymentStatus {
+			problem = "The buyer bank approves a Payment with approvePayment"
+		} else if args[2] != res.BuyerName || args[3] != res.SellerName || args[11] != res.BB_name || args[12] != res.SB_name {
+			problem = "The parties were screened when the Payment was created and can't be changed"
+		} else if args[1] != res.AgreementID || args[4] != res.BuyerAccount || args[5] != res.SellerAccount || args[6] != res.AmountTransferred {
+			problem = "The Agreement, accounts and amount of a Payment can't be changed"
+		}
+		if problem != "" {
+			errMsg := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+			err = stub.SetEvent("errEvent", []byte(errMsg))
+			if err != nil {
+				return nil, err
+			}
+			return nil, nil
+		}
+
+		res.PaymentCUDate = args[7]
+		res.PaymentDeadlineDate = args[9]
+		if status != res.PaymentStatus {
+			setPaymentStatus(&res, status, res.PaymentCUDate, callerName(stub))
+		}
+	}else{
+		errMsg := "{ \"message\" : \""+ paymentId+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
 	}
-	var paymentIndex []string
-	fmt.Print("paymentIndexAsBytes: ")
-	fmt.Println(paymentIndexAsBytes)
-	
-	json.Unmarshal(paymentIndexAsBytes, &paymentIndex)							//un stringify it aka JSON.parse()
-	fmt.Print("paymentIndexAsBytes after unmarshal..before append: ")
-	fmt.Println(paymentIndexAsBytes)
 	
-	//append
-	paymentIndex = append(paymentIndex, paymentId)									//add Payment paymentId to index list
-	fmt.Println("! Payment index: ", paymentIndex)
-	jsonAsBytes, _ := json.Marshal(paymentIndex)
-	fmt.Print("jsonAsBytes: ")
-	fmt.Println(jsonAsBytes)
-	err = stub.PutState(PaymentIndexStr, jsonAsBytes)						//store name of Payment
+	// money only moves through settlePayment
+	order, _ := json.Marshal(res)
+
+	err = stub.PutState(paymentId, order)									//store Payment with id as key
 	if err != nil {
 		return nil, err
 	}
 
-	tosend := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Payment created succcessfully\", \"code\" : \"200\"}"
+	tosend := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Payment updated succcessfully\", \"code\" : \"200\"}"
 	err = stub.SetEvent("evtsender", []byte(tosend))
 	if err != nil {
 		return nil, err
-	} 
+	} 	
+	fmt.Println("end updatePayment()")
+	return nil, nil
+}
 
-	fmt.Println("end createPayment()")
+// ============================================================================================================================
+// approvePayment - the buyer bank of an Initiated Payment approves it, so it can be settled
+// ============================================================================================================================
+func (t *ManagePayment) approvePayment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// approvePayment("paymentId", "approvedAt")
+	var err error
+	fmt.Println("start approvePayment")
+	if len(args) != 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 2 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	paymentId := args[0]
+	approvedAt := args[1]
+	paymentAsBytes, err := stub.GetState(paymentId)
+	if err != nil {
+		return nil, errors.New("Failed to get Payment paymentId")
+	}
+	res := Payment{}
+	json.Unmarshal(paymentAsBytes, &res)
+	problem := ""
+	if res.PaymentID != paymentId {
+		problem = paymentId + " Not Found."
+	} else if res.PaymentStatus != PaymentInitiatedStatus {
+		problem = "Payment cannot be approved, status is " + res.PaymentStatus
+	} else if !callerIs(stub, res.BB_name) {
+		problem = "Only the buyer bank can approve this Payment"
+	} else if !isDate(approvedAt) {
+		problem = "approvedAt must be a YYYY-MM-DD date"
+	}
+	if problem != "" {
+		errMsg := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	res.BuyerBank_sign = "true"
+	setPaymentStatus(&res, PaymentApprovedStatus, approvedAt, callerName(stub))
+	paymentAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(paymentId, paymentAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Payment approved succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end approvePayment")
 	return nil, nil
 }
+
+// ============================================================================================================================
+// Init Payment- create a new Payment, store into chaincode state
+// ============================================================================================================================
+func (t *ManagePayment) createPayment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	if len(args) != 15 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 15 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil
+	}
+	//input sanitation
+	fmt.Println("- start createPayment")
+	/*if len(args[0]) <= 0 {
+		return nil, errors.New("1st argument must be a non-empty string")
+	}
+	if len(args[1]) <= 0 {
+		return nil, errors.New("2nd argument must be a non-empty string")
+	}
+	if len(args[2]) <= 0 {
+		return nil, errors.New("3rd argument must be a non-empty string")
+	}
+	if len(args[3]) <= 0 {
+		return nil, errors.New("4th argument must be a non-empty string")
+	}
+	if len(args[4]) <= 0 {
+		return nil, errors.New("5th argument must be a non-empty string")
+	}
+	if len(args[5]) <= 0 {
+		return nil, errors.New("6th argument must be a non-empty string")
+	}
+	if len(args[6]) <= 0 {
+		return nil, errors.New("7th argument must be a non-empty string")
+	}
+	if len(args[7]) <= 0 {
+		return nil, errors.New("8th argument must be a non-empty string")
+	}
+	if len(args[8]) <= 0 {
+		return nil, errors.New("9th argument must be a non-empty string")
+	}
+	if len(args[9]) <= 0 {
+		return nil, errors.New("10th argument must be a non-empty string")
+	}
+*/
+	paymentId := args[0]
+	agreementId := args[1]
+	buyerName := args[2]
+	sellerName := args[3]
+	buyerAccount := args[11]
+	sellerAccount := args[12]
+	amountTransferred := args[4]
+	paymentCUDate := args[5]
+	paymentStatus := PaymentInitiatedStatus						// args[6] is kept for compatibility, a Payment always starts Initiated
+	paymentDeadlineDate := args[7]
+	// args[8], the buyer bank signature, is kept for compatibility, the buyer bank approves with approvePayment
+	bb_name := args[9]
+	sb_name := args[10]
+	agreementChaincode := args[13]						// name of the Agreement chaincode holding agreementId
+	instalmentNo := args[14]						// instalment line of the Agreement, "" when it has no schedule
+
+	// The agreement must exist and carry every party's signature before money can move
+	f := "getAgreement_byID"
+	queryArgs := util.ToChaincodeArgs(f, agreementId)
+	agreementAsBytes, err := stub.QueryChaincode(agreementChaincode, queryArgs)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to query Agreement chaincode. Got error: %s", err.Error())
+		fmt.Println(errStr)
+		return nil, errors.New(errStr)
+	}
+	agreement := Agreement{}
+	json.Unmarshal(agreementAsBytes, &agreement)
+	if agreement.AgreementID != agreementId {
+		errMsg := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Agreement " + agreementId + " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if !isFullySigned(agreement) || agreement.Archive != nil {
+		errMsg := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Agreement " + agreementId + " is not signed by all parties or is archived.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	problem := ""
+	if buyerName != agreement.BuyerName || sellerName != agreement.SellerName {
+		problem = "Buyer and seller must be the buyer and seller of Agreement " + agreementId
+	} else if bb_name != agreement.BB_name || sb_name != agreement.SB_name {
+		problem = "Buyer bank and seller bank must be the banks of Agreement " + agreementId
+	} else if !callerIs(stub, agreement.BuyerName) && !callerIs(stub, agreement.BB_name) {
+		problem = "Only the buyer or the buyer bank can create a Payment"
+	}
+	if problem != "" {
+		errMsg := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	// Both accounts must be on the ledger, belong to the parties and hold the same currency
+	debit, err := getAccount(stub, buyerAccount)
+	if err != nil {
+		return nil, err
+	}
+	credit, err := getAccount(stub, sellerAccount)
+	if err != nil {
+		return nil, err
+	}
+	if debit.AccountNumber != buyerAccount || debit.Owner != buyerName {
+		problem = "Account " + buyerAccount + " of " + buyerName + " Not Found."
+	} else if credit.AccountNumber != sellerAccount || credit.Owner != sellerName {
+		problem = "Account " + sellerAccount + " of " + sellerName + " Not Found."
+	}
+	if problem != "" {
+		errMsg := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	paymentAsBytes, err := stub.GetState(paymentId)
+	if err != nil {
+		return nil, errors.New("Failed to get Payment paymentId")
+	}
+	fmt.Print("paymentAsBytes: ")
+	fmt.Println(paymentAsBytes)
+	res := Payment{}
+	json.Unmarshal(paymentAsBytes, &res)
+	fmt.Print("res: ")
+	fmt.Println(res)
+	if res.PaymentID == paymentId{
+		fmt.Println("This Payment arleady exists: " + paymentId)
+		errMsg := "{ \"message\" : \"This Payment arleady exists.\", \"code\" : \"503\"}"
+		err := stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		} 
+		return nil, nil				//all stop a payment by this name exists
+	}
+	
+	payment := Payment{
+		PaymentID: paymentId,
+		AgreementID: agreementId,
+		BuyerName: buyerName,
+		SellerName: sellerName,
+		BuyerAccount: buyerAccount,
+		SellerAccount: sellerAccount,
+		AmountTransferred: amountTransferred,
+		PaymentCUDate: paymentCUDate,
+		PaymentDeadlineDate: paymentDeadlineDate,
+		BuyerBank_sign: "false",
+		BB_name: bb_name,
+		SB_name: sb_name,
+		InstalmentNo: instalmentNo,
+		AgreementChaincode: agreementChaincode,
+		Currency: agreement.Currency,
+	}
+	if payment.Currency == "" {
+		payment.Currency = debit.Currency				// Agreements created before currencies were recorded
+	}
+
+	setPaymentStatus(&payment, paymentStatus, paymentCUDate, callerName(stub))
+	if agreement.Shipment != nil && agreement.Shipment.Late {
+		applyLateDeliveryHold(&payment, agreement, paymentCUDate)
+	}
+
+	// Screen every party of the payment against the fraud list
+	f = "screen_parties"
+	queryArgs = util.ToChaincodeArgs(f, "buyer", buyerName, "seller", sellerName, "buyerBank", bb_name, "sellerBank", sb_name)
+	hitsAsBytes, err := stub.QueryChaincode(agreementChaincode, queryArgs)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to screen Payment parties. Got error: %s", err.Error())
+		fmt.Println(errStr)
+		return nil, errors.New(errStr)
+	}
+	var hits []ScreeningHit
+	json.Unmarshal(hitsAsBytes, &hits)
+	if len(hits) > 0 {
+		payment.Screening = &Screening{Status: "Held", Hits: hits, PreviousStatus: payment.PaymentStatus}
+		payment.PaymentStatus = HeldScreeningStatus
+	}
+	order, _ := json.Marshal(payment)
+
+	err = stub.PutState(paymentId, order)									//store Payment with id as key
+	if err != nil {
+		return nil, err
+	}
+	
+	//get the Payment index
+	paymentIndexAsBytes, err := stub.GetState(PaymentIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Payment index")
+	}
+	var paymentIndex []string
+	fmt.Print("paymentIndexAsBytes: ")
+	fmt.Println(paymentIndexAsBytes)
+	
+	json.Unmarshal(paymentIndexAsBytes, &paymentIndex)							//un stringify it aka JSON.parse()
+	fmt.Print("paymentIndexAsBytes after unmarshal..before append: ")
+	fmt.Println(paymentIndexAsBytes)
+	
+	//append
+	paymentIndex = append(paymentIndex, paymentId)									//add Payment paymentId to index list
+	fmt.Println("! Payment index: ", paymentIndex)
+	jsonAsBytes, _ := json.Marshal(paymentIndex)
+	fmt.Print("jsonAsBytes: ")
+	fmt.Println(jsonAsBytes)
+	err = stub.PutState(PaymentIndexStr, jsonAsBytes)						//store name of Payment
+	if err != nil {
+		return nil, err
+	}
+
+	tosend := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Payment created succcessfully\", \"code\" : \"200\"}"
+	if payment.Screening != nil {
+		tosend = "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Payment created and held for fraud screening\", \"code\" : \"200\"}"
+	} else if payment.Hold != nil {
+		tosend = "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Payment created and held for late delivery\", \"code\" : \"200\"}"
+	}
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	} 
+
+	fmt.Println("end createPayment()")
+	return nil, nil
+}
+// ============================================================================================================================
+//  getTradeLifecycle - get the PO, its Agreements and their Payments with their statuses for one trade ID
+// ============================================================================================================================
+func (t *ManagePayment) getTradeLifecycle(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// getTradeLifecycle("tradeId", "poChaincode", "agreementChaincode")
+	var err error
+	fmt.Println("start getTradeLifecycle")
+	if len(args) != 3 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 3 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	tradeId := args[0]
+	poChaincode := args[1]
+	agreementChaincode := args[2]
+	lifecycle := TradeLifecycle{TradeID: tradeId, Agreements: make(map[string]json.RawMessage), Payments: make(map[string]json.RawMessage)}
+
+	// PO
+	f := "getPO_byID"
+	queryArgs := util.ToChaincodeArgs(f, tradeId)
+	poAsBytes, err := stub.QueryChaincode(poChaincode, queryArgs)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to query PO chaincode. Got error: %s", err.Error())
+		fmt.Println(errStr)
+		return nil, errors.New(errStr)
+	}
+	po := struct{
+		TransID string `json:"transId"`
+		PO_status string `json:"po_status"`
+	}{}
+	json.Unmarshal(poAsBytes, &po)
+	if po.TransID != tradeId {
+		errMsg := "{ \"message\" : \"PO " + tradeId + " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	lifecycle.PO = json.RawMessage(poAsBytes)
+	lifecycle.Statuses = append(lifecycle.Statuses, TradeStage{"PO", tradeId, po.PO_status})
+
+	// Agreements
+	f = "getAgreement_byTransID"
+	queryArgs = util.ToChaincodeArgs(f, tradeId, IncludeArchived)
+	agreementsAsBytes, err := stub.QueryChaincode(agreementChaincode, queryArgs)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to query Agreement chaincode. Got error: %s", err.Error())
+		fmt.Println(errStr)
+		return nil, errors.New(errStr)
+	}
+	json.Unmarshal(agreementsAsBytes, &lifecycle.Agreements)
+	var agreementIds []string
+	for agreementId := range lifecycle.Agreements {
+		agreementIds = append(agreementIds, agreementId)
+	}
+	sort.Strings(agreementIds)							// keep the response deterministic
+	for _, agreementId := range agreementIds {
+		agreement := Agreement{}
+		json.Unmarshal(lifecycle.Agreements[agreementId], &agreement)
+		lifecycle.Statuses = append(lifecycle.Statuses, TradeStage{"Agreement", agreementId, agreement.Agreement_status})
+	}
+
+	// Payments
+	var paymentIndex []string
+	paymentIndexAsBytes, err := stub.GetState(PaymentIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Payment index")
+	}
+	json.Unmarshal(paymentIndexAsBytes, &paymentIndex)
+	for _, val := range paymentIndex {
+		valueAsBytes, err := stub.GetState(val)
+		if err != nil {
+			return nil, errors.New("{\"Error\":\"Failed to get state for " + val + "\"}")
+		}
+		payment := Payment{}
+		json.Unmarshal(valueAsBytes, &payment)
+		if _, ok := lifecycle.Agreements[payment.AgreementID]; ok {
+			lifecycle.Payments[val] = json.RawMessage(valueAsBytes)
+			lifecycle.Statuses = append(lifecycle.Statuses, TradeStage{"Payment", val, payment.PaymentStatus})
+		}
+	}
+
+	jsonResp, _ := json.Marshal(lifecycle)
+	fmt.Println("end getTradeLifecycle")
+	return jsonResp, nil
+}
+// ============================================================================================================================
+//  reviewScreening - a compliance user clears or confirms the fraud list hit that holds a Payment
+// ============================================================================================================================
+func (t *ManagePayment) reviewScreening(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// reviewScreening("paymentId", "clear|confirm", "reason", "reviewedAt")
+	var err error
+	fmt.Println("start reviewScreening")
+	if len(args) != 4 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 4 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	paymentId := args[0]
+	decision := args[1]
+	reason := args[2]
+	reviewedAt := args[3]
+	reviewer, ok := complianceUser(stub)
+	if !ok {
+		errMsg := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Only a compliance user can review a screening hit\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if (decision != "clear" && decision != "confirm") || reason == "" {
+		errMsg := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Expecting decision clear or confirm with a reason\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	paymentAsBytes, err := stub.GetState(paymentId)
+	if err != nil {
+		return nil, errors.New("Failed to get Payment paymentId")
+	}
+	res := Payment{}
+	json.Unmarshal(paymentAsBytes, &res)
+	if res.PaymentID != paymentId || res.Screening == nil || res.PaymentStatus != HeldScreeningStatus {
+		errMsg := "{ \"message\" : \""+ paymentId+ " is not held for screening.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if decision == "clear" {
+		res.Screening.Status = "Cleared"
+		res.PaymentStatus = res.Screening.PreviousStatus
+	} else {
+		res.Screening.Status = "Confirmed"
+		res.PaymentStatus = ConfirmedFraudStatus
+	}
+	res.Screening.Reason = reason
+	res.Screening.ReviewedBy = reviewer
+	res.Screening.ReviewedAt = reviewedAt
+	paymentAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(paymentId, paymentAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Screening reviewed, status is " + res.PaymentStatus + "\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end reviewScreening")
+	return nil, nil
+}
+// ============================================================================================================================
+//  holdForLateDelivery - put a Payment on hold when the shipment of its Agreement has been flagged late
+// ============================================================================================================================
+func (t *ManagePayment) holdForLateDelivery(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// holdForLateDelivery("paymentId", "heldAt", "agreementChaincode")
+	var err error
+	fmt.Println("start holdForLateDelivery")
+	if len(args) != 3 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 3 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	paymentId := args[0]
+	heldAt := args[1]
+	agreementChaincode := args[2]
+	paymentAsBytes, err := stub.GetState(paymentId)
+	if err != nil {
+		return nil, errors.New("Failed to get Payment paymentId")
+	}
+	res := Payment{}
+	json.Unmarshal(paymentAsBytes, &res)
+	if res.PaymentID != paymentId || res.PaymentStatus == HeldScreeningStatus || res.PaymentStatus == ConfirmedFraudStatus || res.PaymentStatus == LateDeliveryHoldStatus {
+		errMsg := "{ \"message\" : \""+ paymentId+ " Not Found or cannot be held.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	f := "getAgreement_byID"
+	queryArgs := util.ToChaincodeArgs(f, res.AgreementID)
+	agreementAsBytes, err := stub.QueryChaincode(agreementChaincode, queryArgs)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to query Agreement chaincode. Got error: %s", err.Error())
+		fmt.Println(errStr)
+		return nil, errors.New(errStr)
+	}
+	agreement := Agreement{}
+	json.Unmarshal(agreementAsBytes, &agreement)
+	if agreement.Shipment == nil || !agreement.Shipment.Late {
+		errMsg := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Delivery of Agreement " + res.AgreementID + " is not late.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	applyLateDeliveryHold(&res, agreement, heldAt)
+	paymentAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(paymentId, paymentAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Payment held for late delivery\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end holdForLateDelivery")
+	return nil, nil
+}
+// ============================================================================================================================
+//  releasePaymentHold - the buyer accepts the late delivery and releases the Payment
+// ============================================================================================================================
+func (t *ManagePayment) releasePaymentHold(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// releasePaymentHold("paymentId", "reason", "releasedAt")
+	var err error
+	fmt.Println("start releasePaymentHold")
+	if len(args) != 3 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 3 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	paymentId := args[0]
+	paymentAsBytes, err := stub.GetState(paymentId)
+	if err != nil {
+		return nil, errors.New("Failed to get Payment paymentId")
+	}
+	res := Payment{}
+	json.Unmarshal(paymentAsBytes, &res)
+	if res.PaymentID != paymentId || res.Hold == nil || res.PaymentStatus != LateDeliveryHoldStatus {
+		errMsg := "{ \"message\" : \""+ paymentId+ " is not held for late delivery.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	caller, err := stub.ReadCertAttribute("username")
+	if err != nil || string(caller) != res.BuyerName {
+		errMsg := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Only the buyer can release the hold\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	res.PaymentStatus = res.Hold.PreviousStatus
+	res.Hold.ReleasedBy = string(caller)
+	res.Hold.ReleaseReason = args[1]
+	res.Hold.ReleasedAt = args[2]
+	paymentAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(paymentId, paymentAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Payment hold released, status is " + res.PaymentStatus + "\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end releasePaymentHold")
+	return nil, nil
+}
+// ============================================================================================================================
+//  applyLateDeliveryHold - move a Payment to the late delivery hold, keeping the status to restore on release
+// ============================================================================================================================
+func applyLateDeliveryHold(payment *Payment, agreement Agreement, heldAt string) {
+	payment.Hold = &PaymentHold{
+		Reason: "Delivery due " + agreement.Delivery_date + " is late since " + agreement.Shipment.LateSince,
+		PreviousStatus: payment.PaymentStatus,
+		HeldAt: heldAt,
+	}
+	payment.PaymentStatus = LateDeliveryHoldStatus
+}
+// ============================================================================================================================
+//  settlePayment - move the money of an approved Payment, settling twice leaves the ledger untouched
+// ============================================================================================================================
+func (t *ManagePayment) settlePayment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// settlePayment("paymentId", "settledAt")
+	var err error
+	fmt.Println("start settlePayment")
+	if len(args) != 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 2 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	paymentId := args[0]
+	settledAt := args[1]
+	paymentAsBytes, err := stub.GetState(paymentId)
+	if err != nil {
+		return nil, errors.New("Failed to get Payment paymentId")
+	}
+	res := Payment{}
+	json.Unmarshal(paymentAsBytes, &res)
+	if res.PaymentID == paymentId && res.PaymentStatus == PaymentSettledStatus {
+		tosend := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Payment already settled\", \"code\" : \"200\"}"
+		err = stub.SetEvent("evtsender", []byte(tosend))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	problem := ""
+	if res.PaymentID != paymentId {
+		problem = paymentId + " Not Found."
+	} else if !canMovePayment(res.PaymentStatus, PaymentSettledStatus) {
+		problem = "Payment cannot be settled, status is " + res.PaymentStatus
+	} else if !callerIs(stub, res.BB_name) && !callerIs(stub, res.SB_name) {
+		problem = "Only the buyer bank or the seller bank can settle this Payment"
+	} else if res.BuyerBank_sign != "true" {
+		problem = "Payment is not approved by the buyer bank"
+	}
+	if problem != "" {
+		errMsg := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if res.AgreementChaincode != "" {
+		problem, err = checkAgreementBalance(stub, res)
+		if err != nil {
+			return nil, err
+		}
+		if problem != "" {
+			errMsg := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+			err = stub.SetEvent("errEvent", []byte(errMsg))
+			if err != nil {
+				return nil, err
+			}
+			return nil, nil
+		}
+	}
+	// a transfer already made under the payment ID is never repeated
+	transferred, err := transferExists(stub, paymentId)
+	if err != nil {
+		return nil, err
+	}
+	transfer := Transfer{}
+	if !transferred {
+		transfer, problem, err = transferFunds(stub, paymentId, paymentId, res.BuyerAccount, res.SellerAccount, res.AmountTransferred, res.Currency, settledAt)
+		if err != nil {
+			return nil, err
+		}
+		if problem != "" {
+			errMsg := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+			err = stub.SetEvent("errEvent", []byte(errMsg))
+			if err != nil {
+				return nil, err
+			}
+			return nil, nil
+		}
+	} else {
+		transferAsBytes, err := stub.GetState(TransferPrefix + paymentId)
+		if err != nil {
+			return nil, errors.New("Failed to get transfer " + paymentId)
+		}
+		json.Unmarshal(transferAsBytes, &transfer)
+	}
+	recordSettlement(&res, transfer)
+	setPaymentStatus(&res, PaymentSettledStatus, settledAt, callerName(stub))
+	paymentAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(paymentId, paymentAsBytes)				// stored first, the Agreement chaincode reads the settlement back
+	if err != nil {
+		return nil, err
+	}
+	if res.AgreementChaincode != "" {
+		function := "record_instalment_payment"
+		invokeArgs := util.ToChaincodeArgs(function, res.AgreementID, res.InstalmentNo, paymentId, res.AmountTransferred, settledAt)
+		result, err := stub.InvokeChaincode(res.AgreementChaincode, invokeArgs)
+		if err != nil {
+			errStr := fmt.Sprintf("Failed to record Payment in 'Agreement' chaincode. Got error: %s", err.Error())
+			fmt.Println(errStr)
+			return nil, errors.New(errStr)
+		}
+		fmt.Print("Agreement hash returned: ")
+		fmt.Println(result)
+	}
+	tosend := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Payment settled succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end settlePayment")
+	return nil, nil
+}
+// ============================================================================================================================
+//  refundPayment - return the money of a settled Payment to the buyer with a reversing transfer
+// ============================================================================================================================
+func (t *ManagePayment) refundPayment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// refundPayment("paymentId", "refundedAt")
+	var err error
+	fmt.Println("start refundPayment")
+	if len(args) != 2 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 2 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	paymentId := args[0]
+	refundedAt := args[1]
+	paymentAsBytes, err := stub.GetState(paymentId)
+	if err != nil {
+		return nil, errors.New("Failed to get Payment paymentId")
+	}
+	res := Payment{}
+	json.Unmarshal(paymentAsBytes, &res)
+	problem := ""
+	if res.PaymentID != paymentId {
+		problem = paymentId + " Not Found."
+	} else if !canMovePayment(res.PaymentStatus, PaymentRefundedStatus) {
+		problem = "Payment cannot be refunded, status is " + res.PaymentStatus
+	} else if !callerIs(stub, res.SB_name) {
+		problem = "Only the seller bank can refund this Payment"
+	} else if res.OpenDispute != "" {
+		problem = "Payment is disputed in " + res.OpenDispute + ", refund it by resolving the dispute"
+	}
+	if problem == "" {
+		// the seller returns what was credited to them, converted back at the current rates
+		amount, currency := res.SettledAmount, res.SettledCurrency
+		if amount == "" {
+			amount, currency = res.AmountTransferred, res.Currency
+		}
+		if res.RefundedAmount != "" {
+			// part was already refunded through disputes, only the rest is returned
+			settled, _ := toCents(res.AmountTransferred)
+			refunded, _ := toCents(res.RefundedAmount)
+			amount, currency = formatCents(settled - refunded), res.Currency
+		}
+		_, problem, err = transferFunds(stub, paymentId + RefundSuffix, paymentId, res.SellerAccount, res.BuyerAccount, amount, currency, refundedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if problem != "" {
+		errMsg := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	setPaymentStatus(&res, PaymentRefundedStatus, refundedAt, callerName(stub))
+	paymentAsBytes, _ = json.Marshal(res)
+	err = stub.PutState(paymentId, paymentAsBytes)				// stored first, the Agreement chaincode reads the refund back
+	if err != nil {
+		return nil, err
+	}
+	if res.AgreementChaincode != "" {
+		function := "reverse_instalment_payment"
+		invokeArgs := util.ToChaincodeArgs(function, res.AgreementID, paymentId, refundedAt)
+		result, err := stub.InvokeChaincode(res.AgreementChaincode, invokeArgs)
+		if err != nil {
+			errStr := fmt.Sprintf("Failed to reverse Payment in 'Agreement' chaincode. Got error: %s", err.Error())
+			fmt.Println(errStr)
+			return nil, errors.New(errStr)
+		}
+		fmt.Print("Agreement hash returned: ")
+		fmt.Println(result)
+	}
+	tosend := "{ \"paymentID\" : \""+paymentId+"\", \"message\" : \"Payment refunded succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end refundPayment")
+	return nil, nil
+}
+// ============================================================================================================================
+//  openDispute - buyer disputes a settled Payment or an Agreement, with evidence given as name and sha256 pairs
+// ============================================================================================================================
+func (t *ManagePayment) openDispute(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// openDispute("disputeId", "payment|agreement", "targetId", "reason", "openedAt", "agreementChaincode", "name", "sha256", ...)
+	var err error
+	fmt.Println("start openDispute")
+	if len(args) < 8 || len(args) % 2 != 0 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 6 arguments and \"name\", \"sha256\" evidence pairs.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	disputeId := args[0]
+	target := args[1]
+	targetId := args[2]
+	openedAt := args[4]
+	existing, err := getDispute(stub, disputeId)
+	if err != nil {
+		return nil, err
+	}
+	dispute := Dispute{
+		DisputeID: disputeId,
+		Target: target,
+		AgreementChaincode: args[5],
+		Reason: args[3],
+		Dispute_status: DisputeOpenStatus,
+		OpenedBy: callerName(stub),
+		OpenedAt: openedAt,
+		Evidence: []Evidence{},
+		Refunds: []DisputeRefund{},
+	}
+	payment := Payment{}
+	problem := ""
+	if disputeId == "" || existing.DisputeID == disputeId {
+		problem = "Dispute " + disputeId + " already exists"
+	} else if dispute.Reason == "" || !isDate(openedAt) {
+		problem = "Expecting a reason and a YYYY-MM-DD date"
+	} else if target == "payment" {
+		paymentAsBytes, err := stub.GetState(targetId)
+		if err != nil {
+			return nil, errors.New("Failed to get Payment " + targetId)
+		}
+		json.Unmarshal(paymentAsBytes, &payment)
+		if payment.PaymentID != targetId {
+			problem = "Payment " + targetId + " Not Found."
+		} else if payment.Archive != nil {
+			problem = "Payment " + targetId + " is archived"
+		} else if payment.PaymentStatus != PaymentSettledStatus {
+			problem = "Only a settled Payment can be disputed, status is " + payment.PaymentStatus
+		} else if payment.OpenDispute != "" {
+			problem = "Payment is already disputed in " + payment.OpenDispute
+		} else {
+			dispute.PaymentID = payment.PaymentID
+			dispute.AgreementID = payment.AgreementID
+			dispute.AgreementChaincode = payment.AgreementChaincode
+			dispute.BuyerName = payment.BuyerName
+			dispute.SellerName = payment.SellerName
+			dispute.BB_name = payment.BB_name
+			dispute.SB_name = payment.SB_name
+		}
+	} else if target == "agreement" {
+		// the Agreement is read through the chaincode its Payments were created with, args[5] is kept for compatibility
+		dispute.AgreementChaincode, err = agreementChaincodeOf(stub, targetId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if problem == "" && target == "agreement" && dispute.AgreementChaincode == "" {
+		problem = "Agreement " + targetId + " has no Payments to dispute"
+	} else if problem == "" && target == "agreement" {
+		f := "getAgreement_byID"
+		queryArgs := util.ToChaincodeArgs(f, targetId)
+		agreementAsBytes, err := stub.QueryChaincode(dispute.AgreementChaincode, queryArgs)
+		if err != nil {
+			errStr := fmt.Sprintf("Failed to query Agreement chaincode. Got error: %s", err.Error())
+			fmt.Println(errStr)
+			return nil, errors.New(errStr)
+		}
+		agreement := Agreement{}
+		json.Unmarshal(agreementAsBytes, &agreement)
+		if agreement.AgreementID != targetId {
+			problem = "Agreement " + targetId + " Not Found."
+		} else {
+			dispute.AgreementID = agreement.AgreementID
+			dispute.BuyerName = agreement.BuyerName
+			dispute.SellerName = agreement.SellerName
+			dispute.BB_name = agreement.BB_name
+			dispute.SB_name = agreement.SB_name
+		}
+	} else if problem == "" && target != "payment" && target != "agreement" {
+		problem = "A dispute is opened on a payment or an agreement"
+	}
+	if problem == "" && !callerIs(stub, dispute.BuyerName) {
+		problem = "Only the buyer can open a dispute"
+	}
+	if problem == "" {
+		dispute.Evidence, problem = parseEvidence(args[6:], dispute.OpenedBy, openedAt)
+	}
+	if problem != "" {
+		errMsg := "{ \"disputeID\" : \""+disputeId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if dispute.PaymentID != "" {
+		payment.OpenDispute = disputeId
+		paymentAsBytes, _ := json.Marshal(payment)
+		err = stub.PutState(payment.PaymentID, paymentAsBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = putDispute(stub, dispute)
+	if err != nil {
+		return nil, err
+	}
+	disputeIndexAsBytes, err := stub.GetState(DisputeIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Dispute index")
+	}
+	var disputeIndex []string
+	json.Unmarshal(disputeIndexAsBytes, &disputeIndex)
+	disputeIndex = append(disputeIndex, disputeId)
+	jsonAsBytes, _ := json.Marshal(disputeIndex)
+	err = stub.PutState(DisputeIndexStr, jsonAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"disputeID\" : \""+disputeId+"\", \"message\" : \"Dispute opened succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end openDispute")
+	return nil, nil
+}
+// ============================================================================================================================
+//  addDisputeEvidence - buyer or seller adds name and sha256 evidence pairs to an open dispute
+// ============================================================================================================================
+func (t *ManagePayment) addDisputeEvidence(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// addDisputeEvidence("disputeId", "addedAt", "name", "sha256", ...)
+	var err error
+	fmt.Println("start addDisputeEvidence")
+	if len(args) < 4 || len(args) % 2 != 0 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting dispute ID, date and \"name\", \"sha256\" evidence pairs.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	disputeId := args[0]
+	addedAt := args[1]
+	dispute, err := getDispute(stub, disputeId)
+	if err != nil {
+		return nil, err
+	}
+	var evidence []Evidence
+	problem := ""
+	if dispute.DisputeID != disputeId {
+		problem = disputeId + " Not Found."
+	} else if dispute.Dispute_status != DisputeOpenStatus {
+		problem = "Evidence cannot be added, status is " + dispute.Dispute_status
+	} else if !callerIs(stub, dispute.BuyerName) && !callerIs(stub, dispute.SellerName) {
+		problem = "Only the buyer or the seller can add evidence"
+	} else if !isDate(addedAt) {
+		problem = "Expecting a YYYY-MM-DD date"
+	} else {
+		evidence, problem = parseEvidence(args[2:], callerName(stub), addedAt)
+	}
+	if problem != "" {
+		errMsg := "{ \"disputeID\" : \""+disputeId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	dispute.Evidence = append(dispute.Evidence, evidence...)
+	err = putDispute(stub, dispute)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"disputeID\" : \""+disputeId+"\", \"message\" : \"Evidence added succcessfully\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end addDisputeEvidence")
+	return nil, nil
+}
+// ============================================================================================================================
+//  resolveDispute - a bank of the trade rejects the dispute or refunds a settled Payment in full or in part.
+//  Refunds are compensating transfers from the seller account back to the buyer account, nothing is deleted.
+// ============================================================================================================================
+func (t *ManagePayment) resolveDispute(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// resolveDispute("disputeId", "refund|partial_refund|reject", "paymentId", "amount", "resolvedAt", "remarks")
+	var err error
+	fmt.Println("start resolveDispute")
+	if len(args) != 6 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting 6 arguments.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	disputeId := args[0]
+	decision := args[1]
+	paymentId := args[2]
+	resolvedAt := args[4]
+	dispute, err := getDispute(stub, disputeId)
+	if err != nil {
+		return nil, err
+	}
+	if paymentId == "" {
+		paymentId = dispute.PaymentID
+	}
+	res := Payment{}
+	if decision != DecisionReject {
+		paymentAsBytes, err := stub.GetState(paymentId)
+		if err != nil {
+			return nil, errors.New("Failed to get Payment " + paymentId)
+		}
+		json.Unmarshal(paymentAsBytes, &res)
+	}
+	settled, _ := toCents(res.AmountTransferred)
+	refunded, _ := toCents(res.RefundedAmount)
+	amount, ok := toCents(args[3])
+	if decision == DecisionRefund {
+		amount, ok = settled - refunded, true
+	}
+	problem := ""
+	if dispute.DisputeID != disputeId {
+		problem = disputeId + " Not Found."
+	} else if dispute.Dispute_status != DisputeOpenStatus {
+		problem = "Dispute is already closed, status is " + dispute.Dispute_status
+	} else if !callerIs(stub, dispute.BB_name) && !callerIs(stub, dispute.SB_name) {
+		problem = "Only the buyer bank or the seller bank can resolve this dispute"
+	} else if !isDate(resolvedAt) {
+		problem = "Expecting a YYYY-MM-DD date"
+	} else if decision != DecisionRefund && decision != DecisionPartialRefund && decision != DecisionReject {
+		problem = "Decision must be refund, partial_refund or reject"
+	}
+	if problem == "" && decision != DecisionReject {
+		if res.PaymentID == "" || res.PaymentID != paymentId || res.AgreementID != dispute.AgreementID {
+			problem = "Payment " + paymentId + " is not a Payment of Agreement " + dispute.AgreementID
+		} else if !callerIs(stub, res.BB_name) && !callerIs(stub, res.SB_name) {
+			problem = "Only the buyer bank or the seller bank of Payment " + paymentId + " can refund it"
+		} else if dispute.PaymentID != "" && paymentId != dispute.PaymentID {
+			problem = "Only Payment " + dispute.PaymentID + " can be refunded for this dispute"
+		} else if res.PaymentStatus != PaymentSettledStatus {
+			problem = "Only a settled Payment can be refunded, status is " + res.PaymentStatus
+		} else if res.OpenDispute != "" && res.OpenDispute != disputeId {
+			problem = "Payment is disputed in " + res.OpenDispute
+		} else if !ok || amount <= 0 || amount > settled - refunded {
+			problem = "Refund must be a positive amount of at most " + formatCents(settled - refunded) + " " + res.Currency
+		} else if decision == DecisionPartialRefund && amount == settled - refunded {
+			problem = "A partial refund must be less than " + formatCents(settled - refunded) + ", use refund instead"
+		}
+	}
+	transferId := paymentId + DisputeTransferInfix + disputeId
+	if problem == "" && decision != DecisionReject {
+		_, problem, err = transferFunds(stub, transferId, paymentId, res.SellerAccount, res.BuyerAccount, formatCents(amount), res.Currency, resolvedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if problem != "" {
+		errMsg := "{ \"disputeID\" : \""+disputeId+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	dispute.Decision = decision
+	dispute.Remarks = args[5]
+	dispute.ResolvedBy = callerName(stub)
+	dispute.ResolvedAt = resolvedAt
+	dispute.Dispute_status = DisputeRejectedStatus
+	if decision != DecisionReject {
+		dispute.Dispute_status = DisputeRefundedStatus
+		if decision == DecisionPartialRefund {
+			dispute.Dispute_status = DisputePartiallyRefundedStatus
+		}
+		dispute.Refunds = append(dispute.Refunds, DisputeRefund{paymentId, transferId, formatCents(amount), res.Currency})
+		res.RefundedAmount = formatCents(refunded + amount)
+		if refunded + amount == settled {
+			setPaymentStatus(&res, PaymentRefundedStatus, resolvedAt, dispute.ResolvedBy)
+		}
+		if res.AgreementChaincode != "" {
+			paymentAsBytes, _ := json.Marshal(res)
+			err = stub.PutState(res.PaymentID, paymentAsBytes)			// stored first, the Agreement chaincode reads the refund back
+			if err != nil {
+				return nil, err
+			}
+			function := "reverse_instalment_payment"
+			invokeArgs := util.ToChaincodeArgs(function, res.AgreementID, paymentId, resolvedAt, formatCents(amount))
+			result, err := stub.InvokeChaincode(res.AgreementChaincode, invokeArgs)
+			if err != nil {
+				errStr := fmt.Sprintf("Failed to reverse Payment in 'Agreement' chaincode. Got error: %s", err.Error())
+				fmt.Println(errStr)
+				return nil, errors.New(errStr)
+			}
+			fmt.Print("Agreement hash returned: ")
+			fmt.Println(result)
+		}
+	}
+	if dispute.PaymentID != "" {
+		if res.PaymentID == "" {
+			paymentAsBytes, err := stub.GetState(dispute.PaymentID)
+			if err != nil {
+				return nil, errors.New("Failed to get Payment " + dispute.PaymentID)
+			}
+			json.Unmarshal(paymentAsBytes, &res)
+		}
+		res.OpenDispute = ""
+	}
+	if res.PaymentID != "" {
+		paymentAsBytes, _ := json.Marshal(res)
+		err = stub.PutState(res.PaymentID, paymentAsBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = putDispute(stub, dispute)
+	if err != nil {
+		return nil, err
+	}
+	tosend := "{ \"disputeID\" : \""+disputeId+"\", \"message\" : \"Dispute " + dispute.Dispute_status + "\", \"code\" : \"200\"}"
+	err = stub.SetEvent("evtsender", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end resolveDispute")
+	return nil, nil
+}
+// ============================================================================================================================
+//  getDisputeByID - get the details of a dispute
+// ============================================================================================================================
+func (t *ManagePayment) getDisputeByID(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start getDisputeByID")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"disputeId\" as an argument.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	dispute, err := getDispute(stub, args[0])
+	if err != nil {
+		return nil, err
+	}
+	if dispute.DisputeID != args[0] {
+		errMsg := "{ \"message\" : \""+ args[0]+ " Not Found.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	disputeAsBytes, _ := json.Marshal(dispute)
+	fmt.Println("end getDisputeByID")
+	return disputeAsBytes, nil
+}
+// ============================================================================================================================
+//  getDisputesByAgreement - get the disputes opened on an agreement or on its payments
+// ============================================================================================================================
+func (t *ManagePayment) getDisputesByAgreement(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var err error
+	fmt.Println("start getDisputesByAgreement")
+	if len(args) != 1 {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting \"agreementId\" as an argument.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	disputeIndexAsBytes, err := stub.GetState(DisputeIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Dispute index")
+	}
+	var disputeIndex []string
+	json.Unmarshal(disputeIndexAsBytes, &disputeIndex)
+	disputes := []Dispute{}
+	for _, disputeId := range disputeIndex {
+		dispute, err := getDispute(stub, disputeId)
+		if err != nil {
+			return nil, err
+		}
+		if dispute.AgreementID == args[0] {
+			disputes = append(disputes, dispute)
+		}
+	}
+	jsonResp, _ := json.Marshal(disputes)
+	fmt.Println("end getDisputesByAgreement")
+	return jsonResp, nil
+}
+// ============================================================================================================================
+//  parseEvidence - read name and sha256 pairs into evidence, returns why they were refused
+// ============================================================================================================================
+func parseEvidence(pairs []string, addedBy string, addedAt string) ([]Evidence, string) {
+	evidence := []Evidence{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		hash := strings.ToLower(pairs[i+1])
+		decoded, err := hex.DecodeString(hash)
+		if pairs[i] == "" || err != nil || len(decoded) != sha256.Size {
+			return nil, "Evidence " + pairs[i] + " needs a name and a sha256 hash"
+		}
+		evidence = append(evidence, Evidence{pairs[i], hash, addedBy, addedAt})
+	}
+	return evidence, ""
+}
+// ============================================================================================================================
+//  getDispute - read a dispute, an empty Dispute is returned when it does not exist
+// ============================================================================================================================
+func getDispute(stub shim.ChaincodeStubInterface, disputeId string) (Dispute, error) {
+	dispute := Dispute{}
+	disputeAsBytes, err := stub.GetState(DisputePrefix + disputeId)
+	if err != nil {
+		return dispute, errors.New("Failed to get Dispute " + disputeId)
+	}
+	json.Unmarshal(disputeAsBytes, &dispute)
+	return dispute, nil
+}
+// ============================================================================================================================
+//  putDispute - store a dispute under its dispute ID
+// ============================================================================================================================
+func putDispute(stub shim.ChaincodeStubInterface, dispute Dispute) error {
+	disputeAsBytes, _ := json.Marshal(dispute)
+	return stub.PutState(DisputePrefix + dispute.DisputeID, disputeAsBytes)
+}
+// ============================================================================================================================
+//  markOverduePayments - a bank or an admin sweeps the Payments whose deadline is before asOf and that are not settled yet.
+//  asOf can't be after the transaction date. A single paymentsOverdue event lists the Payments marked by this sweep.
+// ============================================================================================================================
+func (t *ManagePayment) markOverduePayments(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	// markOverduePayments("asOf")
+	var err error
+	fmt.Println("start markOverduePayments")
+	if len(args) != 1 || !isDate(args[0]) {
+		errMsg := "{ \"message\" : \"Incorrect number of arguments. Expecting a YYYY-MM-DD date.\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	asOf := args[0]
+	today, err := txDate(stub)
+	if err != nil {
+		return nil, err
+	}
+	problem := ""
+	role, err := stub.ReadCertAttribute("role")
+	if err != nil || (string(role) != "bank" && string(role) != "admin") {
+		problem = "Only a bank or an admin can mark Payments overdue"
+	} else if asOf > today {
+		problem = "asOf can't be after the transaction date " + today
+	}
+	if problem != "" {
+		errMsg := "{ \"asOf\" : \""+asOf+"\", \"message\" : \"" + problem + "\", \"code\" : \"503\"}"
+		err = stub.SetEvent("errEvent", []byte(errMsg))
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	var paymentIndex []string
+	paymentIndexAsBytes, err := stub.GetState(PaymentIndexStr)
+	if err != nil {
+		return nil, errors.New("Failed to get Payment index")
+	}
+	json.Unmarshal(paymentIndexAsBytes, &paymentIndex)
+	overdue := []string{}
+	for _, paymentId := range paymentIndex {
+		paymentAsBytes, err := stub.GetState(paymentId)
+		if err != nil {
+			return nil, errors.New("Failed to get state for " + paymentId)
+		}
+		res := Payment{}
+		json.Unmarshal(paymentAsBytes, &res)
+		if !canMovePayment(res.PaymentStatus, PaymentOverdueStatus) || !isDate(res.PaymentDeadlineDate) || res.PaymentDeadlineDate >= asOf {
+			continue
+		}
+		setPaymentStatus(&res, PaymentOverdueStatus, asOf, "")
+		paymentAsBytes, _ = json.Marshal(res)
+		err = stub.PutState(paymentId, paymentAsBytes)
+		if err != nil {
+			return nil, err
+		}
+		overdue = append(overdue, paymentId)
+	}
+	overdueAsBytes, _ := json.Marshal(overdue)
+	tosend := "{ \"asOf\" : \""+asOf+"\", \"payments\" : " + string(overdueAsBytes) + ", \"message\" : \"" + strconv.Itoa(len(overdue)) + " Payments marked overdue\", \"code\" : \"200\"}"
+	err = stub.SetEvent("paymentsOverdue", []byte(tosend))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("end markOverduePayments")
+	return nil, nil
+}
+// ============================================================================================================================
+//  agreementChaincodeOf - the Agreement chaincode the Payments of an Agreement were created with, "" when it has none
+// ============================================================================================================================
+func agreementChaincodeOf(stub shim.ChaincodeStubInterface, agreementId string) (string, error) {
+	var paymentIndex []string
+	paymentIndexAsBytes, err := stub.GetState(PaymentIndexStr)
+	if err != nil {
+		return "", errors.New("Failed to get Payment index")
+	}
+	json.Unmarshal(paymentIndexAsBytes, &paymentIndex)
+	for _, paymentId := range paymentIndex {
+		paymentAsBytes, err := stub.GetState(paymentId)
+		if err != nil {
+			return "", errors.New("Failed to get state for " + paymentId)
+		}
+		payment := Payment{}
+		json.Unmarshal(paymentAsBytes, &payment)
+		if payment.AgreementID == agreementId && payment.AgreementChaincode != "" {
+			return payment.AgreementChaincode, nil
+		}
+	}
+	return "", nil
+}
+// ============================================================================================================================
+//  checkAgreementBalance - returns why the Agreement cannot take the Payment amount on its instalment line, or ""
+// ============================================================================================================================
+func checkAgreementBalance(stub shim.ChaincodeStubInterface, payment Payment) (string, error) {
+	f := "getAgreementBalance"
+	queryArgs := util.ToChaincodeArgs(f, payment.AgreementID)
+	balanceAsBytes, err := stub.QueryChaincode(payment.AgreementChaincode, queryArgs)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to query Agreement chaincode. Got error: %s", err.Error())
+		fmt.Println(errStr)
+		return "", errors.New(errStr)
+	}
+	balance := AgreementBalance{}
+	json.Unmarshal(balanceAsBytes, &balance)
+	amount, _ := toCents(payment.AmountTransferred)
+	outstanding, _ := toCents(balance.Outstanding)
+	if payment.InstalmentNo == "" {
+		if len(balance.Instalments) > 0 {
+			return "Agreement " + payment.AgreementID + " has an instalment schedule, the Payment needs an instalment line", nil
+		}
+		if amount > outstanding {
+			return "Amount is more than the outstanding " + balance.Outstanding + " on Agreement " + payment.AgreementID, nil
+		}
+		return "", nil
+	}
+	for _, line := range balance.Instalments {
+		if line.LineNo != payment.InstalmentNo {
+			continue
+		}
+		outstanding, _ = toCents(line.Outstanding)
+		if !line.Due {
+			return "Instalment " + line.LineNo + " is not due before " + line.Trigger, nil
+		}
+		if amount > outstanding {
+			return "Amount is more than the outstanding " + line.Outstanding + " on instalment " + line.LineNo, nil
+		}
+		return "", nil
+	}
+	return "Instalment " + payment.InstalmentNo + " Not Found on Agreement " + payment.AgreementID, nil
+}
+// ============================================================================================================================
+//  canMovePayment - whether PaymentTransitions allow a Payment to go from one status to the other
+// ============================================================================================================================
+func canMovePayment(from string, to string) bool {
+	for _, next := range PaymentTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+// ============================================================================================================================
+//  setPaymentStatus - change the status of a Payment and keep the change in its history
+// ============================================================================================================================
+func setPaymentStatus(payment *Payment, status string, at string, by string) {
+	payment.StatusHistory = append(payment.StatusHistory, StatusChange{payment.PaymentStatus, status, at, by})
+	payment.PaymentStatus = status
+}
+// ============================================================================================================================
+//  includeArchived - whether the argument at position n asks a list query for archived records too
+// ============================================================================================================================
+func includeArchived(args []string, n int) bool {
+	return len(args) > n && args[n] == IncludeArchived
+}
+// ============================================================================================================================
+//  paymentIsOpen - whether a Payment can still move money or is waiting on a hold or a dispute
+// ============================================================================================================================
+func paymentIsOpen(payment Payment) bool {
+	switch payment.PaymentStatus {
+	case PaymentInitiatedStatus, PaymentApprovedStatus, PaymentOverdueStatus, HeldScreeningStatus, LateDeliveryHoldStatus:
+		return true
+	}
+	return payment.OpenDispute != ""
+}
+// ============================================================================================================================
+//  callerName - username attribute of the caller's certificate, "" when it has none
+// ============================================================================================================================
+func callerName(stub shim.ChaincodeStubInterface) string {
+	username, err := stub.ReadCertAttribute("username")
+	if err != nil {
+		return ""
+	}
+	return string(username)
+}
+// ============================================================================================================================
+//  callerIs - whether the username attribute of the caller's certificate is party
+// ============================================================================================================================
+func callerIs(stub shim.ChaincodeStubInterface, party string) bool {
+	return party != "" && callerName(stub) == party
+}
+// ============================================================================================================================
+//  txDate - the YYYY-MM-DD date of the transaction timestamp, the latest date a client can act as of
+// ============================================================================================================================
+func txDate(stub shim.ChaincodeStubInterface) (string, error) {
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return "", errors.New("Failed to get the transaction timestamp")
+	}
+	return time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).UTC().Format(DateLayout), nil
+}
+// ============================================================================================================================
+//  isDate - whether a value is a YYYY-MM-DD date
+// ============================================================================================================================
+func isDate(value string) bool {
+	_, err := time.Parse(DateLayout, value)
+	return err == nil
+}
+// ============================================================================================================================
+//  isFullySigned - true when buyer, seller and both banks have signed the agreement
+// ============================================================================================================================
+func isFullySigned(agreement Agreement) bool {
+	return agreement.Buyer_sign == "true" && agreement.Seller_sign == "true" &&
+		agreement.BuyerBank_sign == "true" && agreement.SellerBank_sign == "true"
+}
+// ============================================================================================================================
+//  complianceUser - name of the caller when its certificate carries the compliance role
+// ============================================================================================================================
+func complianceUser(stub shim.ChaincodeStubInterface) (string, bool) {
+	role, err := stub.ReadCertAttribute("role")
+	if err != nil || string(role) != "compliance" {
+		return "", false
+	}
+	username, err := stub.ReadCertAttribute("username")
+	if err != nil {
+		return "", false
+	}
+	return string(username), true
+}